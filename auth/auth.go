@@ -0,0 +1,157 @@
+// Package auth issues and verifies the JWT bearer tokens of the API, signed with HS256 or RS256.
+package auth
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"os"
+	"time"
+
+	"student-management-system/config"
+	"student-management-system/errors"
+	"student-management-system/models"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// claims are the registered claims of a token and the role of its subject.
+type claims struct {
+	jwt.RegisteredClaims
+	Role string `json:"role,omitempty"`
+}
+
+type tokens struct {
+	hmacSecret []byte
+	publicKeys map[string]*rsa.PublicKey
+	signingKey *rsa.PrivateKey
+	keyID      string
+	issuer     string
+	audience   string
+	ttl        time.Duration
+	now        func() time.Time
+}
+
+// New loads the keys named by cfg. The public half of the signing key is trusted under its key ID even when the
+// JWKS file does not list it.
+func New(cfg *config.Auth) (tokens, error) {
+	t := tokens{hmacSecret: []byte(cfg.HMACSecret), publicKeys: map[string]*rsa.PublicKey{}, keyID: cfg.SigningKeyID,
+		issuer: cfg.Issuer, audience: cfg.Audience, ttl: cfg.TokenTTL, now: time.Now}
+
+	if cfg.JWKSFile != "" {
+		keys, err := loadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return tokens{}, err
+		}
+
+		t.publicKeys = keys
+	}
+
+	if cfg.SigningKeyFile != "" {
+		pem, err := os.ReadFile(cfg.SigningKeyFile)
+		if err != nil {
+			return tokens{}, err
+		}
+
+		if t.signingKey, err = jwt.ParseRSAPrivateKeyFromPEM(pem); err != nil {
+			return tokens{}, fmt.Errorf("signing key %s: %w", cfg.SigningKeyFile, err)
+		}
+
+		t.publicKeys[t.keyID] = &t.signingKey.PublicKey
+	}
+
+	return t, nil
+}
+
+// CanIssue reports whether tokens can be issued, which needs a signing key or an HMAC secret.
+func (t tokens) CanIssue() bool {
+	return t.signingKey != nil || len(t.hmacSecret) > 0
+}
+
+// Issue returns a token for subject in role that expires after the configured lifetime, signed with RS256 when
+// there is a signing key and HS256 otherwise.
+func (t tokens) Issue(subject, role string) (models.Token, error) {
+	now := t.now()
+	claims := claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			Issuer:    t.issuer,
+			Audience:  jwt.ClaimStrings{t.audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(t.ttl)),
+		},
+		Role: role,
+	}
+
+	var (
+		signed string
+		err    error
+	)
+
+	switch {
+	case t.signingKey != nil:
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = t.keyID
+		signed, err = token.SignedString(t.signingKey)
+	case len(t.hmacSecret) > 0:
+		signed, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(t.hmacSecret)
+	default:
+		return models.Token{}, errors.Internal{Err: fmt.Errorf("no key to sign tokens with")}
+	}
+
+	if err != nil {
+		return models.Token{}, errors.Internal{Err: err}
+	}
+
+	return models.Token{AccessToken: signed, TokenType: "Bearer", ExpiresIn: int(t.ttl.Seconds())}, nil
+}
+
+// Verify checks the signature and the claims of a token and returns the principal it was issued to, in the role
+// of its role claim. Tokens must expire, and carry the configured issuer and audience when those are set.
+func (t tokens) Verify(token string) (models.Principal, error) {
+	var claims claims
+
+	_, err := jwt.ParseWithClaims(token, &claims, t.key, jwt.WithValidMethods([]string{"HS256", "RS256"}),
+		jwt.WithoutClaimsValidation())
+	if err != nil {
+		return models.Principal{}, errors.Unauthenticated{Reason: "invalid token"}
+	}
+
+	now := t.now()
+
+	switch {
+	case !claims.VerifyExpiresAt(now, true):
+		return models.Principal{}, errors.Unauthenticated{Reason: "token has expired"}
+	case !claims.VerifyNotBefore(now, false):
+		return models.Principal{}, errors.Unauthenticated{Reason: "token is not valid yet"}
+	case t.issuer != "" && !claims.VerifyIssuer(t.issuer, true):
+		return models.Principal{}, errors.Unauthenticated{Reason: "token has the wrong issuer"}
+	case t.audience != "" && !claims.VerifyAudience(t.audience, true):
+		return models.Principal{}, errors.Unauthenticated{Reason: "token has the wrong audience"}
+	case claims.Subject == "":
+		return models.Principal{}, errors.Unauthenticated{Reason: "token has no subject"}
+	}
+
+	return models.Principal{Subject: claims.Subject, Role: claims.Role}, nil
+}
+
+// key picks the key that verifies a token: the HMAC secret for HS256, and the public key named by the kid
+// header for RS256.
+func (t tokens) key(token *jwt.Token) (interface{}, error) {
+	if token.Method.Alg() == jwt.SigningMethodHS256.Alg() {
+		if len(t.hmacSecret) == 0 {
+			return nil, fmt.Errorf("hs256 tokens are not accepted")
+		}
+
+		return t.hmacSecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok := t.publicKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"student-management-system/config"
+	"student-management-system/errors"
+	"student-management-system/models"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const secret = "0123456789abcdef0123456789abcdef"
+
+func now() time.Time {
+	return time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+}
+
+// keyFiles writes an RSA private key as PEM and its public half as a JWKS, and returns their paths.
+func keyFiles(t *testing.T, key *rsa.PrivateKey, kid string) (string, string) {
+	dir := t.TempDir()
+	pemFile, jwksFile := filepath.Join(dir, "key.pem"), filepath.Join(dir, "jwks.json")
+
+	der := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(pemFile, der, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	jwks, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{
+		{"kty": "EC", "kid": "other", "crv": "P-256"},
+		{"kty": "RSA", "kid": kid, "use": "sig", "n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())},
+	}})
+	if err := os.WriteFile(jwksFile, jwks, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return pemFile, jwksFile
+}
+
+func newTokens(t *testing.T, cfg config.Auth) tokens {
+	cfg.Issuer, cfg.Audience, cfg.TokenTTL = "sms", "sms", time.Hour
+
+	tk, err := New(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tk.now = now
+
+	return tk
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.RegisteredClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func TestIssueAndVerify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pemFile, jwksFile := keyFiles(t, key, "k1")
+
+	testcases := []struct {
+		desc   string
+		issuer config.Auth
+		verify config.Auth
+	}{
+		{desc: "hs256", issuer: config.Auth{HMACSecret: secret}, verify: config.Auth{HMACSecret: secret}},
+		{desc: "rs256 verified with the signing key", issuer: config.Auth{SigningKeyFile: pemFile, SigningKeyID: "k1"},
+			verify: config.Auth{SigningKeyFile: pemFile, SigningKeyID: "k1"}},
+		{desc: "rs256 verified with the jwks", issuer: config.Auth{SigningKeyFile: pemFile, SigningKeyID: "k1"},
+			verify: config.Auth{JWKSFile: jwksFile}},
+	}
+
+	for i, tc := range testcases {
+		token, err := newTokens(t, tc.issuer).Issue("admin", "registrar")
+		if err != nil {
+			t.Errorf("testcases %d failed unexpected error %v", i+1, err)
+
+			continue
+		}
+
+		if token.TokenType != "Bearer" || token.ExpiresIn != 3600 {
+			t.Errorf("testcases %d failed got %v", i+1, token)
+		}
+
+		exp := models.Principal{Subject: "admin", Role: "registrar"}
+
+		p, err := newTokens(t, tc.verify).Verify(token.AccessToken)
+		if err != nil || p != exp {
+			t.Errorf("testcases %d failed expected %v got %v, %v", i+1, exp, p, err)
+		}
+	}
+}
+
+func TestVerify_Rejects(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, jwksFile := keyFiles(t, key, "k1")
+	tk := newTokens(t, config.Auth{HMACSecret: secret, JWKSFile: jwksFile})
+	rsOnly := newTokens(t, config.Auth{JWKSFile: jwksFile})
+
+	valid := jwt.RegisteredClaims{Subject: "admin", Issuer: "sms", Audience: jwt.ClaimStrings{"sms"},
+		ExpiresAt: jwt.NewNumericDate(now().Add(time.Minute))}
+	with := func(change func(c *jwt.RegisteredClaims)) jwt.RegisteredClaims {
+		c := valid
+		change(&c)
+
+		return c
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)})
+	none, _ := jwt.NewWithClaims(jwt.SigningMethodNone, valid).SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+	testcases := []struct {
+		desc   string
+		tokens tokens
+		token  string
+		expErr error
+	}{
+		{desc: "garbage", tokens: tk, token: "not-a-token", expErr: errors.Unauthenticated{Reason: "invalid token"}},
+		{desc: "wrong secret", tokens: tk, token: sign(t, jwt.SigningMethodHS256, "", []byte(secret+"x"), valid),
+			expErr: errors.Unauthenticated{Reason: "invalid token"}},
+		{desc: "unsigned", tokens: tk, token: none, expErr: errors.Unauthenticated{Reason: "invalid token"}},
+		{desc: "hs256 signed with the public key", tokens: rsOnly, token: sign(t, jwt.SigningMethodHS256, "k1", publicPEM, valid),
+			expErr: errors.Unauthenticated{Reason: "invalid token"}},
+		{desc: "unknown key id", tokens: tk, token: sign(t, jwt.SigningMethodRS256, "k2", key, valid),
+			expErr: errors.Unauthenticated{Reason: "invalid token"}},
+		{desc: "expired", tokens: tk, token: sign(t, jwt.SigningMethodRS256, "k1", key, with(func(c *jwt.RegisteredClaims) {
+			c.ExpiresAt = jwt.NewNumericDate(now().Add(-time.Second))
+		})), expErr: errors.Unauthenticated{Reason: "token has expired"}},
+		{desc: "no expiry", tokens: tk, token: sign(t, jwt.SigningMethodHS256, "", []byte(secret), with(func(c *jwt.RegisteredClaims) {
+			c.ExpiresAt = nil
+		})), expErr: errors.Unauthenticated{Reason: "token has expired"}},
+		{desc: "not valid yet", tokens: tk, token: sign(t, jwt.SigningMethodHS256, "", []byte(secret), with(func(c *jwt.RegisteredClaims) {
+			c.NotBefore = jwt.NewNumericDate(now().Add(time.Minute))
+		})), expErr: errors.Unauthenticated{Reason: "token is not valid yet"}},
+		{desc: "wrong issuer", tokens: tk, token: sign(t, jwt.SigningMethodHS256, "", []byte(secret), with(func(c *jwt.RegisteredClaims) {
+			c.Issuer = "someone-else"
+		})), expErr: errors.Unauthenticated{Reason: "token has the wrong issuer"}},
+		{desc: "wrong audience", tokens: tk, token: sign(t, jwt.SigningMethodHS256, "", []byte(secret), with(func(c *jwt.RegisteredClaims) {
+			c.Audience = jwt.ClaimStrings{"someone-else"}
+		})), expErr: errors.Unauthenticated{Reason: "token has the wrong audience"}},
+		{desc: "no subject", tokens: tk, token: sign(t, jwt.SigningMethodRS256, "k1", key, with(func(c *jwt.RegisteredClaims) {
+			c.Subject = ""
+		})), expErr: errors.Unauthenticated{Reason: "token has no subject"}},
+	}
+
+	for i, tc := range testcases {
+		_, err := tc.tokens.Verify(tc.token)
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
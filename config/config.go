@@ -0,0 +1,406 @@
+// Package config loads the application settings.
+//
+// Every setting has a default and can be overridden, in increasing order of precedence, by a YAML file, an
+// environment variable and a command-line flag. The file is chosen with -config or CONFIG_FILE. Run the
+// binary with -help to list every flag together with its environment variable.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/nyaruka/phonenumbers"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	maxPort = 65535
+
+	// minHMACSecret is the shortest HS256 secret accepted, as long as the SHA-256 output.
+	minHMACSecret = 32
+)
+
+type Config struct {
+	Database   Database   `yaml:"database"`
+	HTTP       HTTP       `yaml:"http"`
+	GRPC       GRPC       `yaml:"grpc"`
+	Validation Validation `yaml:"validation"`
+	Trash      Trash      `yaml:"trash"`
+	Auth       Auth       `yaml:"auth"`
+	Encryption Encryption `yaml:"encryption"`
+}
+
+type Database struct {
+	Host            string        `yaml:"host"`
+	Port            int           `yaml:"port"`
+	User            string        `yaml:"user"`
+	Password        Secret        `yaml:"password"`
+	Name            string        `yaml:"name"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnectTimeout  time.Duration `yaml:"connect_timeout"`
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	PingTimeout     time.Duration `yaml:"ping_timeout"`
+	PingBackoff     time.Duration `yaml:"ping_backoff"`
+	PingMaxBackoff  time.Duration `yaml:"ping_max_backoff"`
+	MigrateOnStart  bool          `yaml:"migrate_on_start"`
+}
+
+type HTTP struct {
+	Address      string        `yaml:"address"`
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout"`
+	DrainTimeout time.Duration `yaml:"drain_timeout"`
+	TLS          TLS           `yaml:"tls"`
+	// RequireIfMatch rejects updates and deletes without an If-Match header with 428 Precondition Required.
+	RequireIfMatch bool `yaml:"require_if_match"`
+	// MaxBodySize caps in bytes the body of a request that creates, replaces or patches one student, answering
+	// larger ones with 413 Payload Too Large.
+	MaxBodySize int `yaml:"max_body_size"`
+}
+
+// GRPC configures the gRPC server of the student service, which shares the TLS certificate and the drain timeout
+// of the HTTP server.
+type GRPC struct {
+	// Address is where the gRPC server listens, empty to serve HTTP only.
+	Address string `yaml:"address"`
+}
+
+type TLS struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+type Validation struct {
+	// MaxLength caps the length in characters of the free text fields of a student, keyed by json field name.
+	MaxLength map[string]int `yaml:"max_length"`
+	// MinAge and MaxAge bound the age in whole years, on the day of admission, of a student with a dob.
+	MinAge int `yaml:"min_age"`
+	MaxAge int `yaml:"max_age"`
+	// PhoneRegion is the ISO 3166 region assumed for contact numbers given without a +country code.
+	PhoneRegion string `yaml:"phone_region"`
+}
+
+// Trash controls how long deleted students can be restored before they are purged for good.
+type Trash struct {
+	Retention time.Duration `yaml:"retention"`
+	// PurgeInterval is how often deleted students past the retention are purged, 0 to never purge.
+	PurgeInterval time.Duration `yaml:"purge_interval"`
+}
+
+// Auth configures the bearer tokens required by the /student routes. Tokens are verified with the HMAC secret
+// (HS256) or the RSA public keys of the JWKS file (RS256), and issued by /login with the signing key, or the
+// HMAC secret when there is none.
+type Auth struct {
+	// Disabled leaves the API open to anyone who can reach it, for local development only: the servers must listen
+	// on loopback addresses, and every request runs in AnonymousRole.
+	Disabled       bool          `yaml:"disabled"`
+	AnonymousRole  string        `yaml:"anonymous_role"`
+	HMACSecret     Secret        `yaml:"hmac_secret"`
+	JWKSFile       string        `yaml:"jwks_file"`
+	SigningKeyFile string        `yaml:"signing_key_file"`
+	SigningKeyID   string        `yaml:"signing_key_id"`
+	Issuer         string        `yaml:"issuer"`
+	Audience       string        `yaml:"audience"`
+	TokenTTL       time.Duration `yaml:"token_ttl"`
+	// Roles grants each role, named in the role claim of a token, its permissions such as "student:read".
+	Roles map[string][]string `yaml:"roles"`
+	// Redact hides fields of students, by json name, from a role. A field suffixed with ":mask" shows only its
+	// last characters instead of being omitted.
+	Redact map[string][]string `yaml:"redact"`
+}
+
+// Encryption configures the encryption at rest of the sensitive columns of students.
+type Encryption struct {
+	// KeyFile holds the key-encryption keys and the blind index key, see the envelope package.
+	KeyFile string `yaml:"key_file"`
+}
+
+// Secret is a string that never prints its value, so a Config can be logged safely.
+type Secret string
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+
+	return "******"
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
+// Default returns the settings used when nothing overrides them.
+func Default() Config {
+	return Config{
+		Database: Database{
+			Host:            "127.0.0.1",
+			Port:            3306,
+			User:            "root",
+			Name:            "institution",
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnectTimeout:  5 * time.Second,
+			ReadTimeout:     30 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			PingTimeout:     time.Minute,
+			PingBackoff:     500 * time.Millisecond,
+			PingMaxBackoff:  10 * time.Second,
+			MigrateOnStart:  true,
+		},
+		HTTP: HTTP{
+			Address:      ":9090",
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 15 * time.Second,
+			IdleTimeout:  60 * time.Second,
+			DrainTimeout: 30 * time.Second,
+			MaxBodySize:  64 << 10,
+		},
+		Validation: Validation{
+			MaxLength: map[string]int{
+				"first_name":        50,
+				"last_name":         50,
+				"mother_tongue":     50,
+				"nationality":       50,
+				"father_name":       50,
+				"mother_name":       50,
+				"father_occupation": 50,
+				"mother_occupation": 50,
+			},
+			MinAge:      3,
+			MaxAge:      60,
+			PhoneRegion: "IN",
+		},
+		Trash: Trash{
+			Retention:     30 * 24 * time.Hour,
+			PurgeInterval: time.Hour,
+		},
+		Auth: Auth{
+			AnonymousRole: "readonly",
+			Issuer:        "student-management-system",
+			Audience:      "student-management-system",
+			TokenTTL:      time.Hour,
+			Roles: map[string][]string{
+				"admin": {"student:read", "student:create", "student:update", "student:delete", "student:trash",
+					"student:history", "student:purge"},
+				"registrar": {"student:read", "student:create", "student:update", "student:delete", "student:trash",
+					"student:history"},
+				"teacher":  {"student:read"},
+				"parent":   {"student:read:own"},
+				"readonly": {"student:read"},
+			},
+			Redact: map[string][]string{
+				"teacher": {"family_income", "father_occupation", "mother_occupation", "contact_number:mask",
+					"home_contact_number:mask", "emergency_contact_number:mask"},
+				"readonly": {"family_income", "father_occupation", "mother_occupation", "contact_number:mask",
+					"home_contact_number:mask", "emergency_contact_number:mask"},
+			},
+		},
+	}
+}
+
+// Load builds the configuration from args (without the program name) and the environment, as seen through
+// lookupEnv. It returns the arguments left after the flags, e.g. a subcommand.
+func Load(args []string, lookupEnv func(string) (string, bool)) (Config, []string, error) {
+	cfg := Default()
+	settings := bindings(&cfg)
+
+	fs := flag.NewFlagSet("student-management-system", flag.ContinueOnError)
+	file := fs.String("config", "", "path to a YAML config file (env CONFIG_FILE)")
+
+	for _, s := range settings {
+		fs.String(s.flag, "", s.usage+" (env "+s.env+")")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+
+	if *file == "" {
+		*file, _ = lookupEnv("CONFIG_FILE")
+	}
+
+	if *file != "" {
+		if err := loadFile(*file, &cfg); err != nil {
+			return Config{}, nil, err
+		}
+	}
+
+	for _, s := range settings {
+		if v, ok := lookupEnv(s.env); ok {
+			if err := s.set(v); err != nil {
+				return Config{}, nil, fmt.Errorf("env %s: %w", s.env, err)
+			}
+		}
+	}
+
+	var err error
+
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name && err == nil {
+				if setErr := s.set(f.Value.String()); setErr != nil {
+					err = fmt.Errorf("flag -%s: %w", s.flag, setErr)
+				}
+			}
+		}
+	})
+
+	if err != nil {
+		return Config{}, nil, err
+	}
+
+	return cfg, fs.Args(), cfg.Validate()
+}
+
+func loadFile(file string, cfg *Config) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+
+	if err := dec.Decode(cfg); err != nil {
+		return fmt.Errorf("config file %s: %w", file, err)
+	}
+
+	return nil
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var problems []string
+
+	check := func(ok bool, problem string) {
+		if !ok {
+			problems = append(problems, problem)
+		}
+	}
+
+	db := c.Database
+	check(db.Host != "", "database host is required")
+	check(db.Port > 0 && db.Port <= maxPort, "database port must be between 1 and 65535")
+	check(db.User != "", "database user is required")
+	check(db.Name != "", "database name is required")
+	check(db.MaxOpenConns >= 0, "database max open connections must not be negative")
+	check(db.MaxIdleConns >= 0, "database max idle connections must not be negative")
+	check(db.MaxOpenConns == 0 || db.MaxIdleConns <= db.MaxOpenConns,
+		"database max idle connections must not exceed max open connections")
+	check(db.ConnMaxLifetime >= 0 && db.ConnectTimeout >= 0 && db.ReadTimeout >= 0 && db.WriteTimeout >= 0,
+		"database timeouts must not be negative")
+	check(db.PingTimeout > 0 && db.PingBackoff > 0 && db.PingMaxBackoff >= db.PingBackoff,
+		"database ping timeout and backoff must be positive and the max backoff at least the initial backoff")
+
+	h := c.HTTP
+	_, _, err := net.SplitHostPort(h.Address)
+	check(err == nil, "http address must be host:port")
+	check(h.ReadTimeout >= 0 && h.WriteTimeout >= 0 && h.IdleTimeout >= 0 && h.DrainTimeout >= 0,
+		"http timeouts must not be negative")
+	check(h.MaxBodySize > 0, "http max body size must be positive")
+	check((h.TLS.CertFile == "") == (h.TLS.KeyFile == ""), "tls cert file and key file must be set together")
+
+	if c.GRPC.Address != "" {
+		_, _, err = net.SplitHostPort(c.GRPC.Address)
+		check(err == nil, "grpc address must be host:port")
+		check(c.GRPC.Address != h.Address, "grpc address must differ from the http address")
+	}
+
+	check(c.Validation.MinAge >= 0 && c.Validation.MaxAge >= c.Validation.MinAge,
+		"validation age range must not be negative or inverted")
+
+	check(phonenumbers.GetCountryCodeForRegion(c.Validation.PhoneRegion) != 0,
+		"validation phone region must be a supported ISO 3166 region code")
+
+	for field, n := range c.Validation.MaxLength {
+		check(n > 0, "validation max length of "+field+" must be positive")
+	}
+
+	check(c.Trash.Retention >= 0 && c.Trash.PurgeInterval >= 0, "trash retention and purge interval must not be negative")
+
+	a := c.Auth
+	check(a.Disabled || a.HMACSecret != "" || a.JWKSFile != "", "auth needs an hmac secret or a jwks file unless disabled")
+	check(a.HMACSecret == "" || len(a.HMACSecret) >= minHMACSecret, "auth hmac secret must be at least 32 bytes")
+	check((a.SigningKeyFile == "") == (a.SigningKeyID == ""), "auth signing key file and key id must be set together")
+	check(a.TokenTTL > 0, "auth token ttl must be positive")
+
+	if a.Disabled {
+		_, ok := a.Roles[a.AnonymousRole]
+		check(ok, "auth anonymous role must be one of the roles")
+		check(loopback(h.Address) && (c.GRPC.Address == "" || loopback(c.GRPC.Address)),
+			"auth can only be disabled when the http and grpc addresses are loopback addresses")
+	}
+
+	check(c.Encryption.KeyFile != "", "encryption key file is required")
+
+	if len(problems) > 0 {
+		return errors.New("invalid config: " + strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// loopback reports whether address listens only on the loopback interface, e.g. 127.0.0.1:9090 or
+// localhost:9090. An address without a host, such as :9090, listens on every interface.
+func loopback(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
+
+// DSN returns the go-sql-driver/mysql data source name for the database settings.
+func (d *Database) DSN() string {
+	cfg := mysql.NewConfig()
+	cfg.User = d.User
+	cfg.Passwd = string(d.Password)
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(d.Host, strconv.Itoa(d.Port))
+	cfg.DBName = d.Name
+	cfg.Timeout = d.ConnectTimeout
+	cfg.ReadTimeout = d.ReadTimeout
+	cfg.WriteTimeout = d.WriteTimeout
+	cfg.ParseTime = true
+
+	return cfg.FormatDSN()
+}
+
+// Enabled reports whether the server should listen with TLS.
+func (t TLS) Enabled() bool {
+	return t.CertFile != ""
+}
+
+// String renders the configuration with secrets redacted, for logging.
+func (c Config) String() string {
+	type plain Config
+
+	return fmt.Sprintf("%+v", plain(c))
+}
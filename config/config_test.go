@@ -0,0 +1,168 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// env serves vars as the environment, on top of the HMAC secret and the keyfile that a valid config needs.
+func env(vars map[string]string) func(string) (string, bool) {
+	required := map[string]string{"AUTH_HMAC_SECRET": "0123456789abcdef0123456789abcdef", "ENCRYPTION_KEY_FILE": "keys.json"}
+
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		if !ok {
+			v, ok = required[key]
+		}
+
+		return v, ok
+	}
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+
+	err := os.WriteFile(file, []byte("database:\n  host: file-host\n  user: file-user\n  password: file-secret\n"+
+		"  conn_max_lifetime: 10m\nhttp:\n  address: ':8000'\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		desc    string
+		args    []string
+		env     map[string]string
+		expHost string
+		expUser string
+		expAddr string
+		expLife time.Duration
+		expArgs []string
+	}{
+		{desc: "defaults", expHost: "127.0.0.1", expUser: "root", expAddr: ":9090", expLife: 30 * time.Minute},
+		{desc: "file overrides defaults", args: []string{"-config", file}, expHost: "file-host", expUser: "file-user",
+			expAddr: ":8000", expLife: 10 * time.Minute},
+		{desc: "env overrides file", env: map[string]string{"CONFIG_FILE": file, "DB_HOST": "env-host"},
+			expHost: "env-host", expUser: "file-user", expAddr: ":8000", expLife: 10 * time.Minute},
+		{desc: "flag overrides env", args: []string{"-config", file, "-db-host", "flag-host", "migrate", "up"},
+			env: map[string]string{"DB_HOST": "env-host"}, expHost: "flag-host", expUser: "file-user", expAddr: ":8000",
+			expLife: 10 * time.Minute, expArgs: []string{"migrate", "up"}},
+	}
+
+	for i, tc := range testcases {
+		cfg, args, err := Load(tc.args, env(tc.env))
+		if err != nil {
+			t.Errorf("testcases %d failed unexpected error %v", i+1, err)
+
+			continue
+		}
+
+		if cfg.Database.Host != tc.expHost || cfg.Database.User != tc.expUser || cfg.HTTP.Address != tc.expAddr ||
+			cfg.Database.ConnMaxLifetime != tc.expLife {
+			t.Errorf("testcases %d failed got %v", i+1, cfg)
+		}
+
+		if strings.Join(args, " ") != strings.Join(tc.expArgs, " ") {
+			t.Errorf("testcases %d failed expected args %v got %v", i+1, tc.expArgs, args)
+		}
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	testcases := []struct {
+		desc   string
+		args   []string
+		env    map[string]string
+		expErr string
+	}{
+		{desc: "invalid number", env: map[string]string{"DB_PORT": "abc"}, expErr: `env DB_PORT: invalid number "abc"`},
+		{desc: "invalid duration", args: []string{"-http-read-timeout", "soon"},
+			expErr: `flag -http-read-timeout: invalid duration "soon"`},
+		{desc: "inverted age range", env: map[string]string{"VALIDATION_MIN_AGE": "18", "VALIDATION_MAX_AGE": "5"},
+			expErr: "invalid config: validation age range must not be negative or inverted"},
+		{desc: "no way to verify tokens", env: map[string]string{"AUTH_HMAC_SECRET": ""},
+			expErr: "invalid config: auth needs an hmac secret or a jwks file unless disabled"},
+		{desc: "short hmac secret", env: map[string]string{"AUTH_HMAC_SECRET": "secret"},
+			expErr: "invalid config: auth hmac secret must be at least 32 bytes"},
+		{desc: "no body size", env: map[string]string{"HTTP_MAX_BODY_SIZE": "0"},
+			expErr: "invalid config: http max body size must be positive"},
+		{desc: "no keyfile", env: map[string]string{"ENCRYPTION_KEY_FILE": ""},
+			expErr: "invalid config: encryption key file is required"},
+		{desc: "grpc on the http address", env: map[string]string{"GRPC_ADDRESS": ":9090"},
+			expErr: "invalid config: grpc address must differ from the http address"},
+		{desc: "auth disabled on every interface", env: map[string]string{"AUTH_DISABLED": "true"},
+			expErr: "invalid config: auth can only be disabled when the http and grpc addresses are loopback addresses"},
+		{desc: "auth disabled with grpc on every interface",
+			env:    map[string]string{"AUTH_DISABLED": "true", "HTTP_ADDRESS": "127.0.0.1:9090", "GRPC_ADDRESS": ":9091"},
+			expErr: "invalid config: auth can only be disabled when the http and grpc addresses are loopback addresses"},
+		{desc: "unknown anonymous role",
+			env:    map[string]string{"AUTH_DISABLED": "true", "HTTP_ADDRESS": "localhost:9090", "AUTH_ANONYMOUS_ROLE": "root"},
+			expErr: "invalid config: auth anonymous role must be one of the roles"},
+		{desc: "missing file", args: []string{"-config", "does-not-exist.yaml"}, expErr: "open does-not-exist.yaml"},
+		{desc: "validation", env: map[string]string{"DB_HOST": "", "DB_MAX_OPEN_CONNS": "2", "TLS_CERT_FILE": "cert.pem"},
+			expErr: "invalid config: database host is required; database max idle connections must not exceed max open " +
+				"connections; tls cert file and key file must be set together"},
+	}
+
+	for i, tc := range testcases {
+		_, _, err := Load(tc.args, env(tc.env))
+
+		if err == nil || !strings.HasPrefix(err.Error(), tc.expErr) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
+
+func TestLoad_AuthDisabled(t *testing.T) {
+	cfg, _, err := Load(nil, env(map[string]string{"AUTH_DISABLED": "true", "HTTP_ADDRESS": "127.0.0.1:9090",
+		"GRPC_ADDRESS": "[::1]:9091"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Auth.AnonymousRole != "readonly" {
+		t.Errorf("expected the anonymous role %v got %v", "readonly", cfg.Auth.AnonymousRole)
+	}
+}
+
+func TestString_RedactsSecrets(t *testing.T) {
+	cfg, _, err := Load(nil, env(map[string]string{"DB_PASSWORD": "Dpyadav@123"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := cfg.String(); strings.Contains(s, "Dpyadav@123") || strings.Contains(s, "0123456789abcdef") ||
+		!strings.Contains(s, "Password:******") {
+		t.Errorf("expected secrets to be redacted got %s", s)
+	}
+
+	if dsn := cfg.Database.DSN(); !strings.HasPrefix(dsn, "root:Dpyadav@123@tcp(127.0.0.1:3306)/institution") {
+		t.Errorf("expected the real password in the dsn got %s", dsn)
+	}
+}
+
+func TestLoad_MaxLength(t *testing.T) {
+	cfg, _, err := Load([]string{"-validation-max-length", "first_name=80,last_name=30"}, env(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m := cfg.Validation.MaxLength; m["first_name"] != 80 || m["last_name"] != 30 || m["nationality"] != 50 {
+		t.Errorf("expected the flag to be merged into the defaults got %v", m)
+	}
+}
+
+func TestLoad_Roles(t *testing.T) {
+	cfg, _, err := Load(nil, env(map[string]string{"AUTH_ROLES": "teacher=student:read student:history,auditor=student:history"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roles := cfg.Auth.Roles
+	if strings.Join(roles["teacher"], " ") != "student:read student:history" || strings.Join(roles["auditor"], " ") !=
+		"student:history" || strings.Join(roles["parent"], " ") != "student:read:own" {
+		t.Errorf("expected the env to be merged into the default roles got %v", roles)
+	}
+}
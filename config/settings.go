@@ -0,0 +1,135 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setting ties a configuration field to its flag and environment variable.
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	field interface{}
+}
+
+func bindings(c *Config) []setting {
+	return []setting{
+		{"db-host", "DB_HOST", "database host", &c.Database.Host},
+		{"db-port", "DB_PORT", "database port", &c.Database.Port},
+		{"db-user", "DB_USER", "database user", &c.Database.User},
+		{"db-password", "DB_PASSWORD", "database password", &c.Database.Password},
+		{"db-name", "DB_NAME", "database name", &c.Database.Name},
+		{"db-max-open-conns", "DB_MAX_OPEN_CONNS", "maximum open database connections, 0 for no limit", &c.Database.MaxOpenConns},
+		{"db-max-idle-conns", "DB_MAX_IDLE_CONNS", "maximum idle database connections", &c.Database.MaxIdleConns},
+		{"db-conn-max-lifetime", "DB_CONN_MAX_LIFETIME", "maximum lifetime of a database connection", &c.Database.ConnMaxLifetime},
+		{"db-connect-timeout", "DB_CONNECT_TIMEOUT", "database dial timeout", &c.Database.ConnectTimeout},
+		{"db-read-timeout", "DB_READ_TIMEOUT", "database read timeout", &c.Database.ReadTimeout},
+		{"db-write-timeout", "DB_WRITE_TIMEOUT", "database write timeout", &c.Database.WriteTimeout},
+		{"db-ping-timeout", "DB_PING_TIMEOUT", "how long to keep retrying the startup ping", &c.Database.PingTimeout},
+		{"db-ping-backoff", "DB_PING_BACKOFF", "initial wait between startup ping attempts", &c.Database.PingBackoff},
+		{"db-ping-max-backoff", "DB_PING_MAX_BACKOFF", "maximum wait between startup ping attempts", &c.Database.PingMaxBackoff},
+		{"db-migrate-on-start", "DB_MIGRATE_ON_START", "apply pending migrations at startup", &c.Database.MigrateOnStart},
+		{"http-address", "HTTP_ADDRESS", "address the HTTP server listens on", &c.HTTP.Address},
+		{"http-read-timeout", "HTTP_READ_TIMEOUT", "HTTP request read timeout", &c.HTTP.ReadTimeout},
+		{"http-write-timeout", "HTTP_WRITE_TIMEOUT", "HTTP response write timeout", &c.HTTP.WriteTimeout},
+		{"http-idle-timeout", "HTTP_IDLE_TIMEOUT", "HTTP keep-alive idle timeout", &c.HTTP.IdleTimeout},
+		{"http-drain-timeout", "HTTP_DRAIN_TIMEOUT", "how long in-flight requests may run after SIGINT or SIGTERM", &c.HTTP.DrainTimeout},
+		{"http-require-if-match", "HTTP_REQUIRE_IF_MATCH", "reject PUT, PATCH and DELETE without an If-Match header", &c.HTTP.RequireIfMatch},
+		{"http-max-body-size", "HTTP_MAX_BODY_SIZE", "maximum size in bytes of the body of a single student", &c.HTTP.MaxBodySize},
+		{"grpc-address", "GRPC_ADDRESS", "address the gRPC server listens on, empty to serve HTTP only", &c.GRPC.Address},
+		{"tls-cert-file", "TLS_CERT_FILE", "TLS certificate file, enables HTTPS", &c.HTTP.TLS.CertFile},
+		{"tls-key-file", "TLS_KEY_FILE", "TLS private key file", &c.HTTP.TLS.KeyFile},
+		{"trash-retention", "TRASH_RETENTION", "how long deleted students can be restored", &c.Trash.Retention},
+		{"trash-purge-interval", "TRASH_PURGE_INTERVAL", "how often expired deleted students are purged, 0 to never purge", &c.Trash.PurgeInterval},
+		{"validation-min-age", "VALIDATION_MIN_AGE", "minimum age in years at admission", &c.Validation.MinAge},
+		{"validation-max-age", "VALIDATION_MAX_AGE", "maximum age in years at admission", &c.Validation.MaxAge},
+		{"validation-phone-region", "VALIDATION_PHONE_REGION", "region of contact numbers given without a country code", &c.Validation.PhoneRegion},
+		{"auth-disabled", "AUTH_DISABLED", "serve the API without authentication, for local development only", &c.Auth.Disabled},
+		{"auth-anonymous-role", "AUTH_ANONYMOUS_ROLE", "role of every request when authentication is disabled", &c.Auth.AnonymousRole},
+		{"auth-hmac-secret", "AUTH_HMAC_SECRET", "secret of HS256 tokens, at least 32 bytes", &c.Auth.HMACSecret},
+		{"auth-jwks-file", "AUTH_JWKS_FILE", "JSON Web Key Set of the RSA keys that verify RS256 tokens", &c.Auth.JWKSFile},
+		{"auth-signing-key-file", "AUTH_SIGNING_KEY_FILE", "PEM RSA private key that signs issued tokens with RS256", &c.Auth.SigningKeyFile},
+		{"auth-signing-key-id", "AUTH_SIGNING_KEY_ID", "kid of the signing key in the JWKS", &c.Auth.SigningKeyID},
+		{"auth-issuer", "AUTH_ISSUER", "iss claim of issued and accepted tokens", &c.Auth.Issuer},
+		{"auth-audience", "AUTH_AUDIENCE", "aud claim of issued and accepted tokens", &c.Auth.Audience},
+		{"auth-token-ttl", "AUTH_TOKEN_TTL", "lifetime of issued tokens", &c.Auth.TokenTTL},
+		{"auth-roles", "AUTH_ROLES", "permissions of roles as role=perm perm,role=perm", &c.Auth.Roles},
+		{"auth-redact", "AUTH_REDACT", "fields hidden from roles as role=field field:mask,role=field", &c.Auth.Redact},
+		{"encryption-key-file", "ENCRYPTION_KEY_FILE", "keyfile of the keys that encrypt sensitive student columns", &c.Encryption.KeyFile},
+		{"validation-max-length", "VALIDATION_MAX_LENGTH", "maximum field lengths as field=n,field=n", &c.Validation.MaxLength},
+	}
+}
+
+func (s setting) set(value string) error {
+	switch field := s.field.(type) {
+	case *string:
+		*field = value
+	case *Secret:
+		*field = Secret(value)
+	case *int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+
+		*field = n
+	case *bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+
+		*field = b
+	case *time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+
+		*field = d
+	case *map[string]int:
+		return setMap(*field, value)
+	case *map[string][]string:
+		return setLists(*field, value)
+	default:
+		return fmt.Errorf("unsupported setting type %T", s.field)
+	}
+
+	return nil
+}
+
+// setLists merges "key=a b,key=c" into m, replacing the lists of the keys mentioned and keeping the others.
+func setLists(m map[string][]string, value string) error {
+	for _, pair := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return fmt.Errorf("invalid key=value pair %q", pair)
+		}
+
+		m[k] = strings.Fields(v)
+	}
+
+	return nil
+}
+
+// setMap merges "key=n,key=n" into m, keeping the entries that are not mentioned.
+func setMap(m map[string]int, value string) error {
+	for _, pair := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return fmt.Errorf("invalid key=value pair %q", pair)
+		}
+
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid number %q for %s", v, k)
+		}
+
+		m[k] = n
+	}
+
+	return nil
+}
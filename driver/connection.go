@@ -1,19 +1,95 @@
 package driver
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"log"
+	"time"
+
+	"student-management-system/config"
 
 	_ "github.com/go-sql-driver/mysql"
 )
 
-func Connection() (*sql.DB, error) {
-	db, err := sql.Open("mysql", "root:Dpyadav@123@tcp(127.0.0.1:3306)/institution")
+type pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// Connection opens the connection pool with the configured limits and waits until the database answers a
+// ping, so the application never starts without a database.
+func Connection(ctx context.Context, cfg *config.Database) (*sql.DB, error) {
+	db, err := sql.Open("mysql", cfg.DSN())
 	if err != nil {
 		return nil, err
 	}
 
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	if err := ping(ctx, db, cfg.PingTimeout, cfg.PingBackoff, cfg.PingMaxBackoff); err != nil {
+		db.Close()
+
+		return nil, err
+	}
+
 	fmt.Println("database connected")
 
 	return db, nil
 }
+
+// ping retries the ping with exponential backoff, starting at backoff and capped at maxBackoff, until it
+// succeeds or timeout has passed.
+func ping(ctx context.Context, db pinger, timeout, backoff, maxBackoff time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+
+		log.Printf("database ping attempt %d failed: %v, retrying in %v", attempt, err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database unreachable after %d attempts: %w", attempt, err)
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// PoolStats is the JSON view of sql.DBStats.
+type PoolStats struct {
+	MaxOpenConnections int    `json:"max_open_connections"`
+	OpenConnections    int    `json:"open_connections"`
+	InUse              int    `json:"in_use"`
+	Idle               int    `json:"idle"`
+	WaitCount          int64  `json:"wait_count"`
+	WaitDuration       string `json:"wait_duration"`
+	MaxIdleClosed      int64  `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64  `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64  `json:"max_lifetime_closed"`
+}
+
+func Stats(db *sql.DB) PoolStats {
+	s := db.Stats()
+
+	return PoolStats{
+		MaxOpenConnections: s.MaxOpenConnections,
+		OpenConnections:    s.OpenConnections,
+		InUse:              s.InUse,
+		Idle:               s.Idle,
+		WaitCount:          s.WaitCount,
+		WaitDuration:       s.WaitDuration.String(),
+		MaxIdleClosed:      s.MaxIdleClosed,
+		MaxIdleTimeClosed:  s.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  s.MaxLifetimeClosed,
+	}
+}
@@ -0,0 +1,51 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakePinger struct {
+	failures int
+	calls    int
+}
+
+func (f *fakePinger) PingContext(ctx context.Context) error {
+	f.calls++
+
+	if f.calls <= f.failures {
+		return errors.New("connection refused")
+	}
+
+	return nil
+}
+
+func TestPing(t *testing.T) {
+	testcases := []struct {
+		desc     string
+		failures int
+		timeout  time.Duration
+		expCalls int
+		expErr   bool
+	}{
+		{desc: "success:first attempt", expCalls: 1, timeout: time.Second},
+		{desc: "success:after retries", failures: 3, expCalls: 4, timeout: time.Second},
+		{desc: "failure:deadline passes", failures: 1000, timeout: 20 * time.Millisecond, expErr: true},
+	}
+
+	for i, tc := range testcases {
+		db := &fakePinger{failures: tc.failures}
+
+		err := ping(context.Background(), db, tc.timeout, time.Millisecond, 4*time.Millisecond)
+
+		if (err != nil) != tc.expErr {
+			t.Errorf("testcases %d failed expected error %v got %v", i+1, tc.expErr, err)
+		}
+
+		if tc.expCalls != 0 && db.calls != tc.expCalls {
+			t.Errorf("testcases %d failed expected %d attempts got %d", i+1, tc.expCalls, db.calls)
+		}
+	}
+}
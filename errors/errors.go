@@ -0,0 +1,168 @@
+// Package errors defines the errors the service layer returns, so that callers can tell a missing student
+// from a conflict, a validation failure or an internal fault without matching on message text.
+package errors
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EntityNotFound is returned when the requested entity does not exist.
+type EntityNotFound struct {
+	Entity string
+	ID     string
+}
+
+func (e EntityNotFound) Error() string {
+	return fmt.Sprintf("no %s found for id %s", e.Entity, e.ID)
+}
+
+// EntityAlreadyExists is returned when creating or updating an entity would duplicate an existing one.
+// ID names the existing entity, when known.
+type EntityAlreadyExists struct {
+	Entity string
+	ID     string
+}
+
+func (e EntityAlreadyExists) Error() string {
+	if e.ID != "" {
+		return fmt.Sprintf("%s already exists with id %s", e.Entity, e.ID)
+	}
+
+	return e.Entity + " already exists"
+}
+
+// InvalidParam is returned when a field of the request is invalid. Field is the json name of the field.
+type InvalidParam struct {
+	Field  string
+	Reason string
+}
+
+func (e InvalidParam) Error() string {
+	if e.Reason == "" {
+		return "invalid " + e.Field
+	}
+
+	return "invalid " + e.Field + ": " + e.Reason
+}
+
+// PreconditionFailed is returned when an entity has changed since the version the caller based its update on.
+type PreconditionFailed struct {
+	Entity string
+	ID     string
+}
+
+func (e PreconditionFailed) Error() string {
+	return e.Entity + " " + e.ID + " has been modified since it was read"
+}
+
+// PreconditionRequired is returned when an update is missing the version it is based on.
+type PreconditionRequired struct {
+	Header string
+}
+
+func (e PreconditionRequired) Error() string {
+	return e.Header + " header is required"
+}
+
+// Aborted is returned for the items of an atomic batch that were rolled back or not attempted because another
+// item, at Index, failed.
+type Aborted struct {
+	Index int
+}
+
+func (e Aborted) Error() string {
+	return fmt.Sprintf("aborted by the failure of item %d", e.Index)
+}
+
+// UnsupportedMediaType is returned when a request body is in a format the endpoint does not accept.
+type UnsupportedMediaType struct {
+	MediaType string
+}
+
+func (e UnsupportedMediaType) Error() string {
+	return "unsupported media type " + e.MediaType
+}
+
+// NotAcceptable is returned when a response can be sent in none of the formats the client accepts.
+type NotAcceptable struct {
+	Accept string
+}
+
+func (e NotAcceptable) Error() string {
+	return "none of the accepted media types " + e.Accept + " can be sent"
+}
+
+// PayloadTooLarge is returned when a request body is longer than the endpoint reads.
+type PayloadTooLarge struct {
+	Limit int64
+}
+
+func (e PayloadTooLarge) Error() string {
+	return fmt.Sprintf("request body is larger than %d bytes", e.Limit)
+}
+
+// Unauthenticated is returned when a request carries no credentials or invalid ones, such as an expired token
+// or a wrong password.
+type Unauthenticated struct {
+	Reason string
+}
+
+func (e Unauthenticated) Error() string {
+	return "unauthenticated: " + e.Reason
+}
+
+// Forbidden is returned when the caller is authenticated but its role lacks the permission an operation needs.
+type Forbidden struct {
+	Permission string
+}
+
+func (e Forbidden) Error() string {
+	return "permission " + e.Permission + " is required"
+}
+
+// FieldError describes one failed validation rule. Rule is a stable, machine-readable name such as "required"
+// and Message is meant for people.
+type FieldError struct {
+	Field   string `json:"field" xml:"field"`
+	Rule    string `json:"rule" xml:"rule"`
+	Message string `json:"message" xml:"message"`
+}
+
+// Validation is returned when an entity fails validation and lists every failing field, not just the first.
+type Validation struct {
+	Errors []FieldError
+}
+
+func (e Validation) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, f := range e.Errors {
+		msgs = append(msgs, f.Message)
+	}
+
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// Internal wraps a failure the caller cannot fix, such as a database error. Its message is logged but never
+// sent to clients.
+type Internal struct {
+	Err error
+}
+
+func (e Internal) Error() string {
+	return "internal error: " + e.Err.Error()
+}
+
+func (e Internal) Unwrap() error {
+	return e.Err
+}
+
+// Response is the body of every error response.
+type Response struct {
+	Code       string       `json:"code" xml:"code"`
+	Message    string       `json:"message" xml:"message"`
+	Field      string       `json:"field,omitempty" xml:"field,omitempty"`
+	ExistingID string       `json:"existing_id,omitempty" xml:"existing_id,omitempty"`
+	RequestID  string       `json:"request_id,omitempty" xml:"request_id,omitempty"`
+	Errors     []FieldError `json:"errors,omitempty" xml:"errors>error,omitempty"`
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"student-management-system/config"
+	"student-management-system/grpc/interceptor"
+	student4 "student-management-system/grpc/student"
+	"student-management-system/pb"
+	"student-management-system/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// serveGRPC serves s over gRPC on the configured address, with the TLS certificate of the HTTP server when it has
+// one, and authenticates calls with authn. Once ctx is cancelled it stops accepting calls and gives in-flight ones
+// up to the drain timeout to finish, then closes stopped.
+func serveGRPC(ctx context.Context, cfg *config.Config, s service.Student, authn interceptor.Interceptor) (<-chan struct{}, error) {
+	opts := interceptor.ServerOptions(interceptor.RequestID(), interceptor.Log(), interceptor.Errors(), authn)
+
+	if cfg.HTTP.TLS.Enabled() {
+		creds, err := credentials.NewServerTLSFromFile(cfg.HTTP.TLS.CertFile, cfg.HTTP.TLS.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	lis, err := net.Listen("tcp", cfg.GRPC.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	srv := grpc.NewServer(opts...)
+	pb.RegisterStudentServiceServer(srv, student4.New(s))
+
+	go func() {
+		fmt.Println("grpc server started and listening on " + cfg.GRPC.Address)
+
+		if err := srv.Serve(lis); err != nil {
+			log.Println("grpc server:", err.Error())
+		}
+	}()
+
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		<-ctx.Done()
+
+		drained := make(chan struct{})
+
+		go func() {
+			srv.GracefulStop()
+			close(drained)
+		}()
+
+		select {
+		case <-drained:
+		case <-time.After(cfg.HTTP.DrainTimeout):
+			fmt.Println("grpc server did not drain in time, closing in-flight calls")
+			srv.Stop()
+		}
+
+		fmt.Println("grpc server stopped")
+	}()
+
+	return stopped, nil
+}
@@ -0,0 +1,42 @@
+package interceptor
+
+import (
+	"context"
+	"strings"
+
+	"student-management-system/errors"
+	"student-management-system/models"
+	"student-management-system/requestctx"
+)
+
+type verifier interface {
+	Verify(token string) (models.Principal, error)
+}
+
+// Authenticate fails the calls without a valid bearer token in their authorization metadata with an
+// errors.Unauthenticated, and passes the others on with the principal of their token in the context.
+func Authenticate(v verifier) Interceptor {
+	return withContext(func(ctx context.Context) (context.Context, error) {
+		scheme, token, _ := strings.Cut(first(ctx, "authorization"), " ")
+		if !strings.EqualFold(scheme, "Bearer") || token == "" {
+			return nil, errors.Unauthenticated{Reason: "a bearer token is required"}
+		}
+
+		principal, err := v.Verify(strings.TrimSpace(token))
+		if err != nil {
+			return nil, err
+		}
+
+		return requestctx.WithPrincipal(ctx, principal), nil
+	})
+}
+
+// Anonymous passes every call on as the principal "anonymous" in role, for deployments that run with
+// authentication disabled and still go through the permission checks.
+func Anonymous(role string) Interceptor {
+	principal := models.Principal{Subject: "anonymous", Role: role}
+
+	return withContext(func(ctx context.Context) (context.Context, error) {
+		return requestctx.WithPrincipal(ctx, principal), nil
+	})
+}
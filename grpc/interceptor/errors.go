@@ -0,0 +1,100 @@
+package interceptor
+
+import (
+	"context"
+	stdErrors "errors"
+	"log"
+
+	"student-management-system/errors"
+	"student-management-system/requestctx"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
+)
+
+// Errors answers every failed call with the status Status maps its error to.
+func Errors() Interceptor {
+	return Interceptor{
+		Unary: func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			res, err := handler(ctx, req)
+			if err != nil {
+				return nil, Status(ctx, err).Err()
+			}
+
+			return res, nil
+		},
+		Stream: func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := handler(srv, ss); err != nil {
+				return Status(ss.Context(), err).Err()
+			}
+
+			return nil
+		},
+	}
+}
+
+// Status maps the service errors to the status codes closest to the HTTP status apierror answers them with. The
+// invalid fields are attached as a BadRequest detail and the existing student of a conflict as a ResourceInfo.
+// A version conflict is Aborted, as gRPC asks of concurrency failures the client can retry at a higher level.
+// Internal errors are logged with the request ID and answered with a generic message.
+func Status(ctx context.Context, err error) *status.Status {
+	if s, ok := status.FromError(err); ok {
+		return s
+	}
+
+	switch e := err.(type) {
+	case errors.Unauthenticated:
+		return status.New(codes.Unauthenticated, e.Error())
+	case errors.Forbidden:
+		return status.New(codes.PermissionDenied, e.Error())
+	case errors.InvalidParam:
+		return withDetails(status.New(codes.InvalidArgument, e.Error()), &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: e.Field, Description: e.Reason}},
+		})
+	case errors.Validation:
+		details := &errdetails.BadRequest{FieldViolations: make([]*errdetails.BadRequest_FieldViolation, len(e.Errors))}
+
+		for i, f := range e.Errors {
+			details.FieldViolations[i] = &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Message}
+		}
+
+		return withDetails(status.New(codes.InvalidArgument, "validation failed"), details)
+	case errors.EntityNotFound:
+		return status.New(codes.NotFound, e.Error())
+	case errors.EntityAlreadyExists:
+		s := status.New(codes.AlreadyExists, e.Error())
+		if e.ID != "" {
+			s = withDetails(s, &errdetails.ResourceInfo{ResourceType: e.Entity, ResourceName: e.ID})
+		}
+
+		return s
+	case errors.PreconditionFailed, errors.Aborted:
+		return status.New(codes.Aborted, e.Error())
+	case errors.PreconditionRequired:
+		return status.New(codes.FailedPrecondition, e.Error())
+	}
+
+	switch {
+	case stdErrors.Is(err, context.Canceled):
+		return status.New(codes.Canceled, "the call was cancelled")
+	case stdErrors.Is(err, context.DeadlineExceeded):
+		return status.New(codes.DeadlineExceeded, "the deadline of the call was exceeded")
+	}
+
+	log.Printf("request %s: %v", requestctx.RequestID(ctx), err)
+
+	return status.New(codes.Internal, "internal server error")
+}
+
+// withDetails attaches details to s, or returns s as is in the unlikely case they cannot be marshalled.
+func withDetails(s *status.Status, details ...protoiface.MessageV1) *status.Status {
+	withDetails, err := s.WithDetails(details...)
+	if err != nil {
+		return s
+	}
+
+	return withDetails
+}
@@ -0,0 +1,69 @@
+package interceptor
+
+import (
+	"context"
+	stdErrors "errors"
+	"testing"
+
+	"student-management-system/errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestStatus(t *testing.T) {
+	testcases := []struct {
+		desc       string
+		err        error
+		expCode    codes.Code
+		expMessage string
+		expDetail  proto.Message
+	}{
+		{desc: "unauthenticated", err: errors.Unauthenticated{Reason: "a bearer token is required"}, expCode: codes.Unauthenticated,
+			expMessage: errors.Unauthenticated{Reason: "a bearer token is required"}.Error()},
+		{desc: "forbidden", err: errors.Forbidden{Permission: "student:create"}, expCode: codes.PermissionDenied,
+			expMessage: "permission student:create is required"},
+		{desc: "invalid param", err: errors.InvalidParam{Field: "dob", Reason: "must be a date in YYYY-MM-DD format"},
+			expCode: codes.InvalidArgument, expMessage: "invalid dob: must be a date in YYYY-MM-DD format",
+			expDetail: &errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{Field: "dob", Description: "must be a date in YYYY-MM-DD format"}}}},
+		{desc: "validation", err: errors.Validation{Errors: []errors.FieldError{
+			{Field: "first_name", Rule: "required", Message: "first name is required"},
+			{Field: "gender", Rule: "oneof", Message: "gender must be one of M, F, O"}}},
+			expCode: codes.InvalidArgument, expMessage: "validation failed",
+			expDetail: &errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{Field: "first_name", Description: "first name is required"},
+				{Field: "gender", Description: "gender must be one of M, F, O"}}}},
+		{desc: "not found", err: errors.EntityNotFound{Entity: "student", ID: "7"}, expCode: codes.NotFound,
+			expMessage: "no student found for id 7"},
+		{desc: "already exists", err: errors.EntityAlreadyExists{Entity: "student", ID: "3"}, expCode: codes.AlreadyExists,
+			expMessage: "student already exists with id 3", expDetail: &errdetails.ResourceInfo{ResourceType: "student", ResourceName: "3"}},
+		{desc: "version conflict", err: errors.PreconditionFailed{Entity: "student", ID: "3"}, expCode: codes.Aborted,
+			expMessage: "student 3 has been modified since it was read"},
+		{desc: "cancelled", err: errors.Internal{Err: context.Canceled}, expCode: codes.Canceled,
+			expMessage: "the call was cancelled"},
+		{desc: "internal", err: errors.Internal{Err: stdErrors.New("connection refused")}, expCode: codes.Internal,
+			expMessage: "internal server error"},
+		{desc: "already a status", err: status.Error(codes.Unavailable, "transport is closing"), expCode: codes.Unavailable,
+			expMessage: "transport is closing"},
+	}
+
+	for i, tc := range testcases {
+		s := Status(context.Background(), tc.err)
+
+		if s.Code() != tc.expCode || s.Message() != tc.expMessage {
+			t.Errorf("testcases %d failed expected %v %q got %v %q", i+1, tc.expCode, tc.expMessage, s.Code(), s.Message())
+		}
+
+		details := s.Details()
+
+		switch {
+		case tc.expDetail == nil && len(details) != 0:
+			t.Errorf("testcases %d failed expected no details got %v", i+1, details)
+		case tc.expDetail != nil && (len(details) != 1 || !proto.Equal(details[0].(proto.Message), tc.expDetail)):
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expDetail, details)
+		}
+	}
+}
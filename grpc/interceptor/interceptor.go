@@ -0,0 +1,70 @@
+// Package interceptor holds the interceptors every call to the gRPC server goes through, the counterparts of
+// the HTTP middleware: request IDs, authentication, logging and the mapping of the service errors to status
+// codes.
+package interceptor
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Interceptor intercepts both the unary and the streaming calls of a server.
+type Interceptor struct {
+	Unary  grpc.UnaryServerInterceptor
+	Stream grpc.StreamServerInterceptor
+}
+
+// ServerOptions chains interceptors into the options of a server, the first one outermost.
+func ServerOptions(interceptors ...Interceptor) []grpc.ServerOption {
+	unary := make([]grpc.UnaryServerInterceptor, len(interceptors))
+	stream := make([]grpc.StreamServerInterceptor, len(interceptors))
+
+	for i, in := range interceptors {
+		unary[i], stream[i] = in.Unary, in.Stream
+	}
+
+	return []grpc.ServerOption{grpc.ChainUnaryInterceptor(unary...), grpc.ChainStreamInterceptor(stream...)}
+}
+
+// withContext derives the context of every call with fn, failing the calls fn returns an error for.
+func withContext(fn func(ctx context.Context) (context.Context, error)) Interceptor {
+	return Interceptor{
+		Unary: func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			ctx, err := fn(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			return handler(ctx, req)
+		},
+		Stream: func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			ctx, err := fn(ss.Context())
+			if err != nil {
+				return err
+			}
+
+			return handler(srv, contextStream{ServerStream: ss, ctx: ctx})
+		},
+	}
+}
+
+// contextStream is a server stream whose context has been derived by an interceptor.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// first returns the first value of key in the metadata the client sent with the call of ctx, or "".
+func first(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
@@ -0,0 +1,194 @@
+package interceptor
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"student-management-system/errors"
+	"student-management-system/models"
+	"student-management-system/pb"
+	"student-management-system/requestctx"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// staticVerifier accepts the token "good" as the user admin.
+type staticVerifier struct{}
+
+func (staticVerifier) Verify(token string) (models.Principal, error) {
+	if token != "good" {
+		return models.Principal{}, errors.Unauthenticated{Reason: "invalid token"}
+	}
+
+	return models.Principal{Subject: "admin"}, nil
+}
+
+// recordingServer fails its calls with err, and records the actor and request ID they ran with.
+type recordingServer struct {
+	pb.UnimplementedStudentServiceServer
+	err       error
+	actor     string
+	requestID string
+}
+
+func (s *recordingServer) GetStudent(ctx context.Context, req *pb.GetStudentRequest) (*pb.Student, error) {
+	s.actor, s.requestID = requestctx.Actor(ctx), requestctx.RequestID(ctx)
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	return &pb.Student{Id: req.GetId()}, nil
+}
+
+func (s *recordingServer) ListStudents(_ *pb.ListStudentsRequest, stream pb.StudentService_ListStudentsServer) error {
+	s.actor, s.requestID = requestctx.Actor(stream.Context()), requestctx.RequestID(stream.Context())
+	if s.err != nil {
+		return s.err
+	}
+
+	return stream.Send(&pb.Student{Id: 1})
+}
+
+// dial serves srv with the interceptors of main over an in-memory connection, and returns a client of it.
+func dial(t *testing.T, srv pb.StudentServiceServer, authn Interceptor) pb.StudentServiceClient {
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer(ServerOptions(RequestID(), Log(), Errors(), authn)...)
+	pb.RegisterStudentServiceServer(s, srv)
+
+	go func() {
+		_ = s.Serve(lis)
+	}()
+
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufconn", grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return pb.NewStudentServiceClient(conn)
+}
+
+func TestAuthenticate(t *testing.T) {
+	srv := &recordingServer{}
+	client := dial(t, srv, Authenticate(staticVerifier{}))
+
+	testcases := []struct {
+		desc     string
+		header   string
+		expCode  codes.Code
+		expActor string
+	}{
+		{desc: "valid token", header: "Bearer good", expCode: codes.OK, expActor: "admin"},
+		{desc: "scheme is case insensitive", header: "bearer good", expCode: codes.OK, expActor: "admin"},
+		{desc: "no token", expCode: codes.Unauthenticated},
+		{desc: "basic credentials", header: "Basic YWRtaW46cGFzcw==", expCode: codes.Unauthenticated},
+		{desc: "invalid token", header: "Bearer bad", expCode: codes.Unauthenticated},
+	}
+
+	for i, tc := range testcases {
+		*srv = recordingServer{}
+		ctx := context.Background()
+
+		if tc.header != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", tc.header)
+		}
+
+		_, err := client.GetStudent(ctx, &pb.GetStudentRequest{Id: 1})
+
+		if status.Code(err) != tc.expCode || srv.actor != tc.expActor {
+			t.Errorf("testcases %d failed expected %v %q got %v %q", i+1, tc.expCode, tc.expActor, status.Code(err), srv.actor)
+		}
+	}
+}
+
+func TestInterceptors_Stream(t *testing.T) {
+	srv := &recordingServer{}
+	client := dial(t, srv, Anonymous("admin"))
+
+	testcases := []struct {
+		desc    string
+		err     error
+		expCode codes.Code
+	}{
+		{desc: "success", expCode: codes.OK},
+		{desc: "service error", err: errors.Forbidden{Permission: "student:trash"}, expCode: codes.PermissionDenied},
+	}
+
+	for i, tc := range testcases {
+		*srv = recordingServer{err: tc.err}
+		ctx := metadata.AppendToOutgoingContext(context.Background(), RequestIDKey, "abc")
+
+		stream, err := client.ListStudents(ctx, &pb.ListStudentsRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for err == nil {
+			_, err = stream.Recv()
+		}
+
+		if err == io.EOF {
+			err = nil
+		}
+
+		if status.Code(err) != tc.expCode {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expCode, err)
+		}
+
+		header, _ := stream.Header()
+
+		if srv.actor != "anonymous" || srv.requestID != "abc" || len(header.Get(RequestIDKey)) != 1 ||
+			header.Get(RequestIDKey)[0] != "abc" {
+			t.Errorf("testcases %d failed expected anonymous abc got %q %q %v", i+1, srv.actor, srv.requestID, header)
+		}
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	srv := &recordingServer{}
+	client := dial(t, srv, Anonymous("admin"))
+
+	testcases := []struct {
+		desc  string
+		id    string
+		expID string
+	}{
+		{desc: "reuses the client id", id: "abc", expID: "abc"},
+		{desc: "generates a missing id"},
+	}
+
+	for i, tc := range testcases {
+		ctx := context.Background()
+
+		if tc.id != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, RequestIDKey, tc.id)
+		}
+
+		var header metadata.MD
+
+		if _, err := client.GetStudent(ctx, &pb.GetStudentRequest{Id: 1}, grpc.Header(&header)); err != nil {
+			t.Fatal(err)
+		}
+
+		got := header.Get(RequestIDKey)
+
+		if len(got) != 1 || got[0] != srv.requestID || (tc.expID != "" && got[0] != tc.expID) || len(got[0]) == 0 {
+			t.Errorf("testcases %d failed expected %q got %v for the request id %q", i+1, tc.expID, got, srv.requestID)
+		}
+	}
+}
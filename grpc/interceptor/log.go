@@ -0,0 +1,36 @@
+package interceptor
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"student-management-system/requestctx"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Log logs every call once it completes, with its request ID, method, status code and duration.
+func Log() Interceptor {
+	return Interceptor{
+		Unary: func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			start := time.Now()
+			res, err := handler(ctx, req)
+			logCall(ctx, info.FullMethod, start, err)
+
+			return res, err
+		},
+		Stream: func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			start := time.Now()
+			err := handler(srv, ss)
+			logCall(ss.Context(), info.FullMethod, start, err)
+
+			return err
+		},
+	}
+}
+
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	log.Printf("grpc request %s: %s %s in %s", requestctx.RequestID(ctx), method, status.Code(err), time.Since(start))
+}
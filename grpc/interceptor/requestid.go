@@ -0,0 +1,47 @@
+package interceptor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+
+	"student-management-system/requestctx"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// RequestIDKey is the metadata key of the request ID, the X-Request-ID header of the HTTP API.
+	RequestIDKey = "x-request-id"
+
+	maxRequestIDLength = 128
+)
+
+// RequestID tags every call with an ID, reusing the one sent by the client or a proxy when present, and sends it
+// back in the response header so that a client error report can be matched with the server logs.
+func RequestID() Interceptor {
+	return withContext(func(ctx context.Context) (context.Context, error) {
+		id := first(ctx, RequestIDKey)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = newRequestID()
+		}
+
+		if err := grpc.SetHeader(ctx, metadata.Pairs(RequestIDKey, id)); err != nil {
+			log.Println(err.Error())
+		}
+
+		return requestctx.WithRequestID(ctx, id), nil
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,130 @@
+// Package student serves the student service over gRPC, see pb/student_service.proto.
+package student
+
+import (
+	"context"
+	"strings"
+
+	"student-management-system/errors"
+	"student-management-system/models"
+	"student-management-system/pb"
+	"student-management-system/service"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type server struct {
+	pb.UnimplementedStudentServiceServer
+	student service.Student
+}
+
+// New returns the gRPC server of s, to be registered with pb.RegisterStudentServiceServer.
+func New(s service.Student) server {
+	return server{student: s}
+}
+
+func (s server) CreateStudent(ctx context.Context, req *pb.CreateStudentRequest) (*pb.Student, error) {
+	student, err := pb.ToStudent(req.GetStudent())
+	if err != nil {
+		return nil, err
+	}
+
+	student, err = s.student.Post(ctx, &student)
+	if err != nil {
+		return nil, err
+	}
+
+	return pb.NewStudent(&student), nil
+}
+
+func (s server) GetStudent(ctx context.Context, req *pb.GetStudentRequest) (*pb.Student, error) {
+	student, err := s.student.GetByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+
+	return pb.NewStudent(&student), nil
+}
+
+// ListStudents sends the students as the service reads them, so a failure midway ends a stream that has already
+// carried some of them.
+func (s server) ListStudents(req *pb.ListStudentsRequest, stream pb.StudentService_ListStudentsServer) error {
+	filter, err := toFilter(req)
+	if err != nil {
+		return err
+	}
+
+	return s.student.Export(stream.Context(), filter, func(student models.Student) error {
+		return stream.Send(pb.NewStudent(&student))
+	})
+}
+
+func (s server) UpdateStudent(ctx context.Context, req *pb.UpdateStudentRequest) (*pb.Student, error) {
+	student, err := pb.ToStudent(req.GetStudent())
+	if err != nil {
+		return nil, err
+	}
+
+	id := int(req.GetId())
+
+	student, err = s.student.Put(ctx, id, &student)
+	if err != nil {
+		return nil, err
+	}
+
+	student.ID = id
+
+	return pb.NewStudent(&student), nil
+}
+
+func (s server) DeleteStudent(ctx context.Context, req *pb.DeleteStudentRequest) (*emptypb.Empty, error) {
+	if err := s.student.Delete(ctx, int(req.GetId()), int(req.GetVersion())); err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// toFilter reads the filters of a list request the way the HTTP handler reads its query parameters.
+func toFilter(req *pb.ListStudentsRequest) (*models.Filter, error) {
+	filter := models.Filter{
+		FirstName:        req.GetFirstName(),
+		LastName:         req.GetLastName(),
+		Gender:           req.GetGender(),
+		MotherTongue:     req.GetMotherTongue(),
+		Nationality:      req.GetNationality(),
+		FatherName:       req.GetFatherName(),
+		MotherName:       req.GetMotherName(),
+		ContactNumber:    req.GetContactNumber(),
+		FatherOccupation: req.GetFatherOccupation(),
+		MotherOccupation: req.GetMotherOccupation(),
+		Deleted:          req.GetDeleted(),
+	}
+
+	dates := []struct {
+		field string
+		text  string
+		value *models.Date
+	}{
+		{"dob_from", req.GetDobFrom(), &filter.DobFrom},
+		{"dob_to", req.GetDobTo(), &filter.DobTo},
+	}
+
+	for _, d := range dates {
+		if err := d.value.UnmarshalText([]byte(d.text)); err != nil {
+			return nil, errors.InvalidParam{Field: d.field, Reason: "must be a date in YYYY-MM-DD format"}
+		}
+	}
+
+	for _, field := range req.GetSort() {
+		sort := models.Sort{Field: strings.TrimSpace(field)}
+
+		if strings.HasPrefix(sort.Field, "-") {
+			sort.Field, sort.Desc = sort.Field[1:], true
+		}
+
+		filter.Sort = append(filter.Sort, sort)
+	}
+
+	return &filter, nil
+}
@@ -0,0 +1,226 @@
+package student
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"student-management-system/errors"
+	"student-management-system/models"
+	"student-management-system/pb"
+	"student-management-system/service"
+
+	"github.com/golang/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestCreateStudent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockStudent(ctrl)
+	s := New(mockService)
+	ctx := context.Background()
+
+	testcases := []struct {
+		desc   string
+		req    *pb.CreateStudentRequest
+		expReq *models.Student
+		res    models.Student
+		err    error
+		expRes *pb.Student
+		expErr error
+	}{
+		{desc: "success", req: &pb.CreateStudentRequest{Student: &pb.Student{FirstName: "arvind", Dob: "2010-04-01"}},
+			expReq: &models.Student{FirstName: "arvind", Dob: models.NewDate(2010, time.April, 1)},
+			res:    models.Student{ID: 1, FirstName: "arvind", Dob: models.NewDate(2010, time.April, 1), Version: 1},
+			expRes: &pb.Student{Id: 1, FirstName: "arvind", Dob: "2010-04-01", Version: 1}},
+		{desc: "service error", req: &pb.CreateStudentRequest{Student: &pb.Student{}}, expReq: &models.Student{},
+			err: errors.Validation{}, expErr: errors.Validation{}},
+		{desc: "invalid dob", req: &pb.CreateStudentRequest{Student: &pb.Student{Dob: "01/04/2010"}},
+			expErr: errors.InvalidParam{Field: "dob", Reason: "must be a date in YYYY-MM-DD format"}},
+	}
+
+	for i, tc := range testcases {
+		if tc.expReq != nil {
+			mockService.EXPECT().Post(ctx, tc.expReq).Return(tc.res, tc.err)
+		}
+
+		res, err := s.CreateStudent(ctx, tc.req)
+
+		if !proto.Equal(res, tc.expRes) || !reflect.DeepEqual(err, tc.expErr) {
+			t.Errorf("testcases %d failed expected %v %v got %v %v", i+1, tc.expRes, tc.expErr, res, err)
+		}
+	}
+}
+
+func TestGetStudent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockStudent(ctrl)
+	s := New(mockService)
+	ctx := context.Background()
+
+	testcases := []struct {
+		desc   string
+		id     int64
+		res    models.Student
+		err    error
+		expRes *pb.Student
+	}{
+		{desc: "success", id: 1, res: models.Student{ID: 1, LastName: "yadav", Version: 2},
+			expRes: &pb.Student{Id: 1, LastName: "yadav", Version: 2}},
+		{desc: "not found", id: 2, err: errors.EntityNotFound{Entity: "student", ID: "2"}},
+	}
+
+	for i, tc := range testcases {
+		mockService.EXPECT().GetByID(ctx, int(tc.id)).Return(tc.res, tc.err)
+
+		res, err := s.GetStudent(ctx, &pb.GetStudentRequest{Id: tc.id})
+
+		if !proto.Equal(res, tc.expRes) || !reflect.DeepEqual(err, tc.err) {
+			t.Errorf("testcases %d failed expected %v %v got %v %v", i+1, tc.expRes, tc.err, res, err)
+		}
+	}
+}
+
+// listStream collects the students sent on a ListStudents stream.
+type listStream struct {
+	grpc.ServerStream
+	sent []*pb.Student
+}
+
+func (s *listStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *listStream) Send(student *pb.Student) error {
+	s.sent = append(s.sent, student)
+
+	return nil
+}
+
+func TestListStudents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockStudent(ctrl)
+	s := New(mockService)
+
+	testcases := []struct {
+		desc      string
+		req       *pb.ListStudentsRequest
+		expFilter *models.Filter
+		students  []models.Student
+		err       error
+		expSent   []*pb.Student
+		expErr    error
+	}{
+		{desc: "filters and sort", req: &pb.ListStudentsRequest{LastName: "yadav", DobFrom: "2010-01-01", Sort: []string{"first_name", "-dob"}},
+			expFilter: &models.Filter{LastName: "yadav", DobFrom: models.NewDate(2010, time.January, 1),
+				Sort: []models.Sort{{Field: "first_name"}, {Field: "dob", Desc: true}}},
+			students: []models.Student{{ID: 1, LastName: "yadav"}, {ID: 2, LastName: "yadav"}},
+			expSent:  []*pb.Student{{Id: 1, LastName: "yadav"}, {Id: 2, LastName: "yadav"}}},
+		{desc: "trash", req: &pb.ListStudentsRequest{Deleted: true}, expFilter: &models.Filter{Deleted: true},
+			err: errors.Forbidden{Permission: "student:trash"}, expErr: errors.Forbidden{Permission: "student:trash"}},
+		{desc: "invalid dob", req: &pb.ListStudentsRequest{DobTo: "soon"},
+			expErr: errors.InvalidParam{Field: "dob_to", Reason: "must be a date in YYYY-MM-DD format"}},
+	}
+
+	for i, tc := range testcases {
+		stream := &listStream{}
+
+		if tc.expFilter != nil {
+			mockService.EXPECT().Export(stream.Context(), tc.expFilter, gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ *models.Filter, fn func(models.Student) error) error {
+					for _, student := range tc.students {
+						if err := fn(student); err != nil {
+							return err
+						}
+					}
+
+					return tc.err
+				})
+		}
+
+		err := s.ListStudents(tc.req, stream)
+
+		if !reflect.DeepEqual(err, tc.expErr) || len(stream.sent) != len(tc.expSent) {
+			t.Errorf("testcases %d failed expected %v %v got %v %v", i+1, tc.expSent, tc.expErr, stream.sent, err)
+
+			continue
+		}
+
+		for j := range tc.expSent {
+			if !proto.Equal(stream.sent[j], tc.expSent[j]) {
+				t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expSent[j], stream.sent[j])
+			}
+		}
+	}
+}
+
+func TestUpdateStudent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockStudent(ctrl)
+	s := New(mockService)
+	ctx := context.Background()
+
+	testcases := []struct {
+		desc   string
+		req    *pb.UpdateStudentRequest
+		expReq *models.Student
+		res    models.Student
+		err    error
+		expRes *pb.Student
+	}{
+		{desc: "success", req: &pb.UpdateStudentRequest{Id: 3, Student: &pb.Student{FirstName: "arvind", Version: 2}},
+			expReq: &models.Student{FirstName: "arvind", Version: 2}, res: models.Student{FirstName: "arvind", Version: 3},
+			expRes: &pb.Student{Id: 3, FirstName: "arvind", Version: 3}},
+		{desc: "version conflict", req: &pb.UpdateStudentRequest{Id: 3, Student: &pb.Student{Version: 1}},
+			expReq: &models.Student{Version: 1}, err: errors.PreconditionFailed{Entity: "student", ID: "3"}},
+	}
+
+	for i, tc := range testcases {
+		mockService.EXPECT().Put(ctx, 3, tc.expReq).Return(tc.res, tc.err)
+
+		res, err := s.UpdateStudent(ctx, tc.req)
+
+		if !proto.Equal(res, tc.expRes) || !reflect.DeepEqual(err, tc.err) {
+			t.Errorf("testcases %d failed expected %v %v got %v %v", i+1, tc.expRes, tc.err, res, err)
+		}
+	}
+}
+
+func TestDeleteStudent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockStudent(ctrl)
+	s := New(mockService)
+	ctx := context.Background()
+
+	testcases := []struct {
+		desc    string
+		version int64
+		err     error
+	}{
+		{desc: "success", version: 2},
+		{desc: "any version", version: 0},
+		{desc: "not found", version: 1, err: errors.EntityNotFound{Entity: "student", ID: "4"}},
+	}
+
+	for i, tc := range testcases {
+		mockService.EXPECT().Delete(ctx, 4, int(tc.version)).Return(tc.err)
+
+		res, err := s.DeleteStudent(ctx, &pb.DeleteStudentRequest{Id: 4, Version: tc.version})
+
+		if !reflect.DeepEqual(err, tc.err) || (err == nil) != (res != nil) {
+			t.Errorf("testcases %d failed expected %v got %v %v", i+1, tc.err, res, err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Package apierror writes the errors of the service layer as HTTP responses, the same way for every handler.
+package apierror
+
+import (
+	"log"
+	"net/http"
+
+	"student-management-system/errors"
+	"student-management-system/http/codec"
+	"student-management-system/requestctx"
+)
+
+// Write maps the service errors to their status code and writes them as an errors.Response, in the format the
+// client accepts or else JSON. Internal errors are logged with the request ID and answered with a generic
+// message.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	status, res := Response(r, err)
+
+	c, err := codec.Negotiate(r)
+	if err != nil {
+		c = codec.JSON
+	}
+
+	body, err := c.Marshal(res)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	codec.SetHeader(w, c)
+	w.WriteHeader(status)
+
+	_, err = w.Write(body)
+	if err != nil {
+		log.Println(err.Error())
+	}
+}
+
+// Response returns the status code and body Write answers err with, for the responses that report several
+// errors, such as those of a batch.
+func Response(r *http.Request, err error) (int, errors.Response) {
+	requestID := requestctx.RequestID(r.Context())
+	res := errors.Response{Code: "INTERNAL_ERROR", Message: "internal server error", RequestID: requestID}
+	status := http.StatusInternalServerError
+
+	switch e := err.(type) {
+	case errors.Unauthenticated:
+		status, res.Code, res.Message = http.StatusUnauthorized, "UNAUTHENTICATED", e.Error()
+	case errors.Forbidden:
+		status, res.Code, res.Message = http.StatusForbidden, "FORBIDDEN", e.Error()
+	case errors.InvalidParam:
+		status, res.Code, res.Message, res.Field = http.StatusBadRequest, "INVALID_PARAM", e.Error(), e.Field
+	case errors.Validation:
+		status, res.Code, res.Message, res.Errors = http.StatusUnprocessableEntity, "VALIDATION_FAILED", "validation failed", e.Errors
+	case errors.EntityNotFound:
+		status, res.Code, res.Message = http.StatusNotFound, "NOT_FOUND", e.Error()
+	case errors.EntityAlreadyExists:
+		status, res.Code, res.Message, res.ExistingID = http.StatusConflict, "ALREADY_EXISTS", e.Error(), e.ID
+	case errors.PreconditionFailed:
+		status, res.Code, res.Message = http.StatusPreconditionFailed, "PRECONDITION_FAILED", e.Error()
+	case errors.PreconditionRequired:
+		status, res.Code, res.Message = http.StatusPreconditionRequired, "PRECONDITION_REQUIRED", e.Error()
+	case errors.UnsupportedMediaType:
+		status, res.Code, res.Message = http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE", e.Error()
+	case errors.PayloadTooLarge:
+		status, res.Code, res.Message = http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE", e.Error()
+	case errors.NotAcceptable:
+		status, res.Code, res.Message = http.StatusNotAcceptable, "NOT_ACCEPTABLE", e.Error()
+	case errors.Aborted:
+		status, res.Code, res.Message = http.StatusFailedDependency, "ABORTED", e.Error()
+	default:
+		log.Printf("request %s: %v", requestID, err)
+	}
+
+	return status, res
+}
@@ -0,0 +1,85 @@
+package apierror
+
+import (
+	"encoding/json"
+	stdErrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"student-management-system/errors"
+	"student-management-system/requestctx"
+)
+
+func TestWrite(t *testing.T) {
+	testcases := []struct {
+		desc      string
+		err       error
+		expStatus int
+		expRes    errors.Response
+	}{
+		{desc: "invalid param names the field", err: errors.InvalidParam{Field: "first_name"}, expStatus: http.StatusBadRequest,
+			expRes: errors.Response{Code: "INVALID_PARAM", Message: "invalid first_name", Field: "first_name", RequestID: "req-1"}},
+		{desc: "validation lists every field", err: errors.Validation{Errors: []errors.FieldError{
+			{Field: "first_name", Rule: "required", Message: "first name is required"},
+			{Field: "gender", Rule: "one_of", Message: "gender must be one of M, F or O"},
+		}}, expStatus: http.StatusUnprocessableEntity, expRes: errors.Response{Code: "VALIDATION_FAILED", Message: "validation failed",
+			RequestID: "req-1", Errors: []errors.FieldError{
+				{Field: "first_name", Rule: "required", Message: "first name is required"},
+				{Field: "gender", Rule: "one_of", Message: "gender must be one of M, F or O"},
+			}}},
+		{desc: "not found", err: errors.EntityNotFound{Entity: "student", ID: "7"}, expStatus: http.StatusNotFound,
+			expRes: errors.Response{Code: "NOT_FOUND", Message: "no student found for id 7", RequestID: "req-1"}},
+		{desc: "conflict", err: errors.EntityAlreadyExists{Entity: "student"}, expStatus: http.StatusConflict,
+			expRes: errors.Response{Code: "ALREADY_EXISTS", Message: "student already exists", RequestID: "req-1"}},
+		{desc: "conflict names the existing student", err: errors.EntityAlreadyExists{Entity: "student", ID: "7"},
+			expStatus: http.StatusConflict, expRes: errors.Response{Code: "ALREADY_EXISTS",
+				Message: "student already exists with id 7", ExistingID: "7", RequestID: "req-1"}},
+		{desc: "precondition failed", err: errors.PreconditionFailed{Entity: "student", ID: "7"},
+			expStatus: http.StatusPreconditionFailed, expRes: errors.Response{Code: "PRECONDITION_FAILED",
+				Message: "student 7 has been modified since it was read", RequestID: "req-1"}},
+		{desc: "precondition required", err: errors.PreconditionRequired{Header: "If-Match"},
+			expStatus: http.StatusPreconditionRequired, expRes: errors.Response{Code: "PRECONDITION_REQUIRED",
+				Message: "If-Match header is required", RequestID: "req-1"}},
+		{desc: "unsupported media type", err: errors.UnsupportedMediaType{MediaType: "text/plain"},
+			expStatus: http.StatusUnsupportedMediaType, expRes: errors.Response{Code: "UNSUPPORTED_MEDIA_TYPE",
+				Message: "unsupported media type text/plain", RequestID: "req-1"}},
+		{desc: "payload too large", err: errors.PayloadTooLarge{Limit: 1024},
+			expStatus: http.StatusRequestEntityTooLarge, expRes: errors.Response{Code: "PAYLOAD_TOO_LARGE",
+				Message: "request body is larger than 1024 bytes", RequestID: "req-1"}},
+		{desc: "not acceptable", err: errors.NotAcceptable{Accept: "text/html"},
+			expStatus: http.StatusNotAcceptable, expRes: errors.Response{Code: "NOT_ACCEPTABLE",
+				Message: "none of the accepted media types text/html can be sent", RequestID: "req-1"}},
+		{desc: "unauthenticated", err: errors.Unauthenticated{Reason: "token has expired"}, expStatus: http.StatusUnauthorized,
+			expRes: errors.Response{Code: "UNAUTHENTICATED", Message: "unauthenticated: token has expired", RequestID: "req-1"}},
+		{desc: "forbidden names the permission", err: errors.Forbidden{Permission: "student:delete"}, expStatus: http.StatusForbidden,
+			expRes: errors.Response{Code: "FORBIDDEN", Message: "permission student:delete is required", RequestID: "req-1"}},
+		{desc: "aborted names the failed item", err: errors.Aborted{Index: 2}, expStatus: http.StatusFailedDependency,
+			expRes: errors.Response{Code: "ABORTED", Message: "aborted by the failure of item 2", RequestID: "req-1"}},
+		{desc: "internal errors hide their cause", err: errors.Internal{Err: stdErrors.New("dial tcp: connection refused")},
+			expStatus: http.StatusInternalServerError,
+			expRes:    errors.Response{Code: "INTERNAL_ERROR", Message: "internal server error", RequestID: "req-1"}},
+	}
+
+	for i, tc := range testcases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/student/7", nil)
+		req = req.WithContext(requestctx.WithRequestID(req.Context(), "req-1"))
+
+		Write(w, req, tc.err)
+
+		var res errors.Response
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Errorf("testcases %d failed invalid body %s", i+1, w.Body.String())
+		}
+
+		if w.Code != tc.expStatus {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expStatus, w.Code)
+		}
+
+		if !reflect.DeepEqual(res, tc.expRes) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes, res)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+// Package codec writes response bodies in the media type the client accepts and reads request bodies in the
+// media type they declare, so that handlers deal only in models.
+package codec
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"student-management-system/errors"
+)
+
+// Codec marshals and unmarshals the bodies of one media type.
+type Codec interface {
+	MediaType() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	JSON     Codec = jsonCodec{}
+	XML      Codec = xmlCodec{}
+	Protobuf Codec = protoCodec{}
+	NDJSON   Codec = ndjsonCodec{}
+)
+
+// codecs are the supported media types, in order of preference when a client accepts several equally.
+var codecs = []Codec{JSON, XML, Protobuf, NDJSON}
+
+// Negotiate picks the codec of the response from the Accept header of r, by quality and then by preference.
+// Each codec gets the quality of the most specific media range that matches it, so that a wildcard does not
+// bring back a media type refused with q=0, e.g. "application/json;q=0, */*" is answered in XML. Without an
+// Accept header the response is JSON, and an Accept header naming no supported media type fails with an
+// errors.NotAcceptable.
+func Negotiate(r *http.Request) (Codec, error) {
+	c, _, err := NegotiateOffer(r, "")
+
+	return c, err
+}
+
+// NegotiateOffer is Negotiate with one more media type on offer, such as text/csv that a handler writes itself,
+// preferred after the codecs. It reports whether offer is the best match, in which case there is no codec.
+func NegotiateOffer(r *http.Request, offer string) (Codec, bool, error) {
+	accept := strings.Join(r.Header.Values("Accept"), ",")
+	if strings.TrimSpace(accept) == "" {
+		return JSON, false, nil
+	}
+
+	ranges := parseAccept(accept)
+
+	var (
+		best    Codec
+		quality float64
+	)
+
+	for _, c := range codecs {
+		if q := qualityOf(c.MediaType(), ranges); q > quality {
+			best, quality = c, q
+		}
+	}
+
+	if offer != "" && qualityOf(offer, ranges) > quality {
+		return nil, true, nil
+	}
+
+	if best == nil {
+		return nil, false, errors.NotAcceptable{Accept: accept}
+	}
+
+	return best, false, nil
+}
+
+// mediaRange is a media range of an Accept header with its quality.
+type mediaRange struct {
+	mediaType string
+	quality   float64
+}
+
+// parseAccept returns the media ranges of an Accept header, skipping the malformed ones, among them those whose
+// quality is not a number between 0 and 1.
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+
+	for _, rng := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(rng)
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil || !(q >= 0 && q <= 1) {
+				continue
+			}
+		}
+
+		ranges = append(ranges, mediaRange{mediaType: mediaType, quality: q})
+	}
+
+	return ranges
+}
+
+// qualityOf returns the quality of the most specific of the ranges that matches mediaType, and 0 when none does.
+func qualityOf(mediaType string, ranges []mediaRange) float64 {
+	var quality float64
+
+	specificity := 0
+
+	for _, rng := range ranges {
+		if s := matches(rng.mediaType, mediaType); s > specificity {
+			quality, specificity = rng.quality, s
+		}
+	}
+
+	return quality
+}
+
+// Limit caps the body of r at n bytes, after which reading it fails with an errors.PayloadTooLarge and the
+// server closes the connection.
+func Limit(w http.ResponseWriter, r *http.Request, n int64) {
+	r.Body = &limitedBody{ReadCloser: http.MaxBytesReader(w, r.Body, n), limit: n}
+}
+
+// limitedBody tells the error http.MaxBytesReader returns past the limit from the others by the bytes read so
+// far, since there is no error value to compare it with.
+type limitedBody struct {
+	io.ReadCloser
+	limit int64
+	read  int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+
+	if err != nil && err != io.EOF && b.read >= b.limit {
+		return n, errors.PayloadTooLarge{Limit: b.limit}
+	}
+
+	return n, err
+}
+
+// ReadBody reads the whole body of r, failing with an errors.PayloadTooLarge past its Limit and with an
+// errors.InvalidParam when it cannot be read.
+func ReadBody(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if _, ok := err.(errors.PayloadTooLarge); err != nil && !ok {
+		return nil, errors.InvalidParam{Field: "body", Reason: err.Error()}
+	}
+
+	return body, err
+}
+
+// Decode reads the body of r into v with the codec of its Content-Type, assuming JSON when there is none.
+// Unsupported media types fail with an errors.UnsupportedMediaType, bodies over the Limit of r with an
+// errors.PayloadTooLarge and malformed bodies with an errors.InvalidParam.
+func Decode(r *http.Request, v interface{}) error {
+	c := JSON
+
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return errors.UnsupportedMediaType{MediaType: contentType}
+		}
+
+		if c = byMediaType(mediaType); c == nil {
+			return errors.UnsupportedMediaType{MediaType: mediaType}
+		}
+	}
+
+	body, err := ReadBody(r)
+	if err != nil {
+		return err
+	}
+
+	err = c.Unmarshal(body, v)
+	if _, ok := err.(errors.InvalidParam); err != nil && !ok {
+		return errors.InvalidParam{Field: "body", Reason: err.Error()}
+	}
+
+	return err
+}
+
+// SetHeader sets the Content-Type of a response written with c, which varies with the Accept header.
+func SetHeader(w http.ResponseWriter, c Codec) {
+	w.Header().Set("Content-Type", c.MediaType())
+	w.Header().Add("Vary", "Accept")
+}
+
+// matches returns how specifically a media range matches a media type: 3 for the type itself, 2 for its
+// type/* range, 1 for */* and 0 when the range does not match.
+func matches(mediaRange, mediaType string) int {
+	switch {
+	case mediaRange == mediaType:
+		return 3
+	case mediaRange == mediaType[:strings.Index(mediaType, "/")]+"/*":
+		return 2
+	case mediaRange == "*/*":
+		return 1
+	default:
+		return 0
+	}
+}
+
+func byMediaType(mediaType string) Codec {
+	for _, c := range codecs {
+		if c.MediaType() == mediaType {
+			return c
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,199 @@
+package codec
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"student-management-system/errors"
+	"student-management-system/models"
+	"student-management-system/pb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNegotiate(t *testing.T) {
+	testcases := []struct {
+		desc   string
+		accept []string
+		expRes Codec
+		expErr error
+	}{
+		{desc: "success:no accept header is json", expRes: JSON},
+		{desc: "success:exact media type", accept: []string{"application/xml"}, expRes: XML},
+		{desc: "success:highest quality wins", accept: []string{"application/json;q=0.5, application/x-protobuf"}, expRes: Protobuf},
+		{desc: "success:several headers", accept: []string{"text/html", "application/x-ndjson;q=0.9"}, expRes: NDJSON},
+		{desc: "success:wildcard is json", accept: []string{"text/html, */*;q=0.1"}, expRes: JSON},
+		{desc: "success:wildcard skips a refused media type", accept: []string{"application/json;q=0, */*"}, expRes: XML},
+		{desc: "success:refusal outranks the wildcard", accept: []string{"*/*", "application/json;q=0, application/xml;q=0"},
+			expRes: Protobuf},
+		{desc: "success:specific quality below the wildcard", accept: []string{"application/json;q=0.2, application/*;q=0.5"},
+			expRes: XML},
+		{desc: "failure:everything refused", accept: []string{"application/*;q=0, */*"},
+			expErr: errors.NotAcceptable{Accept: "application/*;q=0, */*"}},
+		{desc: "failure:refused with a zero quality", accept: []string{"application/json;q=0"},
+			expErr: errors.NotAcceptable{Accept: "application/json;q=0"}},
+		{desc: "success:quality above 1 is skipped", accept: []string{"application/json;q=0.5, application/xml;q=5"},
+			expRes: JSON},
+		{desc: "success:negative quality is skipped", accept: []string{"application/json;q=-1, application/xml;q=0.1"},
+			expRes: XML},
+		{desc: "failure:nothing supported", accept: []string{"text/html"}, expErr: errors.NotAcceptable{Accept: "text/html"}},
+		{desc: "failure:only out of range qualities", accept: []string{"application/json;q=2, */*;q=NaN"},
+			expErr: errors.NotAcceptable{Accept: "application/json;q=2, */*;q=NaN"}},
+	}
+
+	for i, tc := range testcases {
+		req := httptest.NewRequest(http.MethodGet, "/student/1", nil)
+		for _, a := range tc.accept {
+			req.Header.Add("Accept", a)
+		}
+
+		res, err := Negotiate(req)
+
+		if !reflect.DeepEqual(tc.expRes, res) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes, res)
+		}
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
+
+func TestNegotiateOffer(t *testing.T) {
+	testcases := []struct {
+		desc     string
+		accept   string
+		expRes   Codec
+		expOffer bool
+		expErr   error
+	}{
+		{desc: "success:no accept header", expRes: JSON},
+		{desc: "success:offer accepted", accept: "text/csv", expOffer: true},
+		{desc: "success:codecs preferred on a tie", accept: "text/csv, application/json", expRes: JSON},
+		{desc: "success:offer preferred by quality", accept: "application/xml;q=0.5, text/csv", expOffer: true},
+		{desc: "success:offer refused", accept: "text/csv;q=0, */*", expRes: JSON},
+		{desc: "failure:nothing supported", accept: "text/html", expErr: errors.NotAcceptable{Accept: "text/html"}},
+	}
+
+	for i, tc := range testcases {
+		req := httptest.NewRequest(http.MethodPost, "/student/import", nil)
+		req.Header.Set("Accept", tc.accept)
+
+		res, offer, err := NegotiateOffer(req, "text/csv")
+
+		if !reflect.DeepEqual(tc.expRes, res) || offer != tc.expOffer {
+			t.Errorf("testcases %d failed expected %v %v got %v %v", i+1, tc.expRes, tc.expOffer, res, offer)
+		}
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	asha := models.Student{FirstName: "Asha", Dob: models.NewDate(2010, time.April, 1), FamilyIncome: 50000}
+
+	protoBody, err := proto.Marshal(pb.NewStudent(&asha))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	badDob, err := proto.Marshal(&pb.Student{Dob: "01/04/2010"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		desc        string
+		contentType string
+		body        string
+		limit       int64
+		expRes      models.Student
+		expErr      error
+	}{
+		{desc: "success:json without a content type", body: `{"first_name":"Asha","dob":"2010-04-01","family_income":50000}`,
+			expRes: asha},
+		{desc: "success:xml", contentType: "application/xml; charset=utf-8",
+			body:   `<student><first_name>Asha</first_name><dob>2010-04-01</dob><family_income>50000</family_income></student>`,
+			expRes: asha},
+		{desc: "success:protobuf", contentType: "application/x-protobuf", body: string(protoBody), expRes: asha},
+		{desc: "success:ndjson", contentType: "application/x-ndjson",
+			body: `{"first_name":"Asha","dob":"2010-04-01","family_income":50000}` + "\n", expRes: asha},
+		{desc: "failure:unsupported media type", contentType: "text/plain", body: "Asha",
+			expErr: errors.UnsupportedMediaType{MediaType: "text/plain"}},
+		{desc: "failure:malformed body", contentType: "application/json", body: `{"first_name":`,
+			expErr: errors.InvalidParam{Field: "body", Reason: "unexpected end of JSON input"}},
+		{desc: "failure:invalid date in a message", contentType: "application/x-protobuf", body: string(badDob),
+			expErr: errors.InvalidParam{Field: "dob", Reason: "must be a date in YYYY-MM-DD format"}},
+		{desc: "success:body at the limit", body: `{"first_name":"Asha","dob":"2010-04-01","family_income":50000}`,
+			limit: 62, expRes: asha},
+		{desc: "failure:body over the limit", body: `{"first_name":"Asha","dob":"2010-04-01","family_income":50000}`,
+			limit: 61, expErr: errors.PayloadTooLarge{Limit: 61}},
+	}
+
+	for i, tc := range testcases {
+		req := httptest.NewRequest(http.MethodPost, "/student", strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", tc.contentType)
+
+		if tc.limit != 0 {
+			Limit(httptest.NewRecorder(), req, tc.limit)
+		}
+
+		var res models.Student
+
+		err := Decode(req, &res)
+
+		if !reflect.DeepEqual(tc.expRes, res) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes, res)
+		}
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	next := 1
+	list := models.StudentList{Data: []models.Student{{ID: 1, FirstName: "Asha"}, {ID: 2, FirstName: "Ravi"}},
+		Meta: models.Page{Total: 3, Limit: 2, NextOffset: &next}}
+
+	protoList, err := proto.Marshal(pb.NewStudentList(&list))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		desc   string
+		codec  Codec
+		v      interface{}
+		expRes string
+	}{
+		{desc: "xml list", codec: XML, v: list, expRes: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+			`<students><data><student><id>1</id><first_name>Asha</first_name><dob></dob></student>` +
+			`<student><id>2</id><first_name>Ravi</first_name><dob></dob></student></data>` +
+			`<meta><total>3</total><limit>2</limit><offset>0</offset><next_offset>1</next_offset></meta></students>`},
+		{desc: "xml error", codec: XML, v: errors.Response{Code: "VALIDATION_FAILED", Message: "validation failed",
+			Errors: []errors.FieldError{{Field: "dob", Rule: "date", Message: "dob must be a date"}}},
+			expRes: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<error><code>VALIDATION_FAILED</code>` +
+				`<message>validation failed</message><errors><error><field>dob</field><rule>date</rule>` +
+				`<message>dob must be a date</message></error></errors></error>`},
+		{desc: "ndjson list has a line for each student", codec: NDJSON, v: list,
+			expRes: `{"id":1,"first_name":"Asha","dob":null}` + "\n" + `{"id":2,"first_name":"Ravi","dob":null}` + "\n"},
+		{desc: "ndjson student", codec: NDJSON, v: models.Student{ID: 1}, expRes: `{"id":1,"dob":null}` + "\n"},
+		{desc: "protobuf list", codec: Protobuf, v: list, expRes: string(protoList)},
+	}
+
+	for i, tc := range testcases {
+		res, err := tc.codec.Marshal(tc.v)
+
+		if err != nil || string(res) != tc.expRes {
+			t.Errorf("testcases %d failed expected %v got %v, %v", i+1, tc.expRes, string(res), err)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+
+	"student-management-system/errors"
+	"student-management-system/models"
+	"student-management-system/pb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) MediaType() string {
+	return "application/json"
+}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type xmlCodec struct{}
+
+func (xmlCodec) MediaType() string {
+	return "application/xml"
+}
+
+// Marshal writes v as an XML document whose root element is named after the JSON body it stands for.
+func (xmlCodec) Marshal(v interface{}) ([]byte, error) {
+	root := "response"
+
+	switch v.(type) {
+	case models.Student, *models.Student:
+		root = "student"
+	case models.StudentList, *models.StudentList:
+		root = "students"
+	case models.History, *models.History:
+		root = "history"
+	case models.BatchReport, *models.BatchReport:
+		root = "batch"
+	case models.ImportReport, *models.ImportReport:
+		root = "import"
+	case errors.Response, *errors.Response:
+		root = "error"
+	}
+
+	var b bytes.Buffer
+
+	b.WriteString(xml.Header)
+
+	if err := xml.NewEncoder(&b).EncodeElement(v, xml.StartElement{Name: xml.Name{Local: root}}); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+func (xmlCodec) Unmarshal(data []byte, v interface{}) error {
+	return xml.Unmarshal(data, v)
+}
+
+type protoCodec struct{}
+
+func (protoCodec) MediaType() string {
+	return "application/x-protobuf"
+}
+
+// Marshal writes the message of the model v stands for, see package pb.
+func (protoCodec) Marshal(v interface{}) ([]byte, error) {
+	var m proto.Message
+
+	switch e := v.(type) {
+	case models.Student:
+		m = pb.NewStudent(&e)
+	case models.StudentList:
+		m = pb.NewStudentList(&e)
+	case models.History:
+		m = pb.NewHistory(&e)
+	case models.BatchReport:
+		m = pb.NewBatchReport(&e)
+	case models.ImportReport:
+		m = pb.NewImportReport(&e)
+	case errors.Response:
+		m = pb.NewError(&e)
+	case proto.Message:
+		m = e
+	default:
+		return nil, fmt.Errorf("no protocol buffer message for %T", v)
+	}
+
+	return proto.Marshal(m)
+}
+
+func (protoCodec) Unmarshal(data []byte, v interface{}) error {
+	switch e := v.(type) {
+	case *models.Student:
+		var m pb.Student
+
+		if err := proto.Unmarshal(data, &m); err != nil {
+			return err
+		}
+
+		student, err := pb.ToStudent(&m)
+		if err != nil {
+			return err
+		}
+
+		*e = student
+
+		return nil
+	case *models.Batch:
+		var m pb.Batch
+
+		if err := proto.Unmarshal(data, &m); err != nil {
+			return err
+		}
+
+		batch, err := pb.ToBatch(&m)
+		if err != nil {
+			return err
+		}
+
+		*e = batch
+
+		return nil
+	case proto.Message:
+		return proto.Unmarshal(data, e)
+	default:
+		return fmt.Errorf("no protocol buffer message for %T", v)
+	}
+}
+
+// ndjsonCodec writes newline delimited JSON: a list as one line for each of its items, leaving out the page,
+// and anything else as a single line.
+type ndjsonCodec struct{}
+
+func (ndjsonCodec) MediaType() string {
+	return "application/x-ndjson"
+}
+
+func (ndjsonCodec) Marshal(v interface{}) ([]byte, error) {
+	var items []interface{}
+
+	switch e := v.(type) {
+	case models.StudentList:
+		for i := range e.Data {
+			items = append(items, e.Data[i])
+		}
+	case models.History:
+		for i := range e.Data {
+			items = append(items, e.Data[i])
+		}
+	default:
+		items = []interface{}{v}
+	}
+
+	var b bytes.Buffer
+
+	enc := json.NewEncoder(&b)
+
+	for _, item := range items {
+		if err := enc.Encode(item); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+func (ndjsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
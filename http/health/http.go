@@ -0,0 +1,141 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"student-management-system/driver"
+)
+
+const (
+	statusUp   = "UP"
+	statusDown = "DOWN"
+
+	checkTimeout = 2 * time.Second
+)
+
+// migrations is the part of migration.Migrator the readiness check needs.
+type migrations interface {
+	Pending(ctx context.Context) (int, error)
+}
+
+type handler struct {
+	db         *sql.DB
+	migrations migrations
+	draining   *int32
+}
+
+type Check struct {
+	Status  string      `json:"status"`
+	Latency string      `json:"latency"`
+	Error   string      `json:"error,omitempty"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+type Response struct {
+	Status string           `json:"status"`
+	Checks map[string]Check `json:"checks,omitempty"`
+}
+
+func New(db *sql.DB, m migrations) handler {
+	return handler{db: db, migrations: m, draining: new(int32)}
+}
+
+// Drain makes readiness fail from now on, so the load balancer stops routing to an instance that is
+// shutting down.
+func (h handler) Drain() {
+	atomic.StoreInt32(h.draining, 1)
+}
+
+// Liveness reports that the process is up and serving requests. It checks no dependencies, so a database
+// outage never gets the pod restarted.
+func (h handler) Liveness(w http.ResponseWriter, r *http.Request) {
+	writeResponse(w, http.StatusOK, Response{Status: statusUp})
+}
+
+// Readiness reports whether the instance can serve traffic: the database answers and its schema is current.
+func (h handler) Readiness(w http.ResponseWriter, r *http.Request) {
+	if atomic.LoadInt32(h.draining) == 1 {
+		writeResponse(w, http.StatusServiceUnavailable, Response{Status: statusDown, Checks: map[string]Check{
+			"server": {Status: statusDown, Error: "shutting down"},
+		}})
+
+		return
+	}
+
+	res := Response{Status: statusUp, Checks: map[string]Check{
+		"database":   h.check(r.Context(), h.database),
+		"migrations": h.check(r.Context(), h.pendingMigrations),
+	}}
+
+	status := http.StatusOK
+
+	for _, c := range res.Checks {
+		if c.Status != statusUp {
+			res.Status = statusDown
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	writeResponse(w, status, res)
+}
+
+func (h handler) check(ctx context.Context, fn func(ctx context.Context) (interface{}, error)) Check {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := fn(ctx)
+	c := Check{Status: statusUp, Latency: time.Since(start).String(), Details: details}
+
+	if err != nil {
+		c.Status, c.Error = statusDown, err.Error()
+	}
+
+	return c
+}
+
+func (h handler) database(ctx context.Context) (interface{}, error) {
+	err := h.db.PingContext(ctx)
+
+	return driver.Stats(h.db), err
+}
+
+func (h handler) pendingMigrations(ctx context.Context) (interface{}, error) {
+	pending, err := h.migrations.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	details := map[string]int{"pending": pending}
+
+	if pending > 0 {
+		return details, errors.New("database schema has pending migrations")
+	}
+
+	return details, nil
+}
+
+func writeResponse(w http.ResponseWriter, status int, res Response) {
+	body, err := json.Marshal(res)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	_, err = w.Write(body)
+	if err != nil {
+		log.Println(err.Error())
+	}
+}
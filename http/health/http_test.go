@@ -0,0 +1,94 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type fakeMigrations struct {
+	pending int
+	err     error
+}
+
+func (f fakeMigrations) Pending(ctx context.Context) (int, error) {
+	return f.pending, f.err
+}
+
+func TestLiveness(t *testing.T) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+
+	New(nil, nil).Liveness(w, req)
+
+	if w.Code != http.StatusOK || w.Body.String() != `{"status":"UP"}` {
+		t.Errorf("expected 200 {\"status\":\"UP\"} got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	testcases := []struct {
+		desc          string
+		pingErr       error
+		migrations    fakeMigrations
+		expStatus     int
+		expDatabase   string
+		expMigrations string
+	}{
+		{desc: "success:ready", expStatus: http.StatusOK, expDatabase: statusUp, expMigrations: statusUp},
+		{desc: "failure:database unreachable", pingErr: errors.New("connection refused"),
+			expStatus: http.StatusServiceUnavailable, expDatabase: statusDown, expMigrations: statusUp},
+		{desc: "failure:pending migrations", migrations: fakeMigrations{pending: 2},
+			expStatus: http.StatusServiceUnavailable, expDatabase: statusUp, expMigrations: statusDown},
+		{desc: "failure:migration status error", migrations: fakeMigrations{err: errors.New("query error")},
+			expStatus: http.StatusServiceUnavailable, expDatabase: statusUp, expMigrations: statusDown},
+	}
+
+	for i, tc := range testcases {
+		db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		if err != nil {
+			log.Println(err.Error())
+		}
+
+		mock.ExpectPing().WillReturnError(tc.pingErr)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+
+		New(db, tc.migrations).Readiness(w, req)
+
+		var res Response
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Errorf("testcases %d failed invalid body %s", i+1, w.Body.String())
+		}
+
+		if w.Code != tc.expStatus {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expStatus, w.Code)
+		}
+
+		if res.Checks["database"].Status != tc.expDatabase || res.Checks["migrations"].Status != tc.expMigrations {
+			t.Errorf("testcases %d failed expected database %s and migrations %s got %v", i+1, tc.expDatabase,
+				tc.expMigrations, res.Checks)
+		}
+	}
+}
+
+func TestReadiness_Draining(t *testing.T) {
+	h := New(nil, nil)
+	h.Drain()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+
+	h.Readiness(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected %v got %v", http.StatusServiceUnavailable, w.Code)
+	}
+}
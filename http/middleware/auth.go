@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"student-management-system/errors"
+	"student-management-system/http/apierror"
+	"student-management-system/models"
+	"student-management-system/requestctx"
+)
+
+// realm is the protection space named in the WWW-Authenticate challenge of 401 responses.
+const realm = "student-management-system"
+
+type verifier interface {
+	Verify(token string) (models.Principal, error)
+}
+
+// Authenticate answers requests without a valid bearer token with 401 Unauthorized, and passes the others on
+// with the principal of their token in the context.
+func Authenticate(v verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+			if !strings.EqualFold(scheme, "Bearer") || token == "" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="`+realm+`"`)
+				apierror.Write(w, r, errors.Unauthenticated{Reason: "a bearer token is required"})
+
+				return
+			}
+
+			principal, err := v.Verify(strings.TrimSpace(token))
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="`+realm+`", error="invalid_token"`)
+				apierror.Write(w, r, err)
+
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(requestctx.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// Anonymous passes every request on as the principal "anonymous" in role, for deployments that run with
+// authentication disabled and still go through the permission checks.
+func Anonymous(role string) func(http.Handler) http.Handler {
+	principal := models.Principal{Subject: "anonymous", Role: role}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(requestctx.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"student-management-system/errors"
+	"student-management-system/models"
+	"student-management-system/requestctx"
+)
+
+// staticVerifier accepts the token "good" as the user admin.
+type staticVerifier struct{}
+
+func (staticVerifier) Verify(token string) (models.Principal, error) {
+	if token != "good" {
+		return models.Principal{}, errors.Unauthenticated{Reason: "invalid token"}
+	}
+
+	return models.Principal{Subject: "admin"}, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	testcases := []struct {
+		desc         string
+		header       string
+		expStatus    int
+		expChallenge string
+		expActor     string
+	}{
+		{desc: "valid token", header: "Bearer good", expStatus: http.StatusOK, expActor: "admin"},
+		{desc: "scheme is case insensitive", header: "bearer good", expStatus: http.StatusOK, expActor: "admin"},
+		{desc: "no token", expStatus: http.StatusUnauthorized, expChallenge: `Bearer realm="student-management-system"`},
+		{desc: "basic credentials", header: "Basic YWRtaW46cGFzcw==", expStatus: http.StatusUnauthorized,
+			expChallenge: `Bearer realm="student-management-system"`},
+		{desc: "invalid token", header: "Bearer bad", expStatus: http.StatusUnauthorized,
+			expChallenge: `Bearer realm="student-management-system", error="invalid_token"`},
+	}
+
+	for i, tc := range testcases {
+		var actor string
+
+		h := Authenticate(staticVerifier{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			actor = requestctx.Actor(r.Context())
+		}))
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/student", nil)
+
+		if tc.header != "" {
+			req.Header.Set("Authorization", tc.header)
+		}
+
+		h.ServeHTTP(w, req)
+
+		if w.Code != tc.expStatus || actor != tc.expActor {
+			t.Errorf("testcases %d failed expected %v %q got %v %q", i+1, tc.expStatus, tc.expActor, w.Code, actor)
+		}
+
+		if got := w.Header().Get("WWW-Authenticate"); got != tc.expChallenge {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expChallenge, got)
+		}
+	}
+}
+
+func TestAnonymous(t *testing.T) {
+	var principal models.Principal
+
+	h := Anonymous("admin")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ = requestctx.Principal(r.Context())
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/student", nil))
+
+	if exp := (models.Principal{Subject: "anonymous", Role: "admin"}); principal != exp {
+		t.Errorf("expected %v got %v", exp, principal)
+	}
+}
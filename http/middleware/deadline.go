@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+type connKey struct{}
+
+// connDeadline is the connection of a request with the write timeout of its server.
+type connDeadline struct {
+	conn    net.Conn
+	timeout time.Duration
+}
+
+// WriteDeadlines returns the http.Server.ConnContext that lets handlers extend the write deadline of their
+// connection with ExtendWriteDeadline, given the WriteTimeout of the server.
+func WriteDeadlines(timeout time.Duration) func(ctx context.Context, c net.Conn) context.Context {
+	return func(ctx context.Context, c net.Conn) context.Context {
+		return context.WithValue(ctx, connKey{}, connDeadline{conn: c, timeout: timeout})
+	}
+}
+
+// ExtendWriteDeadline gives the response to r another write timeout from now, so that a response streamed for
+// longer than the WriteTimeout of the server is cut off only once the client stops reading it for that long.
+// It reports false when it cannot: for HTTP/2, where the connection is shared by other requests, or when the
+// server was not set up with WriteDeadlines.
+func ExtendWriteDeadline(r *http.Request) bool {
+	d, ok := r.Context().Value(connKey{}).(connDeadline)
+	if !ok || r.ProtoMajor != 1 || d.timeout <= 0 {
+		return false
+	}
+
+	return d.conn.SetWriteDeadline(time.Now().Add(d.timeout)) == nil
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestExtendWriteDeadline(t *testing.T) {
+	testcases := []struct {
+		desc       string
+		conn       bool
+		timeout    time.Duration
+		protoMajor int
+		expOK      bool
+	}{
+		{desc: "http/1.1 connection", conn: true, timeout: 20 * time.Millisecond, protoMajor: 1, expOK: true},
+		{desc: "http/2 shares the connection", conn: true, timeout: 20 * time.Millisecond, protoMajor: 2},
+		{desc: "no write timeout", conn: true, protoMajor: 1},
+		{desc: "server without WriteDeadlines", protoMajor: 1},
+	}
+
+	for i, tc := range testcases {
+		server, client := net.Pipe()
+
+		req := httptest.NewRequest(http.MethodGet, "/student/export", nil)
+		req.ProtoMajor = tc.protoMajor
+
+		if tc.conn {
+			req = req.WithContext(WriteDeadlines(tc.timeout)(context.Background(), server))
+		}
+
+		ok := ExtendWriteDeadline(req)
+		if ok != tc.expOK {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expOK, ok)
+		}
+
+		// nobody reads the client end, so the write blocks until the deadline if one was set
+		if ok {
+			if _, err := server.Write([]byte("x")); !os.IsTimeout(err) {
+				t.Errorf("testcases %d failed expected a timeout got %v", i+1, err)
+			}
+		}
+
+		server.Close()
+		client.Close()
+	}
+}
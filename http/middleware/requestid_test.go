@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"student-management-system/requestctx"
+)
+
+func TestRequestID(t *testing.T) {
+	testcases := []struct {
+		desc   string
+		header string
+		expID  string
+	}{
+		{desc: "reuses the client id", header: "abc-123", expID: "abc-123"},
+		{desc: "generates an id when missing"},
+	}
+
+	for i, tc := range testcases {
+		var ctxID string
+
+		h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctxID = requestctx.RequestID(r.Context())
+		}))
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/student", nil)
+
+		if tc.header != "" {
+			req.Header.Set(RequestIDHeader, tc.header)
+		}
+
+		h.ServeHTTP(w, req)
+
+		if ctxID == "" || ctxID != w.Header().Get(RequestIDHeader) {
+			t.Errorf("testcases %d failed context id %q does not match header %q", i+1, ctxID, w.Header().Get(RequestIDHeader))
+		}
+
+		if tc.expID != "" && ctxID != tc.expID {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expID, ctxID)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package student
+
+import (
+	"context"
+	"net/http"
+
+	"student-management-system/errors"
+	"student-management-system/http/apierror"
+	"student-management-system/http/codec"
+	"student-management-system/models"
+)
+
+// maxBatchSize caps the size of a batch body, which leaves a couple of KiB to each of models.MaxBatch items.
+const maxBatchSize = 1 << 20
+
+type batchFunc func(ctx context.Context, items []models.BatchItem, mode models.BatchMode) ([]models.BatchResult, error)
+
+// PostBatch creates the students of a batch.
+func (h handler) PostBatch(w http.ResponseWriter, r *http.Request) {
+	h.batch(w, r, http.StatusCreated, http.StatusCreated, false, h.student.PostBatch)
+}
+
+// PutBatch replaces the students of a batch, each at the version of its item.
+func (h handler) PutBatch(w http.ResponseWriter, r *http.Request) {
+	h.batch(w, r, http.StatusOK, http.StatusOK, true, h.student.PutBatch)
+}
+
+// DeleteBatch moves the students of a batch to the trash, each if still at the version of its item.
+func (h handler) DeleteBatch(w http.ResponseWriter, r *http.Request) {
+	h.batch(w, r, http.StatusOK, http.StatusNoContent, true, h.student.DeleteBatch)
+}
+
+// batch answers a batch with the result of every item, with status when all of them succeeded and 207 Multi-Status
+// otherwise. Each result has the status its item would have been answered with on its own, which is done for
+// those that succeeded. Items of a versioned batch must name the version they are based on when h requires
+// If-Match.
+func (h handler) batch(w http.ResponseWriter, r *http.Request, status, done int, versioned bool, fn batchFunc) {
+	c, err := codec.Negotiate(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+
+		return
+	}
+
+	codec.Limit(w, r, maxBatchSize)
+
+	var req models.Batch
+
+	if err := codec.Decode(r, &req); err != nil {
+		apierror.Write(w, r, err)
+
+		return
+	}
+
+	if req.Mode == "" {
+		req.Mode = models.Atomic
+	}
+
+	for _, item := range req.Items {
+		if versioned && h.requireIfMatch && item.Version == 0 {
+			apierror.Write(w, r, errors.InvalidParam{Field: "version", Reason: "is required for every item"})
+
+			return
+		}
+	}
+
+	results, err := fn(r.Context(), req.Items, req.Mode)
+	if err != nil {
+		apierror.Write(w, r, err)
+
+		return
+	}
+
+	res := models.BatchReport{Data: make([]models.BatchStatus, len(results))}
+
+	for i, result := range results {
+		res.Data[i] = models.BatchStatus{Index: result.Index, Status: done, ID: result.ID, Version: result.Version}
+
+		if result.Err != nil {
+			code, e := apierror.Response(r, result.Err)
+			res.Data[i].Status, res.Data[i].Error = code, &e
+			status = http.StatusMultiStatus
+		}
+	}
+
+	write(w, r, c, status, res)
+}
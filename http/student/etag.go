@@ -0,0 +1,99 @@
+package student
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"student-management-system/errors"
+)
+
+// etag is the strong entity tag of a student version, e.g. "3".
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// representationETag is the strong entity tag of one representation of a student version, e.g. "3-1f2e3d4c5b6a7980":
+// the version followed by a digest of the body, which differs between formats and between redacted and full
+// views. If-Match compares the version only, see ifMatch.
+func representationETag(version int, body []byte) string {
+	return `"` + strconv.Itoa(version) + "-" + digest(body) + `"`
+}
+
+// listETag is a weak entity tag for a page of students, derived from the response body.
+func listETag(body []byte) string {
+	return `W/"` + digest(body) + `"`
+}
+
+func digest(body []byte) string {
+	sum := sha256.Sum256(body)
+
+	return hex.EncodeToString(sum[:8])
+}
+
+// varyByCaller marks a response as depending on the Authorization header, as the body of a student is redacted
+// for some roles.
+func varyByCaller(w http.ResponseWriter) {
+	w.Header().Add("Vary", "Authorization")
+}
+
+// ifMatch returns the version named by the If-Match header, or 0 when the header is absent or "*". A header
+// that names no version of the student, such as a weak or malformed tag, can never match and fails the
+// request; so does a missing header when h requires one. Every representation of a version matches it.
+func (h handler) ifMatch(r *http.Request, id int) (int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+
+	switch value {
+	case "":
+		if h.requireIfMatch {
+			return 0, errors.PreconditionRequired{Header: "If-Match"}
+		}
+
+		return 0, nil
+	case "*":
+		return 0, nil
+	}
+
+	version, ok := tagVersion(value)
+	if !ok {
+		return 0, errors.PreconditionFailed{Entity: "student", ID: strconv.Itoa(id)}
+	}
+
+	return version, nil
+}
+
+// tagVersion returns the version named by a strong entity tag of a student, either an etag or a
+// representationETag.
+func tagVersion(tag string) (int, bool) {
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+
+	number, sum, found := strings.Cut(tag[1:len(tag)-1], "-")
+
+	version, err := strconv.Atoi(number)
+	if err != nil || version <= 0 || strconv.Itoa(version) != number || found && sum == "" {
+		return 0, false
+	}
+
+	return version, true
+}
+
+// noneMatch reports whether the If-None-Match header lists tag or is "*", comparing weakly as RFC 9110 asks.
+func noneMatch(r *http.Request, tag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == strings.TrimPrefix(tag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,77 @@
+package student
+
+import (
+	"log"
+	"net/http"
+
+	"student-management-system/http/apierror"
+	"student-management-system/http/middleware"
+	"student-management-system/models"
+	"student-management-system/tabular"
+)
+
+// Export streams the students matching the filters of Get, unpaged, as an attachment in the format given by
+// format=csv (the default), jsonl or xlsx. Students are written as they are read, so an error after the first
+// one can no longer change the status; the connection is then aborted so that the client sees the file is
+// incomplete. The write deadline is pushed back before every student, so that a long download is not cut off
+// by the write timeout of the server while one whose client stops reading fails, which closes its cursor.
+func (h handler) Export(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	format := tabular.Format(query.Get("format"))
+	if format == "" {
+		format = tabular.CSV
+	}
+
+	enc, err := tabular.NewEncoder(w, format)
+	if err != nil {
+		apierror.Write(w, r, err)
+
+		return
+	}
+
+	filter, err := parseFilter(query)
+	if err != nil {
+		apierror.Write(w, r, err)
+
+		return
+	}
+
+	var started bool
+
+	start := func() {
+		started = true
+
+		w.Header().Set("Content-Type", format.ContentType())
+		w.Header().Set("Content-Disposition", `attachment; filename="students.`+string(format)+`"`)
+		w.WriteHeader(http.StatusOK)
+	}
+
+	err = h.student.Export(r.Context(), filter, func(student models.Student) error {
+		if !started {
+			start()
+		}
+
+		middleware.ExtendWriteDeadline(r)
+
+		return enc.Encode(&student)
+	})
+
+	switch {
+	case err != nil && !started:
+		apierror.Write(w, r, err)
+
+		return
+	case err != nil:
+		log.Println(err.Error())
+		panic(http.ErrAbortHandler)
+	case !started:
+		start()
+	}
+
+	middleware.ExtendWriteDeadline(r)
+
+	if err := enc.Close(); err != nil {
+		log.Println(err.Error())
+	}
+}
@@ -1,12 +1,14 @@
 package student
 
 import (
-	"encoding/json"
-	"io"
 	"log"
+	"mime"
 	"net/http"
 	"strconv"
 
+	"student-management-system/errors"
+	"student-management-system/http/apierror"
+	"student-management-system/http/codec"
 	"student-management-system/models"
 	"student-management-system/service"
 
@@ -14,125 +16,162 @@ import (
 )
 
 type handler struct {
-	student service.Student
+	student        service.Student
+	requireIfMatch bool
+	maxBodySize    int64
 }
 
-func New(s service.Student) handler {
-	return handler{student: s}
+// New returns the student handler. With requireIfMatch, PUT, PATCH and DELETE must send the ETag of the version
+// they are based on in If-Match. The bodies of POST, PUT and PATCH of a single student are cut at maxBodySize
+// bytes and answered with 413.
+func New(s service.Student, requireIfMatch bool, maxBodySize int64) handler {
+	return handler{student: s, requireIfMatch: requireIfMatch, maxBodySize: maxBodySize}
 }
 
 func (h handler) Post(w http.ResponseWriter, r *http.Request) {
-	body, err := io.ReadAll(r.Body)
+	c, err := codec.Negotiate(r)
 	if err != nil {
-		handleError(w, err)
+		apierror.Write(w, r, err)
 
 		return
 	}
 
+	codec.Limit(w, r, h.maxBodySize)
+
 	var student models.Student
 
-	err = json.Unmarshal(body, &student)
+	err = codec.Decode(r, &student)
 	if err != nil {
-		handleError(w, err)
+		apierror.Write(w, r, err)
 
 		return
 	}
 
 	student, err = h.student.Post(r.Context(), &student)
 	if err != nil {
-		handleError(w, err)
+		apierror.Write(w, r, err)
 
 		return
 	}
 
-	body, err = json.Marshal(student)
+	w.Header().Set("ETag", etag(student.Version))
+	write(w, r, c, http.StatusCreated, student)
+}
+
+func (h handler) Get(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, false)
+}
+
+// Trash lists the deleted students that can still be restored, with the same query parameters as Get.
+func (h handler) Trash(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, true)
+}
+
+func (h handler) list(w http.ResponseWriter, r *http.Request, deleted bool) {
+	c, err := codec.Negotiate(r)
 	if err != nil {
-		handleInternalServerError(w, err)
+		apierror.Write(w, r, err)
 
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
-
-	_, err = w.Write(body)
+	filter, err := parseFilter(r.URL.Query())
 	if err != nil {
-		log.Println(err.Error())
+		apierror.Write(w, r, err)
 
 		return
 	}
-}
 
-func (h handler) Get(w http.ResponseWriter, r *http.Request) {
-	firstName := r.URL.Query().Get("firstName")
-	lastName := r.URL.Query().Get("lastName")
+	filter.Deleted = deleted
 
-	res, err := h.student.Get(r.Context(), firstName, lastName)
+	res, err := h.student.Get(r.Context(), filter)
 	if err != nil {
-		handleError(w, err)
+		apierror.Write(w, r, err)
 
 		return
 	}
 
-	body, err := json.Marshal(res)
+	body, err := c.Marshal(res)
 	if err != nil {
-		handleInternalServerError(w, err)
+		apierror.Write(w, r, errors.Internal{Err: err})
+
+		return
+	}
+
+	// the tag is of the body, which differs between formats
+	writeTagged(w, r, c, listETag(body), body)
+}
+
+// writeTagged answers a GET with body under the entity tag tag, or with 304 when If-None-Match lists the tag.
+func writeTagged(w http.ResponseWriter, r *http.Request, c codec.Codec, tag string, body []byte) {
+	w.Header().Set("ETag", tag)
+	varyByCaller(w)
+	codec.SetHeader(w, c)
+
+	if noneMatch(r, tag) {
+		w.WriteHeader(http.StatusNotModified)
 
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 
-	_, err = w.Write(body)
+	_, err := w.Write(body)
 	if err != nil {
 		log.Println(err.Error())
-
-		return
 	}
 }
 
 func (h handler) GetByID(w http.ResponseWriter, r *http.Request) {
-	ID, err := strconv.Atoi(mux.Vars(r)["id"])
+	c, err := codec.Negotiate(r)
 	if err != nil {
-		handleError(w, err)
+		apierror.Write(w, r, err)
 
 		return
 	}
 
-	student, err := h.student.GetByID(r.Context(), ID)
+	ID, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
-		handleError(w, err)
+		apierror.Write(w, r, errors.InvalidParam{Field: "id"})
 
 		return
 	}
 
-	body, err := json.Marshal(student)
+	student, err := h.student.GetByID(r.Context(), ID)
 	if err != nil {
-		handleInternalServerError(w, err)
+		apierror.Write(w, r, err)
 
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-
-	_, err = w.Write(body)
+	body, err := c.Marshal(student)
 	if err != nil {
-		log.Println(err.Error())
+		apierror.Write(w, r, errors.Internal{Err: err})
 
 		return
 	}
+
+	writeTagged(w, r, c, representationETag(student.Version, body), body)
 }
 
 func (h handler) Delete(w http.ResponseWriter, r *http.Request) {
 	ID, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
-		handleError(w, err)
+		apierror.Write(w, r, errors.InvalidParam{Field: "id"})
+
+		return
+	}
+
+	version, err := h.ifMatch(r, ID)
+	if err != nil {
+		apierror.Write(w, r, err)
 
 		return
 	}
 
-	err = h.student.Delete(r.Context(), ID)
+	err = h.student.Delete(r.Context(), ID, version)
 	if err != nil {
-		handleError(w, err)
+		apierror.Write(w, r, err)
 
 		return
 	}
@@ -141,77 +180,218 @@ func (h handler) Delete(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h handler) Put(w http.ResponseWriter, r *http.Request) {
-	ID, err := strconv.Atoi(mux.Vars(r)["id"])
+	c, err := codec.Negotiate(r)
 	if err != nil {
-		handleError(w, err)
+		apierror.Write(w, r, err)
 
 		return
 	}
 
-	body, err := io.ReadAll(r.Body)
+	ID, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
-		handleError(w, err)
+		apierror.Write(w, r, errors.InvalidParam{Field: "id"})
 
 		return
 	}
 
+	codec.Limit(w, r, h.maxBodySize)
+
 	var student models.Student
 
-	err = json.Unmarshal(body, &student)
+	err = codec.Decode(r, &student)
 	if err != nil {
-		handleError(w, err)
+		apierror.Write(w, r, err)
+
+		return
+	}
+
+	student.Version, err = h.ifMatch(r, ID)
+	if err != nil {
+		apierror.Write(w, r, err)
 
 		return
 	}
 
 	student, err = h.student.Put(r.Context(), ID, &student)
 	if err != nil {
-		handleError(w, err)
+		apierror.Write(w, r, err)
 
 		return
 	}
 
 	student.ID = ID
+	w.Header().Set("ETag", etag(student.Version))
+	write(w, r, c, http.StatusOK, student)
+}
 
-	body, err = json.Marshal(student)
+// Restore brings a deleted student back from the trash.
+func (h handler) Restore(w http.ResponseWriter, r *http.Request) {
+	c, err := codec.Negotiate(r)
 	if err != nil {
-		handleInternalServerError(w, err)
+		apierror.Write(w, r, err)
 
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	ID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		apierror.Write(w, r, errors.InvalidParam{Field: "id"})
 
-	_, err = w.Write(body)
+		return
+	}
+
+	student, err := h.student.Restore(r.Context(), ID)
 	if err != nil {
-		log.Println(err.Error())
+		apierror.Write(w, r, err)
 
 		return
 	}
+
+	w.Header().Set("ETag", etag(student.Version))
+	write(w, r, c, http.StatusOK, student)
 }
 
-func handleError(w http.ResponseWriter, err error) {
-	w.WriteHeader(http.StatusBadRequest)
+// History lists the changes made to a student, oldest first.
+func (h handler) History(w http.ResponseWriter, r *http.Request) {
+	c, err := codec.Negotiate(r)
+	if err != nil {
+		apierror.Write(w, r, err)
 
-	_, err = w.Write([]byte(err.Error()))
+		return
+	}
+
+	ID, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
-		log.Println(err.Error())
+		apierror.Write(w, r, errors.InvalidParam{Field: "id"})
+
+		return
+	}
+
+	entries, err := h.student.History(r.Context(), ID)
+	if err != nil {
+		apierror.Write(w, r, err)
 
 		return
 	}
 
-	return
+	write(w, r, c, http.StatusOK, models.History{Data: entries})
 }
 
-func handleInternalServerError(w http.ResponseWriter, err error) {
-	w.WriteHeader(http.StatusInternalServerError)
+// Revert brings the fields of a student back to an earlier version from its history.
+func (h handler) Revert(w http.ResponseWriter, r *http.Request) {
+	c, err := codec.Negotiate(r)
+	if err != nil {
+		apierror.Write(w, r, err)
 
-	_, err = w.Write([]byte(err.Error()))
+		return
+	}
+
+	ID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		apierror.Write(w, r, errors.InvalidParam{Field: "id"})
+
+		return
+	}
+
+	to, err := strconv.Atoi(mux.Vars(r)["version"])
+	if err != nil {
+		apierror.Write(w, r, errors.InvalidParam{Field: "version"})
+
+		return
+	}
+
+	version, err := h.ifMatch(r, ID)
+	if err != nil {
+		apierror.Write(w, r, err)
+
+		return
+	}
+
+	student, err := h.student.Revert(r.Context(), ID, version, to)
+	if err != nil {
+		apierror.Write(w, r, err)
+
+		return
+	}
+
+	w.Header().Set("ETag", etag(student.Version))
+	write(w, r, c, http.StatusOK, student)
+}
+
+// write answers with v in the format of c.
+func write(w http.ResponseWriter, r *http.Request, c codec.Codec, status int, v interface{}) {
+	body, err := c.Marshal(v)
+	if err != nil {
+		apierror.Write(w, r, errors.Internal{Err: err})
+
+		return
+	}
+
+	codec.SetHeader(w, c)
+	w.WriteHeader(status)
+
+	_, err = w.Write(body)
 	if err != nil {
 		log.Println(err.Error())
+	}
+}
+
+// acceptPatch lists the PATCH body formats, advertised in the Accept-Patch header.
+const acceptPatch = string(models.MergePatch) + ", " + string(models.JSONPatch)
+
+// Patch updates part of a student. The Content-Type selects JSON Merge Patch, also assumed for plain
+// application/json, or JSON Patch.
+func (h handler) Patch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Accept-Patch", acceptPatch)
+
+	c, err := codec.Negotiate(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+
+		return
+	}
+
+	ID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		apierror.Write(w, r, errors.InvalidParam{Field: "id"})
+
+		return
+	}
+
+	version, err := h.ifMatch(r, ID)
+	if err != nil {
+		apierror.Write(w, r, err)
+
+		return
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		apierror.Write(w, r, errors.UnsupportedMediaType{MediaType: r.Header.Get("Content-Type")})
+
+		return
+	}
+
+	if mediaType == "application/json" {
+		mediaType = string(models.MergePatch)
+	}
+
+	codec.Limit(w, r, h.maxBodySize)
+
+	body, err := codec.ReadBody(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+
+		return
+	}
+
+	student, err := h.student.Patch(r.Context(), ID, version, models.PatchType(mediaType), body)
+	if err != nil {
+		apierror.Write(w, r, err)
 
 		return
 	}
 
-	return
+	w.Header().Set("ETag", etag(student.Version))
+	write(w, r, c, http.StatusOK, student)
 }
@@ -2,27 +2,39 @@ package student
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
+	"encoding/xml"
+	stdErrors "errors"
+	"io"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"student-management-system/errors"
+	"student-management-system/http/middleware"
 	"student-management-system/models"
+	"student-management-system/pb"
 	"student-management-system/service"
 
 	"github.com/golang/mock/gomock"
 	"github.com/gorilla/mux"
+	"google.golang.org/protobuf/proto"
 )
 
+// maxBodySize caps the bodies of single students in the tests.
+const maxBodySize = 1 << 10
+
 func TestPost(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockService := service.NewMockStudent(ctrl)
-	mock := New(mockService)
+	mock := New(mockService, false, maxBodySize)
 
 	testcases := []struct {
 		desc      string
@@ -34,18 +46,20 @@ func TestPost(t *testing.T) {
 		{desc: "success:valid details posted successfully", reqBody: models.Student{
 			FirstName:     "arvind",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
+			ContactNumber: "+917348761063",
 		}, expRes: models.Student{
 			ID:            1,
 			FirstName:     "arvind",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
+			ContactNumber: "+917348761063",
 		}, expStatus: http.StatusCreated},
 		{desc: "failure:invalid details  ", reqBody: models.Student{
 			FirstName:     "",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid first name"), expStatus: http.StatusBadRequest},
+			ContactNumber: "+917348761063",
+		}, expErr: errors.Validation{Errors: []errors.FieldError{
+			{Field: "first_name", Rule: "required", Message: "first name is required"},
+		}}, expStatus: http.StatusUnprocessableEntity},
 	}
 
 	for i, tc := range testcases {
@@ -71,7 +85,7 @@ func TestPost_UnmarshallingError(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockService := service.NewMockStudent(ctrl)
-	mock := New(mockService)
+	mock := New(mockService, false, maxBodySize)
 
 	testcases := []struct {
 		desc      string
@@ -83,8 +97,10 @@ func TestPost_UnmarshallingError(t *testing.T) {
 		{desc: "failure:unmarshalling error", reqBody: []byte(`{
 			FirstName:     arvind,
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}`), expErr: errors.New("invalid body"), expStatus: http.StatusBadRequest},
+			ContactNumber: "+917348761063",
+		}`), expErr: stdErrors.New("invalid body"), expStatus: http.StatusBadRequest},
+		{desc: "failure:body too large", reqBody: []byte(`{"first_name":"` + strings.Repeat("a", maxBodySize) + `"}`),
+			expErr: errors.PayloadTooLarge{Limit: maxBodySize}, expStatus: http.StatusRequestEntityTooLarge},
 	}
 
 	for i, tc := range testcases {
@@ -99,12 +115,52 @@ func TestPost_UnmarshallingError(t *testing.T) {
 	}
 }
 
+func TestPost_ContactNumber(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockStudent(ctrl)
+	mock := New(mockService, false, maxBodySize)
+
+	testcases := []struct {
+		desc      string
+		reqBody   string
+		expBody   *models.Student
+		expStatus int
+	}{
+		{desc: "success:string", reqBody: `{"first_name":"arvind","contact_number":"+917348761063"}`,
+			expBody: &models.Student{FirstName: "arvind", ContactNumber: "+917348761063"}, expStatus: http.StatusCreated},
+		{desc: "success:integer sent by older clients", reqBody: `{"first_name":"arvind","contact_number":7348761063}`,
+			expBody: &models.Student{FirstName: "arvind", ContactNumber: "7348761063"}, expStatus: http.StatusCreated},
+		{desc: "success:null", reqBody: `{"first_name":"arvind","contact_number":null}`,
+			expBody: &models.Student{FirstName: "arvind"}, expStatus: http.StatusCreated},
+		{desc: "failure:fraction", reqBody: `{"first_name":"arvind","contact_number":73487.61063}`,
+			expStatus: http.StatusBadRequest},
+		{desc: "failure:boolean", reqBody: `{"first_name":"arvind","contact_number":true}`, expStatus: http.StatusBadRequest},
+	}
+
+	for i, tc := range testcases {
+		req := httptest.NewRequest(http.MethodPost, "/student", strings.NewReader(tc.reqBody))
+		w := httptest.NewRecorder()
+
+		if tc.expBody != nil {
+			mockService.EXPECT().Post(req.Context(), tc.expBody).Return(*tc.expBody, nil)
+		}
+
+		mock.Post(w, req)
+
+		if w.Code != tc.expStatus {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expStatus, w.Code)
+		}
+	}
+}
+
 func TestPut(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockService := service.NewMockStudent(ctrl)
-	mock := New(mockService)
+	mock := New(mockService, false, maxBodySize)
 
 	testcases := []struct {
 		desc      string
@@ -117,18 +173,20 @@ func TestPut(t *testing.T) {
 		{desc: "success:valid details updated successfully", id: "1", reqBody: models.Student{
 			FirstName:     "arvind",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
+			ContactNumber: "+917348761063",
 		}, expRes: models.Student{
 			ID:            1,
 			FirstName:     "arvind",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
+			ContactNumber: "+917348761063",
 		}, expStatus: http.StatusOK},
 		{desc: "failure:invalid details  ", id: "3", reqBody: models.Student{
 			FirstName:     "",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid first name"), expStatus: http.StatusBadRequest},
+			ContactNumber: "+917348761063",
+		}, expErr: errors.Validation{Errors: []errors.FieldError{
+			{Field: "first_name", Rule: "required", Message: "first name is required"},
+		}}, expStatus: http.StatusUnprocessableEntity},
 	}
 
 	for i, tc := range testcases {
@@ -160,7 +218,7 @@ func TestPut_UnmarshallingError(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockService := service.NewMockStudent(ctrl)
-	mock := New(mockService)
+	mock := New(mockService, false, maxBodySize)
 
 	testcases := []struct {
 		desc      string
@@ -173,8 +231,8 @@ func TestPut_UnmarshallingError(t *testing.T) {
 		{desc: "failure:unmarshalling error", id: "1", reqBody: []byte(`{
 			FirstName:     arvind,
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}`), expErr: errors.New("invalid body"), expStatus: http.StatusBadRequest},
+			ContactNumber: "+917348761063",
+		}`), expErr: stdErrors.New("invalid body"), expStatus: http.StatusBadRequest},
 	}
 
 	for i, tc := range testcases {
@@ -195,7 +253,7 @@ func TestPut_StrConvErr(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockService := service.NewMockStudent(ctrl)
-	mock := New(mockService)
+	mock := New(mockService, false, maxBodySize)
 
 	testcases := []struct {
 		desc      string
@@ -208,8 +266,8 @@ func TestPut_StrConvErr(t *testing.T) {
 		{desc: "failure:invalid id will give strconv error", id: "abc", reqBody: models.Student{
 			FirstName:     "arvind",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("strconv error "), expStatus: http.StatusBadRequest},
+			ContactNumber: "+917348761063",
+		}, expErr: stdErrors.New("strconv error "), expStatus: http.StatusBadRequest},
 	}
 
 	for i, tc := range testcases {
@@ -230,12 +288,99 @@ func TestPut_StrConvErr(t *testing.T) {
 	}
 }
 
+func TestPatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockStudent(ctrl)
+	mock := New(mockService, false, maxBodySize)
+
+	testcases := []struct {
+		desc        string
+		contentType string
+		patchType   models.PatchType
+		body        string
+		expRes      models.Student
+		expErr      error
+		expStatus   int
+	}{
+		{desc: "success:merge patch", contentType: "application/merge-patch+json", patchType: models.MergePatch,
+			body: `{"last_name":"yadav"}`, expRes: models.Student{ID: 1, FirstName: "arvind", LastName: "yadav"},
+			expStatus: http.StatusOK},
+		{desc: "success:plain json is a merge patch", contentType: "application/json; charset=utf-8",
+			patchType: models.MergePatch, body: `{"last_name":"yadav"}`, expRes: models.Student{ID: 1, LastName: "yadav"},
+			expStatus: http.StatusOK},
+		{desc: "success:json patch", contentType: "application/json-patch+json", patchType: models.JSONPatch,
+			body: `[{"op":"replace","path":"/last_name","value":"yadav"}]`, expRes: models.Student{ID: 1, LastName: "yadav"},
+			expStatus: http.StatusOK},
+		{desc: "failure:validation", contentType: "application/merge-patch+json", patchType: models.MergePatch,
+			body: `{"first_name":null}`, expErr: errors.Validation{Errors: []errors.FieldError{
+				{Field: "first_name", Rule: "required", Message: "first name is required"}}},
+			expStatus: http.StatusUnprocessableEntity},
+		{desc: "failure:unsupported media type", contentType: "text/plain", patchType: "text/plain", body: "yadav",
+			expErr: errors.UnsupportedMediaType{MediaType: "text/plain"}, expStatus: http.StatusUnsupportedMediaType},
+	}
+
+	for i, tc := range testcases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPatch, "/student/1", bytes.NewReader([]byte(tc.body)))
+		req.Header.Set("Content-Type", tc.contentType)
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+
+		mockService.EXPECT().Patch(req.Context(), 1, 0, tc.patchType, []byte(tc.body)).Return(tc.expRes, tc.expErr)
+		mock.Patch(w, req)
+
+		if w.Code != tc.expStatus {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expStatus, w.Code)
+		}
+
+		if got := w.Header().Get("Accept-Patch"); got != acceptPatch {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, acceptPatch, got)
+		}
+	}
+}
+
+func TestPatch_BadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockStudent(ctrl)
+	mock := New(mockService, false, maxBodySize)
+
+	testcases := []struct {
+		desc        string
+		id          string
+		contentType string
+		body        string
+		expStatus   int
+	}{
+		{desc: "failure:invalid id", id: "abc", contentType: "application/merge-patch+json", body: `{}`,
+			expStatus: http.StatusBadRequest},
+		{desc: "failure:missing content type", id: "1", body: `{}`, expStatus: http.StatusUnsupportedMediaType},
+		{desc: "failure:body too large", id: "1", contentType: "application/merge-patch+json",
+			body: `{"first_name":"` + strings.Repeat("a", maxBodySize) + `"}`, expStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for i, tc := range testcases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPatch, "/student/"+tc.id, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", tc.contentType)
+		req = mux.SetURLVars(req, map[string]string{"id": tc.id})
+
+		mock.Patch(w, req)
+
+		if w.Code != tc.expStatus {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expStatus, w.Code)
+		}
+	}
+}
+
 func TestGetByID(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockService := service.NewMockStudent(ctrl)
-	mock := New(mockService)
+	mock := New(mockService, false, maxBodySize)
 
 	testcases := []struct {
 		desc      string
@@ -248,10 +393,12 @@ func TestGetByID(t *testing.T) {
 			ID:            1,
 			FirstName:     "arvind",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
+			ContactNumber: "+917348761063",
 		}, expStatus: http.StatusOK},
-		{desc: "failure: invalid id", id: "1111", expErr: errors.New("invalid id not present in db rows set"),
-			expStatus: http.StatusBadRequest},
+		{desc: "failure: invalid id", id: "1111", expErr: errors.EntityNotFound{Entity: "student", ID: "1111"},
+			expStatus: http.StatusNotFound},
+		{desc: "failure: internal error", id: "1", expErr: errors.Internal{Err: stdErrors.New("connection refused")},
+			expStatus: http.StatusInternalServerError},
 	}
 
 	for i, tc := range testcases {
@@ -279,7 +426,7 @@ func TestGetByID_StrConvErr(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockService := service.NewMockStudent(ctrl)
-	mock := New(mockService)
+	mock := New(mockService, false, maxBodySize)
 
 	testcases := []struct {
 		desc      string
@@ -288,7 +435,7 @@ func TestGetByID_StrConvErr(t *testing.T) {
 		expErr    error
 		expStatus int
 	}{
-		{desc: "failure: strconv error", id: "abc", expErr: errors.New("invalid id will give strconv error"),
+		{desc: "failure: strconv error", id: "abc", expErr: stdErrors.New("invalid id will give strconv error"),
 			expStatus: http.StatusBadRequest},
 	}
 
@@ -310,36 +457,66 @@ func TestGet(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockService := service.NewMockStudent(ctrl)
-	mock := New(mockService)
+	mock := New(mockService, false, maxBodySize)
 
 	testcases := []struct {
 		desc      string
-		firstName string
-		lastName  string
-		expRes    []models.Student
+		query     string
+		expFilter models.Filter
+		expRes    models.StudentList
 		expErr    error
 		expStatus int
 	}{
-		{desc: "success:valid query params firstName and lastName", firstName: "arvind", lastName: "yadav", expRes: []models.Student{
-			{ID: 1, FirstName: "arvind", LastName: "yadav", Nationality: "Indian", ContactNumber: 7348761063},
-		}, expStatus: http.StatusOK},
-		{desc: "success:valid query params firstName ", firstName: "anuj", expRes: []models.Student{
-			{ID: 1, FirstName: "arvind", Nationality: "Indian", ContactNumber: 1234567891},
-		}, expStatus: http.StatusOK},
-		{desc: "success:valid query params lastName ", lastName: "bhagat", expRes: []models.Student{
-			{ID: 1, FirstName: "chetan", LastName: "bhagat", Nationality: "Indian", ContactNumber: 1234567875},
-		}, expStatus: http.StatusOK},
-		{desc: "failure:invalid query params", firstName: "12345", lastName: "5678",
-			expErr: errors.New("no rows in db result set"), expStatus: http.StatusBadRequest},
-		{desc: "failure:invalid query params", firstName: "", lastName: "",
-			expErr: errors.New("missing  both query params"), expStatus: http.StatusBadRequest},
+		{desc: "success:valid query params firstName and lastName", query: "firstName=arvind&lastName=yadav",
+			expFilter: models.Filter{FirstName: "arvind", LastName: "yadav"}, expRes: models.StudentList{Data: []models.Student{
+				{ID: 1, FirstName: "arvind", LastName: "yadav", Nationality: "Indian", ContactNumber: "+917348761063"},
+			}, Meta: models.Page{Total: 1, Limit: 20}}, expStatus: http.StatusOK},
+		{desc: "success:no query params lists all students", expRes: models.StudentList{Data: []models.Student{
+			{ID: 1, FirstName: "arvind", Nationality: "Indian", ContactNumber: "+911234567891"},
+		}, Meta: models.Page{Total: 1, Limit: 20}}, expStatus: http.StatusOK},
+		{desc: "success:filters, sorting and pagination", query: "gender=M&min_family_income=100&max_family_income=500" +
+			"&dob_from=2000-01-01&sort=last_name,-dob&limit=10&offset=20", expFilter: models.Filter{Gender: "M",
+			MinFamilyIncome: 100, MaxFamilyIncome: 500, DobFrom: models.NewDate(2000, time.January, 1), Limit: 10, Offset: 20,
+			Sort: []models.Sort{{Field: "last_name"}, {Field: "dob", Desc: true}}}, expStatus: http.StatusOK},
+		{desc: "failure:service error", query: "sort=password", expFilter: models.Filter{Sort: []models.Sort{{Field: "password"}}},
+			expErr: errors.InvalidParam{Field: "sort", Reason: "cannot sort on password"}, expStatus: http.StatusBadRequest},
 	}
 
 	for i, tc := range testcases {
 		w := httptest.NewRecorder()
-		req := httptest.NewRequest(http.MethodGet, "/student?"+"firstName="+tc.firstName+"&"+"lastName="+tc.lastName, nil)
+		req := httptest.NewRequest(http.MethodGet, "/student?"+tc.query, nil)
 
-		mockService.EXPECT().Get(req.Context(), tc.firstName, tc.lastName).Return(tc.expRes, tc.expErr)
+		mockService.EXPECT().Get(req.Context(), &tc.expFilter).Return(tc.expRes, tc.expErr)
+
+		mock.Get(w, req)
+
+		if w.Code != tc.expStatus {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expStatus, w.Code)
+		}
+	}
+}
+
+func TestGet_InvalidQueryParams(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockStudent(ctrl)
+	mock := New(mockService, false, maxBodySize)
+
+	testcases := []struct {
+		desc      string
+		query     string
+		expStatus int
+	}{
+		{desc: "failure:non numeric limit", query: "limit=ten", expStatus: http.StatusBadRequest},
+		{desc: "failure:non numeric income", query: "min_family_income=abc", expStatus: http.StatusBadRequest},
+		{desc: "failure:impossible date", query: "dob_from=2001-02-29", expStatus: http.StatusBadRequest},
+		{desc: "failure:old date format", query: "dob_to=12-31-2005", expStatus: http.StatusBadRequest},
+	}
+
+	for i, tc := range testcases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/student?"+tc.query, nil)
 
 		mock.Get(w, req)
 
@@ -354,7 +531,7 @@ func TestDelete(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockService := service.NewMockStudent(ctrl)
-	mock := New(mockService)
+	mock := New(mockService, false, maxBodySize)
 
 	testcases := []struct {
 		desc      string
@@ -363,8 +540,8 @@ func TestDelete(t *testing.T) {
 		expStatus int
 	}{
 		{desc: "success:deleted successfully", id: "1", expStatus: http.StatusNoContent},
-		{desc: "failure:id is not present", id: "1111", expErr: errors.New("no rows present in db result set"),
-			expStatus: http.StatusBadRequest},
+		{desc: "failure:id is not present", id: "1111", expErr: errors.EntityNotFound{Entity: "student", ID: "1111"},
+			expStatus: http.StatusNotFound},
 	}
 
 	for i, tc := range testcases {
@@ -377,7 +554,7 @@ func TestDelete(t *testing.T) {
 			log.Println(err.Error())
 		}
 
-		mockService.EXPECT().Delete(req.Context(), ID).Return(tc.expErr)
+		mockService.EXPECT().Delete(req.Context(), ID, 0).Return(tc.expErr)
 
 		mock.Delete(w, req)
 
@@ -392,7 +569,7 @@ func TestDelete_StrConvErr(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockService := service.NewMockStudent(ctrl)
-	mock := New(mockService)
+	mock := New(mockService, false, maxBodySize)
 
 	testcases := []struct {
 		desc      string
@@ -400,7 +577,7 @@ func TestDelete_StrConvErr(t *testing.T) {
 		expErr    error
 		expStatus int
 	}{
-		{desc: "failure:invalid id will give strconv error", id: "abc", expErr: errors.New("strconv error "),
+		{desc: "failure:invalid id will give strconv error", id: "abc", expErr: stdErrors.New("strconv error "),
 			expStatus: http.StatusBadRequest},
 	}
 
@@ -416,3 +593,702 @@ func TestDelete_StrConvErr(t *testing.T) {
 		}
 	}
 }
+
+func TestGetByID_IfNoneMatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockStudent(ctrl)
+	mock := New(mockService, false, maxBodySize)
+
+	student := models.Student{ID: 1, FirstName: "arvind", Version: 3}
+	body, _ := json.Marshal(student)
+	tag := representationETag(3, body)
+
+	testcases := []struct {
+		desc        string
+		accept      string
+		ifNoneMatch string
+		expStatus   int
+	}{
+		{desc: "success:no condition", expStatus: http.StatusOK},
+		{desc: "success:current representation is not modified", ifNoneMatch: tag, expStatus: http.StatusNotModified},
+		{desc: "success:weak comparison", ifNoneMatch: `"1", W/` + tag, expStatus: http.StatusNotModified},
+		{desc: "success:older version is sent again", ifNoneMatch: strings.Replace(tag, `"3-`, `"2-`, 1), expStatus: http.StatusOK},
+		{desc: "success:other format is sent", accept: "application/xml", ifNoneMatch: tag, expStatus: http.StatusOK},
+		{desc: "success:version alone is sent again", ifNoneMatch: `"3"`, expStatus: http.StatusOK},
+	}
+
+	for i, tc := range testcases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/student/1", nil)
+		req.Header.Set("Accept", tc.accept)
+		req.Header.Set("If-None-Match", tc.ifNoneMatch)
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+
+		mockService.EXPECT().GetByID(req.Context(), 1).Return(student, nil)
+		mock.GetByID(w, req)
+
+		if w.Code != tc.expStatus {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expStatus, w.Code)
+		}
+
+		if got := w.Header().Get("ETag"); (got == tag) != (tc.accept == "") || !strings.HasPrefix(got, `"3-`) {
+			t.Errorf("testcases %d failed expected the tag of the representation got %v", i+1, got)
+		}
+
+		if got := w.Header().Get("Vary"); got != "Authorization" {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, "Authorization", got)
+		}
+	}
+}
+
+func TestGet_IfNoneMatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockStudent(ctrl)
+	mock := New(mockService, false, maxBodySize)
+
+	list := models.StudentList{Data: []models.Student{{ID: 1, FirstName: "arvind"}}, Meta: models.Page{Total: 1, Limit: 20}}
+
+	mockService.EXPECT().Get(gomock.Any(), gomock.Any()).Return(list, nil).Times(2)
+
+	w := httptest.NewRecorder()
+	mock.Get(w, httptest.NewRequest(http.MethodGet, "/student", nil))
+
+	tag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || !strings.HasPrefix(tag, `W/"`) {
+		t.Fatalf("expected 200 with a weak ETag got %v %q", w.Code, tag)
+	}
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/student", nil)
+	req.Header.Set("If-None-Match", tag)
+	mock.Get(w, req)
+
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("expected %v with no body got %v %q", http.StatusNotModified, w.Code, w.Body.String())
+	}
+
+	if got := strings.Join(w.Header().Values("Vary"), ", "); got != "Authorization, Accept" {
+		t.Errorf("expected Vary %q got %q", "Authorization, Accept", got)
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockStudent(ctrl)
+
+	testcases := []struct {
+		desc           string
+		requireIfMatch bool
+		ifMatch        string
+		expVersion     int
+		expCall        bool
+		expStatus      int
+	}{
+		{desc: "success:no header", expCall: true, expStatus: http.StatusNoContent},
+		{desc: "success:any version", requireIfMatch: true, ifMatch: "*", expCall: true, expStatus: http.StatusNoContent},
+		{desc: "success:version from the etag", requireIfMatch: true, ifMatch: `"3"`, expVersion: 3, expCall: true,
+			expStatus: http.StatusNoContent},
+		{desc: "success:version from the etag of a representation", ifMatch: `"3-1f2e3d4c5b6a7980"`, expVersion: 3,
+			expCall: true, expStatus: http.StatusNoContent},
+		{desc: "failure:missing header when required", requireIfMatch: true, expStatus: http.StatusPreconditionRequired},
+		{desc: "failure:weak etag never matches", ifMatch: `W/"3"`, expStatus: http.StatusPreconditionFailed},
+		{desc: "failure:malformed etag", ifMatch: "3", expStatus: http.StatusPreconditionFailed},
+		{desc: "failure:etag without a digest", ifMatch: `"3-"`, expStatus: http.StatusPreconditionFailed},
+	}
+
+	for i, tc := range testcases {
+		mock := New(mockService, tc.requireIfMatch, maxBodySize)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodDelete, "/student/1", nil)
+		req.Header.Set("If-Match", tc.ifMatch)
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+
+		if tc.expCall {
+			mockService.EXPECT().Delete(req.Context(), 1, tc.expVersion).Return(nil)
+		}
+
+		mock.Delete(w, req)
+
+		if w.Code != tc.expStatus {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expStatus, w.Code)
+		}
+	}
+}
+
+func TestPut_ETag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockStudent(ctrl)
+	mock := New(mockService, false, maxBodySize)
+
+	reqBody := models.Student{FirstName: "arvind", Nationality: "Indian", ContactNumber: "+917348761063"}
+	expStudent := reqBody
+	expStudent.Version = 2
+
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		log.Println(err.Error())
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/student/1", bytes.NewReader(body))
+	req.Header.Set("If-Match", `"2"`)
+	req = mux.SetURLVars(req, map[string]string{"id": "1"})
+
+	mockService.EXPECT().Put(req.Context(), 1, &expStudent).Return(models.Student{FirstName: "arvind", Version: 3}, nil)
+	mock.Put(w, req)
+
+	if got := w.Header().Get("ETag"); w.Code != http.StatusOK || got != `"3"` {
+		t.Errorf("expected %v with ETag %v got %v %v", http.StatusOK, `"3"`, w.Code, got)
+	}
+}
+
+func TestTrash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockStudent(ctrl)
+	mock := New(mockService, false, maxBodySize)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/student/trash?sort=-deleted_at", nil)
+
+	expFilter := models.Filter{Deleted: true, Sort: []models.Sort{{Field: "deleted_at", Desc: true}}}
+
+	mockService.EXPECT().Get(req.Context(), &expFilter).Return(models.StudentList{Data: []models.Student{}}, nil)
+	mock.Trash(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected %v got %v", http.StatusOK, w.Code)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockStudent(ctrl)
+	mock := New(mockService, false, maxBodySize)
+
+	testcases := []struct {
+		desc      string
+		id        string
+		expRes    models.Student
+		expErr    error
+		expStatus int
+	}{
+		{desc: "success:restored", id: "1", expRes: models.Student{ID: 1, FirstName: "arvind", Version: 4},
+			expStatus: http.StatusOK},
+		{desc: "failure:not in the trash", id: "2", expErr: errors.EntityNotFound{Entity: "deleted student", ID: "2"},
+			expStatus: http.StatusNotFound},
+		{desc: "failure:invalid id", id: "abc", expStatus: http.StatusBadRequest},
+	}
+
+	for i, tc := range testcases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/student/"+tc.id+"/restore", nil)
+		req = mux.SetURLVars(req, map[string]string{"id": tc.id})
+
+		if id, err := strconv.Atoi(tc.id); err == nil {
+			mockService.EXPECT().Restore(req.Context(), id).Return(tc.expRes, tc.expErr)
+		}
+
+		mock.Restore(w, req)
+
+		if w.Code != tc.expStatus {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expStatus, w.Code)
+		}
+	}
+}
+
+func TestHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockStudent(ctrl)
+	mock := New(mockService, false, maxBodySize)
+
+	testcases := []struct {
+		desc      string
+		id        string
+		expRes    []models.AuditEntry
+		expErr    error
+		expStatus int
+		expBody   string
+	}{
+		{desc: "success:history", id: "1", expRes: []models.AuditEntry{{ID: 7, StudentID: 1, Version: 1,
+			Action: models.ActionCreate, Actor: "admin", At: time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC),
+			Changes: []models.Change{}}}, expStatus: http.StatusOK,
+			expBody: `{"data":[{"id":7,"student_id":1,"version":1,"action":"create","actor":"admin",` +
+				`"at":"2024-03-01T10:00:00Z","changes":[]}]}`},
+		{desc: "failure:no such student", id: "2", expErr: errors.EntityNotFound{Entity: "student", ID: "2"},
+			expStatus: http.StatusNotFound},
+		{desc: "failure:invalid id", id: "abc", expStatus: http.StatusBadRequest},
+	}
+
+	for i, tc := range testcases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/student/"+tc.id+"/history", nil)
+		req = mux.SetURLVars(req, map[string]string{"id": tc.id})
+
+		if id, err := strconv.Atoi(tc.id); err == nil {
+			mockService.EXPECT().History(req.Context(), id).Return(tc.expRes, tc.expErr)
+		}
+
+		mock.History(w, req)
+
+		if w.Code != tc.expStatus {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expStatus, w.Code)
+		}
+
+		if tc.expBody != "" && w.Body.String() != tc.expBody {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expBody, w.Body.String())
+		}
+	}
+}
+
+func TestRevert(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockStudent(ctrl)
+	mock := New(mockService, false, maxBodySize)
+
+	testcases := []struct {
+		desc      string
+		version   string
+		ifMatch   string
+		expCall   bool
+		expRes    models.Student
+		expErr    error
+		expStatus int
+		expETag   string
+	}{
+		{desc: "success:reverted", version: "1", ifMatch: `"3"`, expCall: true,
+			expRes: models.Student{ID: 1, FirstName: "arvind", Version: 4}, expStatus: http.StatusOK, expETag: `"4"`},
+		{desc: "failure:not an earlier version", version: "3", expCall: true,
+			expErr:    errors.InvalidParam{Field: "version", Reason: "must be an earlier version of the student"},
+			expStatus: http.StatusBadRequest},
+		{desc: "failure:invalid version", version: "abc", expStatus: http.StatusBadRequest},
+	}
+
+	for i, tc := range testcases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/student/1/history/"+tc.version+"/revert", nil)
+		req = mux.SetURLVars(req, map[string]string{"id": "1", "version": tc.version})
+
+		if tc.ifMatch != "" {
+			req.Header.Set("If-Match", tc.ifMatch)
+		}
+
+		if tc.expCall {
+			to, _ := strconv.Atoi(tc.version)
+			version, _ := strconv.Atoi(strings.Trim(tc.ifMatch, `"`))
+
+			mockService.EXPECT().Revert(req.Context(), 1, version, to).Return(tc.expRes, tc.expErr)
+		}
+
+		mock.Revert(w, req)
+
+		if w.Code != tc.expStatus {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expStatus, w.Code)
+		}
+
+		if w.Header().Get("ETag") != tc.expETag {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expETag, w.Header().Get("ETag"))
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockStudent(ctrl)
+
+	student := models.Student{FirstName: "arvind", Nationality: "Indian", ContactNumber: "+917348761063"}
+
+	batch, err := proto.Marshal(&pb.Batch{Items: []*pb.BatchItem{{Student: pb.NewStudent(&student)}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		desc           string
+		method         string
+		contentType    string
+		accept         string
+		body           string
+		requireIfMatch bool
+		mock           func()
+		expStatus      int
+		expBody        string
+	}{
+		{desc: "success:every student created", method: http.MethodPost,
+			body: `{"items":[{"student":{"first_name":"arvind","nationality":"Indian","contact_number":"+917348761063"}}]}`,
+			mock: func() {
+				mockService.EXPECT().PostBatch(gomock.Any(), []models.BatchItem{{Student: &student}}, models.Atomic).
+					Return([]models.BatchResult{{ID: 5, Version: 1}}, nil)
+			}, expStatus: http.StatusCreated, expBody: `{"data":[{"index":0,"status":201,"id":5,"version":1}]}`},
+		{desc: "success:some items failed", method: http.MethodDelete,
+			body: `{"mode":"best_effort","items":[{"id":1,"version":2},{"id":2,"version":1}]}`,
+			mock: func() {
+				mockService.EXPECT().DeleteBatch(gomock.Any(), []models.BatchItem{{ID: 1, Version: 2}, {ID: 2, Version: 1}},
+					models.BestEffort).Return([]models.BatchResult{{ID: 1}, {Index: 1,
+					Err: errors.PreconditionFailed{Entity: "student", ID: "2"}}}, nil)
+			}, expStatus: http.StatusMultiStatus, expBody: `{"data":[{"index":0,"status":204,"id":1},{"index":1,"status":412,` +
+				`"error":{"code":"PRECONDITION_FAILED","message":"student 2 has been modified since it was read"}}]}`},
+		{desc: "failure:version required", method: http.MethodPut, requireIfMatch: true,
+			body: `{"items":[{"id":1,"student":{"first_name":"arvind"}}]}`, expStatus: http.StatusBadRequest},
+		{desc: "failure:unknown mode", method: http.MethodPut, body: `{"mode":"some","items":[{"id":1,"version":2}]}`,
+			mock: func() {
+				mockService.EXPECT().PutBatch(gomock.Any(), gomock.Any(), models.BatchMode("some")).
+					Return(nil, errors.InvalidParam{Field: "mode"})
+			}, expStatus: http.StatusBadRequest},
+		{desc: "failure:malformed body", method: http.MethodPost, body: `{"items":`, expStatus: http.StatusBadRequest},
+		{desc: "success:xml", method: http.MethodPost, contentType: "application/xml", accept: "application/xml",
+			body: `<batch><mode>best_effort</mode><items><item><student><first_name>arvind</first_name>` +
+				`<nationality>Indian</nationality><contact_number>+917348761063</contact_number></student></item></items></batch>`,
+			mock: func() {
+				mockService.EXPECT().PostBatch(gomock.Any(), []models.BatchItem{{Student: &student}}, models.BestEffort).
+					Return([]models.BatchResult{{ID: 5, Version: 1}}, nil)
+			}, expStatus: http.StatusCreated, expBody: xml.Header +
+				`<batch><data><result><index>0</index><status>201</status><id>5</id><version>1</version></result></data></batch>`},
+		{desc: "success:protobuf", method: http.MethodPost, contentType: "application/x-protobuf",
+			body: string(batch), mock: func() {
+				mockService.EXPECT().PostBatch(gomock.Any(), []models.BatchItem{{Student: &student}}, models.Atomic).
+					Return([]models.BatchResult{{ID: 5, Version: 1}}, nil)
+			}, expStatus: http.StatusCreated, expBody: `{"data":[{"index":0,"status":201,"id":5,"version":1}]}`},
+		{desc: "failure:body too large", method: http.MethodDelete,
+			body: `{"items":[` + strings.Repeat(`{"id":1},`, maxBatchSize/9) + `{"id":1}]}`, expStatus: http.StatusRequestEntityTooLarge},
+		{desc: "failure:not acceptable", method: http.MethodPost, accept: "text/csv", body: `{"items":[]}`,
+			expStatus: http.StatusNotAcceptable},
+	}
+
+	for i, tc := range testcases {
+		h := New(mockService, tc.requireIfMatch, maxBodySize)
+		handlers := map[string]http.HandlerFunc{http.MethodPost: h.PostBatch, http.MethodPut: h.PutBatch,
+			http.MethodDelete: h.DeleteBatch}
+
+		if tc.mock != nil {
+			tc.mock()
+		}
+
+		req := httptest.NewRequest(tc.method, "/students:batch", strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", tc.contentType)
+		req.Header.Set("Accept", tc.accept)
+		w := httptest.NewRecorder()
+
+		handlers[tc.method](w, req)
+
+		if w.Code != tc.expStatus {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expStatus, w.Code)
+		}
+
+		if tc.expBody != "" && w.Body.String() != tc.expBody {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expBody, w.Body.String())
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockStudent(ctrl)
+	mock := New(mockService, false, maxBodySize)
+
+	items := []models.BatchItem{{Student: &models.Student{FirstName: "arvind", Nationality: "Indian"}},
+		{Student: &models.Student{FirstName: "ravi", Nationality: "Indian"}}}
+	created := []models.BatchResult{{ID: 5, Version: 1}, {Index: 1, Err: errors.EntityAlreadyExists{Entity: "student", ID: "3"}}}
+
+	testcases := []struct {
+		desc      string
+		query     string
+		accept    string
+		body      string
+		mock      func()
+		expStatus int
+		expType   string
+		expBody   string
+	}{
+		{desc: "success:json report", query: "?map=Name=first_name",
+			mock: func() {
+				mockService.EXPECT().Import(gomock.Any(), items, false).Return(created, nil)
+			}, expStatus: http.StatusOK, expBody: `{"dry_run":false,"created":1,"failed":1,"data":[{"line":2,"id":5},` +
+				`{"line":3,"error":{"code":"ALREADY_EXISTS","message":"student already exists with id 3","existing_id":"3"}}]}`},
+		{desc: "success:xml report", query: "?map=Name=first_name", accept: "application/xml",
+			mock: func() {
+				mockService.EXPECT().Import(gomock.Any(), items, false).Return(created, nil)
+			}, expStatus: http.StatusOK, expType: "application/xml", expBody: xml.Header + "<import><dry_run>false</dry_run>" +
+				"<created>1</created><failed>1</failed><data><result><line>2</line><id>5</id></result><result><line>3</line>" +
+				"<error><code>ALREADY_EXISTS</code><message>student already exists with id 3</message>" +
+				"<existing_id>3</existing_id><errors></errors></error></result></data></import>"},
+		{desc: "success:protobuf report", query: "?map=Name=first_name", accept: "application/x-protobuf",
+			mock: func() {
+				mockService.EXPECT().Import(gomock.Any(), items, false).Return(created, nil)
+			}, expStatus: http.StatusOK, expType: "application/x-protobuf"},
+		{desc: "success:csv refused", query: "?map=Name=first_name", accept: "text/csv;q=0, */*",
+			mock: func() {
+				mockService.EXPECT().Import(gomock.Any(), items, false).Return(created, nil)
+			}, expStatus: http.StatusOK, expType: "application/json"},
+		{desc: "success:csv report of a dry run", query: "?map=Name=first_name&dry_run=true", accept: "text/csv",
+			mock: func() {
+				mockService.EXPECT().Import(gomock.Any(), items, true).Return(created, nil)
+			}, expStatus: http.StatusOK, expType: "text/csv",
+			expBody: "line,field,rule,message\n3,,unique,student already exists with id 3\n"},
+		{desc: "failure:not acceptable", accept: "image/png", expStatus: http.StatusNotAcceptable},
+		{desc: "failure:invalid dry_run", query: "?dry_run=maybe", expStatus: http.StatusBadRequest},
+		{desc: "failure:unmapped header", expStatus: http.StatusBadRequest},
+		{desc: "failure:file too large", query: "?map=Name=first_name",
+			body:      "Name,nationality\narvind," + strings.Repeat("x", maxImportSize) + "\n",
+			expStatus: http.StatusRequestEntityTooLarge,
+			expBody:   `{"code":"PAYLOAD_TOO_LARGE","message":"request body is larger than 8388608 bytes"}`},
+		{desc: "failure:too many rows", query: "?map=Name=first_name",
+			body: "Name,nationality\n" + strings.Repeat("arvind,Indian\n", models.MaxImport+1), expStatus: http.StatusBadRequest,
+			expBody: `{"code":"INVALID_PARAM","message":"invalid body: has more than 10000 rows","field":"body"}`},
+	}
+
+	for i, tc := range testcases {
+		if tc.mock != nil {
+			tc.mock()
+		}
+
+		if tc.body == "" {
+			tc.body = "Name,nationality\narvind,Indian\nravi,Indian\n"
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/student/import"+tc.query, strings.NewReader(tc.body))
+		req.Header.Set("Accept", tc.accept)
+		w := httptest.NewRecorder()
+
+		mock.Import(w, req)
+
+		if w.Code != tc.expStatus {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expStatus, w.Code)
+		}
+
+		if tc.expType != "" && w.Header().Get("Content-Type") != tc.expType {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expType, w.Header().Get("Content-Type"))
+		}
+
+		if tc.expBody != "" && w.Body.String() != tc.expBody {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expBody, w.Body.String())
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockStudent(ctrl)
+	mock := New(mockService, false, maxBodySize)
+
+	export := func(students ...models.Student) func(ctx context.Context, filter *models.Filter, fn func(models.Student) error) error {
+		return func(ctx context.Context, filter *models.Filter, fn func(models.Student) error) error {
+			for _, s := range students {
+				if err := fn(s); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+	}
+
+	testcases := []struct {
+		desc      string
+		query     string
+		mock      func()
+		expStatus int
+		expType   string
+		expBody   string
+	}{
+		{desc: "success:csv by default", query: "?nationality=Indian",
+			mock: func() {
+				mockService.EXPECT().Export(gomock.Any(), &models.Filter{Nationality: "Indian"}, gomock.Any()).
+					DoAndReturn(export(models.Student{ID: 1, FirstName: "arvind", Nationality: "Indian"}))
+			}, expStatus: http.StatusOK, expType: "text/csv",
+			expBody: "id,first_name,last_name,gender,dob,mother_tongue,nationality,father_name,mother_name,contact_number," +
+				"home_contact_number,emergency_contact_number,father_occupation,mother_occupation,family_income\n" +
+				"1,arvind,,,,,Indian,,,,,,,,\n"},
+		{desc: "success:json lines without students", query: "?format=jsonl",
+			mock: func() {
+				mockService.EXPECT().Export(gomock.Any(), &models.Filter{}, gomock.Any()).DoAndReturn(export())
+			}, expStatus: http.StatusOK, expType: "application/x-ndjson"},
+		{desc: "failure:unknown format", query: "?format=pdf", expStatus: http.StatusBadRequest},
+		{desc: "failure:forbidden before the first student",
+			mock: func() {
+				mockService.EXPECT().Export(gomock.Any(), &models.Filter{}, gomock.Any()).
+					Return(errors.Forbidden{Permission: "student:read"})
+			}, expStatus: http.StatusForbidden},
+	}
+
+	for i, tc := range testcases {
+		if tc.mock != nil {
+			tc.mock()
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/student/export"+tc.query, nil)
+		w := httptest.NewRecorder()
+
+		mock.Export(w, req)
+
+		if w.Code != tc.expStatus {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expStatus, w.Code)
+		}
+
+		if tc.expType != "" && w.Header().Get("Content-Type") != tc.expType {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expType, w.Header().Get("Content-Type"))
+		}
+
+		if tc.expBody != "" && w.Body.String() != tc.expBody {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expBody, w.Body.String())
+		}
+	}
+}
+
+func TestExport_AbortedMidway(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockStudent(ctrl)
+	mock := New(mockService, false, maxBodySize)
+
+	mockService.EXPECT().Export(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, filter *models.Filter, fn func(models.Student) error) error {
+			if err := fn(models.Student{ID: 1}); err != nil {
+				return err
+			}
+
+			return errors.Internal{Err: stdErrors.New("connection reset")}
+		})
+
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Errorf("expected %v got %v", http.ErrAbortHandler, r)
+		}
+	}()
+
+	mock.Export(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/student/export", nil))
+}
+
+func TestExport_LongerThanWriteTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockStudent(ctrl)
+
+	const timeout = 100 * time.Millisecond
+
+	mockService.EXPECT().Export(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, filter *models.Filter, fn func(models.Student) error) error {
+			for id := 1; id <= 5; id++ {
+				time.Sleep(timeout / 2)
+
+				if err := fn(models.Student{ID: id}); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(New(mockService, false, maxBodySize).Export))
+	srv.Config.WriteTimeout = timeout
+	srv.Config.ConnContext = middleware.WriteDeadlines(timeout)
+	srv.Start()
+
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/student/export?format=jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil || strings.Count(string(body), "\n") != 5 {
+		t.Errorf("expected 5 students got %q, %v", body, err)
+	}
+}
+
+func TestContentNegotiation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockStudent(ctrl)
+	mock := New(mockService, false, maxBodySize)
+
+	arvind := models.Student{ID: 1, FirstName: "arvind", Nationality: "Indian", Version: 1}
+
+	testcases := []struct {
+		desc        string
+		method      string
+		accept      string
+		contentType string
+		body        string
+		mock        func()
+		expStatus   int
+		expType     string
+		expBody     string
+	}{
+		{desc: "success:xml student", method: http.MethodGet, accept: "application/xml",
+			mock: func() {
+				mockService.EXPECT().GetByID(gomock.Any(), 1).Return(arvind, nil)
+			}, expStatus: http.StatusOK, expType: "application/xml", expBody: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<student><id>1</id><first_name>arvind</first_name><dob></dob><nationality>Indian</nationality></student>`},
+		{desc: "success:xml body answered with ndjson", method: http.MethodPost, accept: "application/x-ndjson",
+			contentType: "application/xml", body: `<student><first_name>arvind</first_name><nationality>Indian</nationality></student>`,
+			mock: func() {
+				mockService.EXPECT().Post(gomock.Any(), &models.Student{FirstName: "arvind", Nationality: "Indian"}).Return(arvind, nil)
+			}, expStatus: http.StatusCreated, expType: "application/x-ndjson",
+			expBody: `{"id":1,"first_name":"arvind","dob":null,"nationality":"Indian"}` + "\n"},
+		{desc: "failure:not acceptable before the student is read", method: http.MethodGet, accept: "text/html",
+			expStatus: http.StatusNotAcceptable, expType: "application/json"},
+		{desc: "failure:unsupported body", method: http.MethodPost, contentType: "text/plain", body: "arvind",
+			expStatus: http.StatusUnsupportedMediaType, expType: "application/json"},
+		{desc: "failure:error in the accepted format", method: http.MethodPost, accept: "application/xml",
+			contentType: "application/xml", body: `<student>`, expStatus: http.StatusBadRequest, expType: "application/xml"},
+	}
+
+	for i, tc := range testcases {
+		if tc.mock != nil {
+			tc.mock()
+		}
+
+		req := httptest.NewRequest(tc.method, "/student/1", strings.NewReader(tc.body))
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		req.Header.Set("Accept", tc.accept)
+		req.Header.Set("Content-Type", tc.contentType)
+		w := httptest.NewRecorder()
+
+		if tc.method == http.MethodGet {
+			mock.GetByID(w, req)
+		} else {
+			mock.Post(w, req)
+		}
+
+		if w.Code != tc.expStatus {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expStatus, w.Code)
+		}
+
+		if w.Header().Get("Content-Type") != tc.expType {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expType, w.Header().Get("Content-Type"))
+		}
+
+		if tc.expBody != "" && w.Body.String() != tc.expBody {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expBody, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package student
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+
+	"student-management-system/errors"
+	"student-management-system/http/apierror"
+	"student-management-system/http/codec"
+	"student-management-system/models"
+	"student-management-system/tabular"
+)
+
+// maxImportSize caps the size of an imported file, which leaves a few hundred bytes to each of models.MaxImport
+// rows.
+const maxImportSize = 8 << 20
+
+// Import creates students from a CSV file whose header names the field of every column, with map=header=field
+// parameters for the headers that are not field names. With dry_run=true nothing is created. The answer lists
+// the outcome of every row in the negotiated format, or is the error report as a CSV attachment when the client
+// prefers text/csv.
+func (h handler) Import(w http.ResponseWriter, r *http.Request) {
+	c, csv, err := codec.NegotiateOffer(r, "text/csv")
+	if err != nil {
+		apierror.Write(w, r, err)
+
+		return
+	}
+
+	query := r.URL.Query()
+
+	var dryRun bool
+
+	if v := query.Get("dry_run"); v != "" {
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			apierror.Write(w, r, errors.InvalidParam{Field: "dry_run", Reason: "must be true or false"})
+
+			return
+		}
+	}
+
+	mapping, err := tabular.ParseMapping(query["map"])
+	if err != nil {
+		apierror.Write(w, r, err)
+
+		return
+	}
+
+	codec.Limit(w, r, maxImportSize)
+
+	results, err := tabular.Import(r.Context(), r.Body, mapping, models.MaxImport,
+		func(ctx context.Context, items []models.BatchItem) ([]models.BatchResult, error) {
+			return h.student.Import(ctx, items, dryRun)
+		})
+	if err != nil {
+		apierror.Write(w, r, err)
+
+		return
+	}
+
+	if csv {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Add("Vary", "Accept")
+		w.Header().Set("Content-Disposition", `attachment; filename="import-errors.csv"`)
+		w.WriteHeader(http.StatusOK)
+
+		if err := tabular.WriteReport(w, results); err != nil {
+			log.Println(err.Error())
+		}
+
+		return
+	}
+
+	report := models.ImportReport{DryRun: dryRun, Data: make([]models.ImportStatus, len(results))}
+
+	for i, res := range results {
+		report.Data[i] = models.ImportStatus{Line: res.Line, ID: res.ID}
+
+		if res.Err == nil {
+			report.Created++
+
+			continue
+		}
+
+		_, e := apierror.Response(r, res.Err)
+		report.Data[i].Error = &e
+		report.Failed++
+	}
+
+	write(w, r, c, http.StatusOK, report)
+}
@@ -0,0 +1,90 @@
+package student
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"student-management-system/errors"
+	"student-management-system/models"
+)
+
+// parseFilter reads the list query parameters. Filters use the json field names of models.Student, with
+// firstName and lastName still accepted for older clients, and sort takes a comma separated list of fields
+// where a leading "-" orders that field descending, e.g. sort=last_name,-dob.
+func parseFilter(query url.Values) (*models.Filter, error) {
+	filter := models.Filter{
+		FirstName:        firstOf(query, "first_name", "firstName"),
+		LastName:         firstOf(query, "last_name", "lastName"),
+		Gender:           query.Get("gender"),
+		MotherTongue:     query.Get("mother_tongue"),
+		Nationality:      query.Get("nationality"),
+		FatherName:       query.Get("father_name"),
+		MotherName:       query.Get("mother_name"),
+		ContactNumber:    query.Get("contact_number"),
+		FatherOccupation: query.Get("father_occupation"),
+		MotherOccupation: query.Get("mother_occupation"),
+	}
+
+	dates := []struct {
+		param string
+		value *models.Date
+	}{
+		{"dob_from", &filter.DobFrom},
+		{"dob_to", &filter.DobTo},
+	}
+
+	for _, d := range dates {
+		if err := d.value.UnmarshalText([]byte(query.Get(d.param))); err != nil {
+			return nil, errors.InvalidParam{Field: d.param, Reason: "must be a date in YYYY-MM-DD format"}
+		}
+	}
+
+	ints := []struct {
+		param string
+		value *int
+	}{
+		{"min_family_income", &filter.MinFamilyIncome},
+		{"max_family_income", &filter.MaxFamilyIncome},
+		{"limit", &filter.Limit},
+		{"offset", &filter.Offset},
+	}
+
+	for _, i := range ints {
+		v := query.Get(i.param)
+		if v == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, errors.InvalidParam{Field: i.param, Reason: "must be a number"}
+		}
+
+		*i.value = n
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		for _, field := range strings.Split(sort, ",") {
+			s := models.Sort{Field: strings.TrimSpace(field)}
+
+			if strings.HasPrefix(s.Field, "-") {
+				s.Field, s.Desc = s.Field[1:], true
+			}
+
+			filter.Sort = append(filter.Sort, s)
+		}
+	}
+
+	return &filter, nil
+}
+
+func firstOf(query url.Values, keys ...string) string {
+	for _, k := range keys {
+		if v := query.Get(k); v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
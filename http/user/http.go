@@ -0,0 +1,67 @@
+package user
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+
+	"student-management-system/errors"
+	"student-management-system/http/apierror"
+	"student-management-system/models"
+	"student-management-system/service"
+)
+
+// maxCredentials caps the size of a login body, which only holds a username and a password.
+const maxCredentials = 4 << 10
+
+type handler struct {
+	user service.User
+}
+
+func New(u service.User) handler {
+	return handler{user: u}
+}
+
+// Login exchanges the username and password in the body for a bearer token.
+func (h handler) Login(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCredentials))
+	if err != nil {
+		apierror.Write(w, r, errors.InvalidParam{Field: "body", Reason: err.Error()})
+
+		return
+	}
+
+	var credentials models.Credentials
+
+	if err := json.Unmarshal(body, &credentials); err != nil {
+		apierror.Write(w, r, errors.InvalidParam{Field: "body", Reason: err.Error()})
+
+		return
+	}
+
+	token, err := h.user.Login(r.Context(), &credentials)
+	if err != nil {
+		apierror.Write(w, r, err)
+
+		return
+	}
+
+	body, err = json.Marshal(token)
+	if err != nil {
+		apierror.Write(w, r, errors.Internal{Err: err})
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(body)
+	if err != nil {
+		log.Println(err.Error())
+
+		return
+	}
+}
@@ -0,0 +1,58 @@
+package user
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"student-management-system/errors"
+	"student-management-system/models"
+	"student-management-system/service"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service.NewMockUser(ctrl)
+	mock := New(mockService)
+
+	testcases := []struct {
+		desc      string
+		body      string
+		expCall   bool
+		expRes    models.Token
+		expErr    error
+		expStatus int
+		expBody   string
+	}{
+		{desc: "success:token issued", body: `{"username":"admin","password":"s3cret-pass"}`, expCall: true,
+			expRes:    models.Token{AccessToken: "abc", TokenType: "Bearer", ExpiresIn: 3600},
+			expStatus: http.StatusOK, expBody: `{"access_token":"abc","token_type":"Bearer","expires_in":3600}`},
+		{desc: "failure:wrong password", body: `{"username":"admin","password":"wrong"}`, expCall: true,
+			expErr: errors.Unauthenticated{Reason: "invalid username or password"}, expStatus: http.StatusUnauthorized},
+		{desc: "failure:malformed body", body: `{"username":`, expStatus: http.StatusBadRequest},
+	}
+
+	for i, tc := range testcases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBufferString(tc.body))
+
+		if tc.expCall {
+			mockService.EXPECT().Login(req.Context(), gomock.Any()).Return(tc.expRes, tc.expErr)
+		}
+
+		mock.Login(w, req)
+
+		if w.Code != tc.expStatus {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expStatus, w.Code)
+		}
+
+		if tc.expBody != "" && w.Body.String() != tc.expBody {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expBody, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"student-management-system/config"
+	"student-management-system/models"
+	"student-management-system/requestctx"
+	student2 "student-management-system/service/student"
+	"student-management-system/store/audit"
+	"student-management-system/store/envelope"
+	"student-management-system/store/sqltx"
+	"student-management-system/store/student"
+	"student-management-system/tabular"
+)
+
+const importUsage = "usage: import [-dry-run] [-map header=field]... [-report file] <file.csv>"
+
+// pairs collects the values of a flag given more than once.
+type pairs []string
+
+func (p *pairs) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *pairs) Set(value string) error {
+	*p = append(*p, value)
+
+	return nil
+}
+
+// importStudents runs the "import" subcommand:
+//
+//	import [-dry-run] [-map header=field]... [-report file] <file.csv>
+//
+// It creates the students of a CSV file like POST /student/import, recorded in the history as made by "import",
+// prints how many rows were created and failed, and writes the failures to the report file when one is given.
+func importStudents(ctx context.Context, db *sql.DB, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "check the file without creating any student")
+	report := fs.String("report", "", "file to write the failed rows to, as CSV")
+
+	var maps pairs
+
+	fs.Var(&maps, "map", "header=field naming the student field of a column; may be repeated")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return errors.New(importUsage)
+	}
+
+	mapping, err := tabular.ParseMapping(maps)
+	if err != nil {
+		return err
+	}
+
+	keyring, err := envelope.Load(cfg.Encryption.KeyFile)
+	if err != nil {
+		return err
+	}
+
+	auditKeyring, err := envelope.LoadAudit(cfg.Encryption.KeyFile)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	svc := student2.New(student.New(db, keyring), audit.New(db, auditKeyring), sqltx.New(db), cfg.Validation)
+	ctx = requestctx.WithPrincipal(ctx, models.Principal{Subject: "import"})
+
+	results, err := tabular.Import(ctx, f, mapping, models.MaxImport,
+		func(ctx context.Context, items []models.BatchItem) ([]models.BatchResult, error) {
+			return svc.Import(ctx, items, *dryRun)
+		})
+	if err != nil {
+		return err
+	}
+
+	var failed int
+
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+
+	verb := "created"
+	if *dryRun {
+		verb = "would create"
+	}
+
+	if _, err := fmt.Printf("%s %d students, %d rows failed\n", verb, len(results)-failed, failed); err != nil {
+		return err
+	}
+
+	if *report == "" {
+		return nil
+	}
+
+	out, err := os.Create(*report)
+	if err != nil {
+		return err
+	}
+
+	if err := tabular.WriteReport(out, results); err != nil {
+		_ = out.Close()
+
+		return err
+	}
+
+	return out.Close()
+}
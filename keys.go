@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"student-management-system/config"
+	"student-management-system/store/envelope"
+	"student-management-system/store/student"
+)
+
+// keys runs the "keys" subcommand:
+//
+//	keys rotate
+//
+// rotate re-encrypts the sensitive columns of every student under the active key of the keyfile and recomputes
+// their blind indexes. Run it after adding a new active key, or after upgrading from plaintext columns, before
+// removing a retired key. Running it again only rewrites the students that are not current yet. The audit log is
+// sealed under the audit key of the keyfile instead, which is never rotated.
+func keys(ctx context.Context, db *sql.DB, cfg *config.Encryption, args []string) error {
+	if len(args) != 1 || args[0] != "rotate" {
+		return errors.New("usage: keys rotate")
+	}
+
+	keyring, err := envelope.Load(cfg.KeyFile)
+	if err != nil {
+		return err
+	}
+
+	n, err := student.New(db, keyring).Rotate(ctx)
+	if err != nil {
+		return fmt.Errorf("re-encrypted %d students before failing: %w", n, err)
+	}
+
+	_, err = fmt.Printf("re-encrypted %d students\n", n)
+
+	return err
+}
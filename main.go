@@ -1,38 +1,254 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"student-management-system/auth"
+	"student-management-system/config"
 	"student-management-system/driver"
+	"student-management-system/grpc/interceptor"
+	"student-management-system/http/health"
+	"student-management-system/http/middleware"
 	student3 "student-management-system/http/student"
+	user3 "student-management-system/http/user"
+	"student-management-system/migration"
+	"student-management-system/policy"
 	student2 "student-management-system/service/student"
+	user2 "student-management-system/service/user"
+	"student-management-system/store/audit"
+	"student-management-system/store/envelope"
+	"student-management-system/store/sqltx"
 	"student-management-system/store/student"
+	"student-management-system/store/user"
 
 	"github.com/gorilla/mux"
 )
 
 func main() {
-	db, err := driver.Connection()
+	cfg, args, err := config.Load(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Println("config:", cfg)
+
+	if err := run(&cfg, args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(cfg *config.Config, args []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := driver.Connection(ctx, &cfg.Database)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Println(err.Error())
+		}
+
+		fmt.Println("database connection closed")
+	}()
+
+	if len(args) > 0 && args[0] == "migrate" {
+		return migrate(ctx, db, args[1:])
+	}
+
+	if len(args) > 0 && args[0] == "user" {
+		return users(ctx, db, &cfg.Auth, args[1:])
+	}
+
+	if len(args) > 0 && args[0] == "keys" {
+		return keys(ctx, db, &cfg.Encryption, args[1:])
+	}
+
+	if len(args) > 0 && args[0] == "import" {
+		return importStudents(ctx, db, cfg, args[1:])
+	}
+
+	migrator, err := migration.New(db, os.Stdout, false)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Database.MigrateOnStart {
+		if err := migrator.Up(ctx); err != nil {
+			return err
+		}
+	}
+
+	keyring, err := envelope.Load(cfg.Encryption.KeyFile)
 	if err != nil {
-		log.Println(err.Error())
+		return err
 	}
 
-	defer db.Close()
+	auditKeyring, err := envelope.LoadAudit(cfg.Encryption.KeyFile)
+	if err != nil {
+		return err
+	}
 
 	//   injecting dependencies
-	storeStudent := student.New(db)
-	serviceStudent := student2.New(storeStudent)
-	handlerStudent := student3.New(serviceStudent)
+	storeStudent := student.New(db, keyring)
+	storeAudit := audit.New(db, auditKeyring)
+	storeUser := user.New(db)
+	serviceStudent := student2.New(storeStudent, storeAudit, sqltx.New(db), cfg.Validation)
+	handlerHealth := health.New(db, migrator)
+
+	perms, err := policy.New(cfg.Auth.Roles)
+	if err != nil {
+		return err
+	}
+
+	redaction, err := policy.NewRedaction(cfg.Auth.Redact)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := auth.New(&cfg.Auth)
+	if err != nil {
+		return err
+	}
+
+	// HTTP and gRPC share the service with the permission checks
+	students := student2.Authorize(serviceStudent, perms, storeUser, redaction)
+	handlerStudent := student3.New(students, cfg.HTTP.RequireIfMatch, int64(cfg.HTTP.MaxBodySize))
+	handlerUser := user3.New(user2.New(storeUser, tokens, perms))
+
+	purgeCtx, stopPurge := context.WithCancel(ctx)
+	purged := make(chan struct{})
+
+	// the purge is a system job, so it runs on the service without the permission checks
+	go func() {
+		purgeTrash(purgeCtx, serviceStudent, &cfg.Trash)
+		close(purged)
+	}()
+
+	// wait for a running purge before the database pool is closed
+	defer func() {
+		stopPurge()
+		<-purged
+	}()
+
+	if cfg.GRPC.Address != "" {
+		authn := interceptor.Authenticate(tokens)
+		if cfg.Auth.Disabled {
+			authn = interceptor.Anonymous(cfg.Auth.AnonymousRole)
+		}
+
+		grpcCtx, stopGRPC := context.WithCancel(ctx)
+
+		stopped, err := serveGRPC(grpcCtx, cfg, students, authn)
+		if err != nil {
+			stopGRPC()
+
+			return err
+		}
+
+		// drain the gRPC calls before the database pool is closed
+		defer func() {
+			stopGRPC()
+			<-stopped
+		}()
+	}
 
 	r := mux.NewRouter()
-	r.HandleFunc("/student", handlerStudent.Post).Methods(http.MethodPost)
-	r.HandleFunc("/student/{id}", handlerStudent.GetByID).Methods(http.MethodGet)
-	r.HandleFunc("/student", handlerStudent.Get).Methods(http.MethodGet)
-	r.HandleFunc("/student/{id}", handlerStudent.Delete).Methods(http.MethodDelete)
-	r.HandleFunc("/student/{id}", handlerStudent.Put).Methods(http.MethodPut)
-
-	fmt.Println("http server started and listening on port :9090")
-	log.Fatal(http.ListenAndServe(":9090", r))
+	r.Use(middleware.RequestID)
+	r.HandleFunc("/healthz", handlerHealth.Liveness).Methods(http.MethodGet)
+	r.HandleFunc("/readyz", handlerHealth.Readiness).Methods(http.MethodGet)
+
+	if tokens.CanIssue() {
+		r.HandleFunc("/login", handlerUser.Login).Methods(http.MethodPost)
+	}
+
+	// every other route needs a bearer token, or runs in the anonymous role when authentication is disabled
+	api := r.NewRoute().Subrouter()
+	if cfg.Auth.Disabled {
+		api.Use(middleware.Anonymous(cfg.Auth.AnonymousRole))
+	} else {
+		api.Use(middleware.Authenticate(tokens))
+	}
+
+	api.HandleFunc("/student", handlerStudent.Post).Methods(http.MethodPost)
+	api.HandleFunc("/student/import", handlerStudent.Import).Methods(http.MethodPost)
+	api.HandleFunc("/students:batch", handlerStudent.PostBatch).Methods(http.MethodPost)
+	api.HandleFunc("/students:batch", handlerStudent.PutBatch).Methods(http.MethodPut)
+	api.HandleFunc("/students:batch", handlerStudent.DeleteBatch).Methods(http.MethodDelete)
+	api.HandleFunc("/student/trash", handlerStudent.Trash).Methods(http.MethodGet)
+	api.HandleFunc("/student/export", handlerStudent.Export).Methods(http.MethodGet)
+	api.HandleFunc("/student/{id}", handlerStudent.GetByID).Methods(http.MethodGet)
+	api.HandleFunc("/student", handlerStudent.Get).Methods(http.MethodGet)
+	api.HandleFunc("/student/{id}", handlerStudent.Delete).Methods(http.MethodDelete)
+	api.HandleFunc("/student/{id}", handlerStudent.Put).Methods(http.MethodPut)
+	api.HandleFunc("/student/{id}", handlerStudent.Patch).Methods(http.MethodPatch)
+	api.HandleFunc("/student/{id}/restore", handlerStudent.Restore).Methods(http.MethodPost)
+	api.HandleFunc("/student/{id}/history", handlerStudent.History).Methods(http.MethodGet)
+	api.HandleFunc("/student/{id}/history/{version}/revert", handlerStudent.Revert).Methods(http.MethodPost)
+
+	srv := &http.Server{
+		Addr:              cfg.HTTP.Address,
+		Handler:           r,
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		ReadHeaderTimeout: cfg.HTTP.ReadTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
+		ConnContext:       middleware.WriteDeadlines(cfg.HTTP.WriteTimeout),
+	}
+
+	return serve(ctx, srv, &cfg.HTTP, handlerHealth.Drain)
+}
+
+// serve runs srv until ctx is cancelled by a signal, then stops accepting connections and gives in-flight
+// requests up to the drain timeout to finish. drain is called first so that readiness probes fail while the
+// server winds down.
+func serve(ctx context.Context, srv *http.Server, cfg *config.HTTP, drain func()) error {
+	errs := make(chan error, 1)
+
+	go func() {
+		fmt.Println("http server started and listening on " + srv.Addr)
+
+		if cfg.TLS.Enabled() {
+			errs <- srv.ListenAndServeTLS(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+
+			return
+		}
+
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	fmt.Println("shutting down, draining in-flight requests for up to " + cfg.DrainTimeout.String())
+
+	drain()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.DrainTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("http server did not drain in time: %w", err)
+	}
+
+	fmt.Println("http server stopped")
+
+	return nil
 }
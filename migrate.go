@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+
+	"student-management-system/migration"
+)
+
+// migrate runs the "migrate" subcommand:
+//
+//	migrate [-dry-run] up
+//	migrate [-dry-run] [-steps n] down
+//	migrate status
+func migrate(ctx context.Context, db *sql.DB, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "print the statements instead of running them")
+	steps := fs.Int("steps", 1, "number of migrations to roll back with down")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	m, err := migration.New(db, os.Stdout, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	switch fs.Arg(0) {
+	case "up":
+		return m.Up(ctx)
+	case "down":
+		return m.Down(ctx, *steps)
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(statuses)
+	default:
+		return errors.New("usage: migrate [-dry-run] [-steps n] up|down|status")
+	}
+}
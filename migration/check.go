@@ -0,0 +1,68 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxReported is the most invalid rows a failed check names.
+const maxReported = 10
+
+// checks run before the up script of their migration, by version, and fail it when existing data cannot be
+// migrated, so that it is corrected by hand first rather than lost or half converted.
+var checks = map[int]func(ctx context.Context, conn *sql.Conn) error{ //nolint:gochecknoglobals // checks by version
+	3: checkDob,
+}
+
+// checkDob verifies that every dob, still text, is a real mm-dd-yyyy date before 0003 converts it with str_to_date,
+// which would fail the conversion on a value such as 02-30-2005 in strict mode and store it as null otherwise.
+func checkDob(ctx context.Context, conn *sql.Conn) error {
+	rows, err := conn.QueryContext(ctx, "select id, dob from student where dob <> '' order by id;")
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	var (
+		invalid  []string
+		reported int
+	)
+
+	for rows.Next() {
+		var (
+			id  int
+			dob string
+		)
+
+		if err := rows.Scan(&id, &dob); err != nil {
+			return err
+		}
+
+		if _, err := time.Parse("01-02-2006", dob); err == nil {
+			continue
+		}
+
+		if reported++; reported <= maxReported {
+			invalid = append(invalid, fmt.Sprintf("%d (%q)", id, dob))
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if reported > maxReported {
+		invalid = append(invalid, fmt.Sprintf("and %d more", reported-maxReported))
+	}
+
+	if reported > 0 {
+		return fmt.Errorf("students %s have a dob that is not a mm-dd-yyyy date: correct or clear them, then migrate "+
+			"again", strings.Join(invalid, ", "))
+	}
+
+	return nil
+}
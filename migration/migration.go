@@ -0,0 +1,372 @@
+// Package migration versions the database schema. Scripts live in sql/ as <version>_<name>.up.sql and
+// <version>_<name>.down.sql, are compiled into the binary and are recorded in the schema_migrations table
+// together with a checksum, so that an applied script that is later edited is detected instead of drifting. A
+// migration that cannot convert every row has a check that refuses it beforehand, see checks.
+package migration
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+//go:embed sql/*.sql
+var scripts embed.FS //nolint:gochecknoglobals // embedded migration scripts
+
+const (
+	versionTable = "schema_migrations"
+	lockName     = "student-management-system." + versionTable
+	lockTimeout  = 30
+
+	errNoSuchTable = 1146
+)
+
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+type Status struct {
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+	Applied bool   `json:"applied"`
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	out        io.Writer
+	dryRun     bool
+}
+
+// New returns a Migrator for the embedded scripts. In dry-run mode the statements that would run are written
+// to out and the database is only read.
+func New(db *sql.DB, out io.Writer, dryRun bool) (Migrator, error) {
+	migrations, err := Load(scripts)
+	if err != nil {
+		return Migrator{}, err
+	}
+
+	return Migrator{db: db, migrations: migrations, out: out, dryRun: dryRun}, nil
+}
+
+// Load reads the up and down scripts of fsys/sql and returns them ordered by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+
+	for _, e := range entries {
+		version, name, direction, err := parseFileName(e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := fs.ReadFile(fsys, path.Join("sql", e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+
+		if m.Name != name {
+			return nil, fmt.Errorf("migration %d has scripts with different names %q and %q", version, m.Name, name)
+		}
+
+		if direction == "up" {
+			m.Up = string(body)
+			sum := sha256.Sum256(body)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d %s needs both an up and a down script", m.Version, m.Name)
+		}
+
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+func parseFileName(file string) (version int, name, direction string, err error) {
+	base := strings.TrimSuffix(file, ".sql")
+
+	dot := strings.LastIndex(base, ".")
+	underscore := strings.Index(base, "_")
+
+	if dot < 0 || underscore < 0 || underscore > dot {
+		return 0, "", "", fmt.Errorf("invalid migration file name %s", file)
+	}
+
+	direction = base[dot+1:]
+	if direction != "up" && direction != "down" {
+		return 0, "", "", fmt.Errorf("invalid migration direction in %s", file)
+	}
+
+	version, err = strconv.Atoi(base[:underscore])
+	if err != nil || version <= 0 {
+		return 0, "", "", fmt.Errorf("invalid migration version in %s", file)
+	}
+
+	return version, base[underscore+1 : dot], direction, nil
+}
+
+// Up applies every pending migration in version order.
+func (m Migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := range m.migrations {
+			if _, ok := applied[m.migrations[i].Version]; ok {
+				continue
+			}
+
+			if err := m.run(ctx, conn, &m.migrations[i], "up"); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Down rolls back the last steps applied migrations, newest first.
+func (m Migrator) Down(ctx context.Context, steps int) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+			if _, ok := applied[m.migrations[i].Version]; !ok {
+				continue
+			}
+
+			if err := m.run(ctx, conn, &m.migrations[i], "down"); err != nil {
+				return err
+			}
+
+			steps--
+		}
+
+		return nil
+	})
+}
+
+// Status reports every known migration and whether it has been applied.
+func (m Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+
+	for _, mg := range m.migrations {
+		_, ok := applied[mg.Version]
+		statuses = append(statuses, Status{Version: mg.Version, Name: mg.Name, Applied: ok})
+	}
+
+	return statuses, nil
+}
+
+// Pending returns the number of migrations not yet applied.
+func (m Migrator) Pending(ctx context.Context) (int, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	pending := 0
+
+	for _, s := range statuses {
+		if !s.Applied {
+			pending++
+		}
+	}
+
+	return pending, nil
+}
+
+// withLock serialises migrations across replicas starting at the same time with a MySQL named lock, which is
+// held by a single connection for the whole run.
+func (m Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	if m.dryRun {
+		return fn(conn)
+	}
+
+	var locked sql.NullInt64
+
+	err = conn.QueryRowContext(ctx, "select get_lock(?, ?);", lockName, lockTimeout).Scan(&locked)
+	if err != nil {
+		return err
+	}
+
+	if locked.Int64 != 1 {
+		return errors.New("timed out waiting for the migration lock")
+	}
+
+	defer func() {
+		_, _ = conn.ExecContext(context.Background(), "select release_lock(?);", lockName)
+	}()
+
+	_, err = conn.ExecContext(ctx, "create table if not exists "+versionTable+" (version bigint not null primary key, "+
+		"name varchar(255) not null, checksum char(64) not null, applied_at timestamp not null default current_timestamp);")
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// applied loads the recorded versions and verifies them against the embedded scripts.
+func (m Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int]string, error) {
+	rows, err := conn.QueryContext(ctx, "select version, checksum from "+versionTable+" order by version;")
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == errNoSuchTable {
+			return map[int]string{}, nil
+		}
+
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	applied := make(map[int]string)
+
+	for rows.Next() {
+		var (
+			version  int
+			checksum string
+		)
+
+		if err := rows.Scan(&version, &checksum); err != nil {
+			return nil, err
+		}
+
+		applied[version] = checksum
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return applied, m.verify(applied)
+}
+
+func (m Migrator) verify(applied map[int]string) error {
+	known := make(map[int]Migration, len(m.migrations))
+	for _, mg := range m.migrations {
+		known[mg.Version] = mg
+	}
+
+	for version, checksum := range applied {
+		mg, ok := known[version]
+		if !ok {
+			return fmt.Errorf("database has migration %d applied which this binary does not know about", version)
+		}
+
+		if mg.Checksum != checksum {
+			return fmt.Errorf("checksum mismatch for migration %d %s: the script changed after it was applied", version, mg.Name)
+		}
+	}
+
+	return nil
+}
+
+func (m Migrator) run(ctx context.Context, conn *sql.Conn, mg *Migration, direction string) error {
+	script, record, args := mg.Up, "insert into "+versionTable+" (version, name, checksum) values (?, ?, ?);",
+		[]interface{}{mg.Version, mg.Name, mg.Checksum}
+	if direction == "down" {
+		script, record, args = mg.Down, "delete from "+versionTable+" where version = ?;", []interface{}{mg.Version}
+	}
+
+	if check, ok := checks[mg.Version]; ok && direction == "up" {
+		if err := check(ctx, conn); err != nil {
+			return fmt.Errorf("migration %d %s (%s): %w", mg.Version, mg.Name, direction, err)
+		}
+	}
+
+	if m.dryRun {
+		_, err := fmt.Fprintf(m.out, "-- %04d %s (%s)\n%s\n", mg.Version, mg.Name, direction, strings.TrimSpace(script))
+
+		return err
+	}
+
+	for _, stmt := range statements(script) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("migration %d %s (%s): %w", mg.Version, mg.Name, direction, err)
+		}
+	}
+
+	if _, err := conn.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(m.out, "migrated %04d %s (%s)\n", mg.Version, mg.Name, direction)
+
+	return err
+}
+
+// statements splits a script on the semicolons that end a line, as the driver runs one statement per call.
+func statements(script string) []string {
+	var stmts []string
+
+	for _, s := range strings.SplitAfter(script, ";\n") {
+		if s = strings.TrimSpace(s); s != "" {
+			stmts = append(stmts, s)
+		}
+	}
+
+	return stmts
+}
@@ -0,0 +1,267 @@
+package migration
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestLoad(t *testing.T) {
+	testcases := []struct {
+		desc   string
+		files  fstest.MapFS
+		expRes []Migration
+		expErr bool
+	}{
+		{desc: "success:ordered by version", files: fstest.MapFS{
+			"sql/0002_b.up.sql":   {Data: []byte("b up;")},
+			"sql/0002_b.down.sql": {Data: []byte("b down;")},
+			"sql/0001_a.up.sql":   {Data: []byte("a up;")},
+			"sql/0001_a.down.sql": {Data: []byte("a down;")},
+		}, expRes: []Migration{
+			{Version: 1, Name: "a", Up: "a up;", Down: "a down;"},
+			{Version: 2, Name: "b", Up: "b up;", Down: "b down;"},
+		}},
+		{desc: "failure:missing down script", files: fstest.MapFS{
+			"sql/0001_a.up.sql": {Data: []byte("a up;")},
+		}, expErr: true},
+		{desc: "failure:invalid version", files: fstest.MapFS{
+			"sql/abc_a.up.sql": {Data: []byte("a up;")},
+		}, expErr: true},
+		{desc: "failure:invalid direction", files: fstest.MapFS{
+			"sql/0001_a.sideways.sql": {Data: []byte("a up;")},
+		}, expErr: true},
+	}
+
+	for i, tc := range testcases {
+		res, err := Load(tc.files)
+
+		if (err != nil) != tc.expErr {
+			t.Errorf("testcases %d failed expected error %v got %v", i+1, tc.expErr, err)
+		}
+
+		if len(res) != len(tc.expRes) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes, res)
+
+			continue
+		}
+
+		for j := range res {
+			if res[j].Version != tc.expRes[j].Version || res[j].Name != tc.expRes[j].Name || res[j].Up != tc.expRes[j].Up ||
+				res[j].Down != tc.expRes[j].Down || len(res[j].Checksum) != 64 {
+				t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes[j], res[j])
+			}
+		}
+	}
+}
+
+func TestLoad_Embedded(t *testing.T) {
+	migrations, err := Load(scripts)
+	if err != nil {
+		t.Fatalf("embedded migrations failed to load: %v", err)
+	}
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %s has version %d, expected %d", m.Name, m.Version, i+1)
+		}
+	}
+}
+
+func TestStatements(t *testing.T) {
+	script := "create table a (id int);\n\ncreate index i on a (id);\n"
+
+	exp := []string{"create table a (id int);", "create index i on a (id);"}
+
+	if res := statements(script); !reflect.DeepEqual(exp, res) {
+		t.Errorf("expected %v got %v", exp, res)
+	}
+}
+
+func testMigrations() []Migration {
+	return []Migration{
+		{Version: 1, Name: "create_a", Up: "create table a (id int);\n", Down: "drop table a;\n", Checksum: "c1"},
+		{Version: 2, Name: "create_b", Up: "create table b (id int);\n", Down: "drop table b;\n", Checksum: "c2"},
+	}
+}
+
+func TestUp(t *testing.T) {
+	testcases := []struct {
+		desc    string
+		applied *sqlmock.Rows
+		expRun  []string
+		expErr  error
+	}{
+		{desc: "success:applies pending migrations", applied: sqlmock.NewRows([]string{"version", "checksum"}).AddRow(1, "c1"),
+			expRun: []string{"create table b (id int);"}},
+		{desc: "success:nothing pending", applied: sqlmock.NewRows([]string{"version", "checksum"}).AddRow(1, "c1").AddRow(2, "c2")},
+		{desc: "failure:checksum mismatch", applied: sqlmock.NewRows([]string{"version", "checksum"}).AddRow(1, "edited"),
+			expErr: errors.New("checksum mismatch for migration 1 create_a: the script changed after it was applied")},
+		{desc: "failure:unknown migration", applied: sqlmock.NewRows([]string{"version", "checksum"}).AddRow(3, "c3"),
+			expErr: errors.New("database has migration 3 applied which this binary does not know about")},
+	}
+
+	for i, tc := range testcases {
+		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			log.Println(err.Error())
+		}
+
+		mock.ExpectQuery("select get_lock(?, ?);").WithArgs(lockName, lockTimeout).
+			WillReturnRows(sqlmock.NewRows([]string{"get_lock"}).AddRow(1))
+		mock.ExpectExec("create table if not exists " + versionTable + " (version bigint not null primary key, " +
+			"name varchar(255) not null, checksum char(64) not null, applied_at timestamp not null default current_timestamp);").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("select version, checksum from " + versionTable + " order by version;").WillReturnRows(tc.applied)
+
+		for _, stmt := range tc.expRun {
+			mock.ExpectExec(stmt).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec("insert into "+versionTable+" (version, name, checksum) values (?, ?, ?);").
+				WithArgs(2, "create_b", "c2").WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+
+		mock.ExpectExec("select release_lock(?);").WithArgs(lockName).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		m := Migrator{db: db, migrations: testMigrations(), out: &bytes.Buffer{}}
+
+		err = m.Up(context.TODO())
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("testcases %d failed %v", i+1, err)
+		}
+	}
+}
+
+func TestUp_CheckDob(t *testing.T) {
+	testcases := []struct {
+		desc   string
+		dobs   *sqlmock.Rows
+		expRun bool
+		expErr string
+	}{
+		{desc: "success:valid dates are converted", dobs: sqlmock.NewRows([]string{"id", "dob"}).AddRow(1, "03-15-2005").
+			AddRow(2, "02-29-2004"), expRun: true},
+		{desc: "failure:malformed legacy values", dobs: sqlmock.NewRows([]string{"id", "dob"}).AddRow(1, "03-15-2005").
+			AddRow(4, "02-30-2005").AddRow(9, "2-3-2005"), expErr: `migration 3 student_dob_date (up): students ` +
+			`4 ("02-30-2005"), 9 ("2-3-2005") have a dob that is not a mm-dd-yyyy date: correct or clear them, then migrate again`},
+	}
+
+	for i, tc := range testcases {
+		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			log.Println(err.Error())
+		}
+
+		mock.ExpectQuery("select get_lock(?, ?);").WithArgs(lockName, lockTimeout).
+			WillReturnRows(sqlmock.NewRows([]string{"get_lock"}).AddRow(1))
+		mock.ExpectExec("create table if not exists " + versionTable + " (version bigint not null primary key, " +
+			"name varchar(255) not null, checksum char(64) not null, applied_at timestamp not null default current_timestamp);").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("select version, checksum from " + versionTable + " order by version;").
+			WillReturnRows(sqlmock.NewRows([]string{"version", "checksum"}))
+		mock.ExpectQuery("select id, dob from student where dob <> '' order by id;").WillReturnRows(tc.dobs)
+
+		if tc.expRun {
+			mock.ExpectExec("update student set dob_date = str_to_date(dob, '%m-%d-%Y') where dob <> '';").
+				WillReturnResult(sqlmock.NewResult(0, 2))
+			mock.ExpectExec("insert into "+versionTable+" (version, name, checksum) values (?, ?, ?);").
+				WithArgs(3, "student_dob_date", "c3").WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+
+		mock.ExpectExec("select release_lock(?);").WithArgs(lockName).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		m := Migrator{db: db, migrations: []Migration{{Version: 3, Name: "student_dob_date",
+			Up: "update student set dob_date = str_to_date(dob, '%m-%d-%Y') where dob <> '';\n", Down: "", Checksum: "c3"}},
+			out: &bytes.Buffer{}}
+
+		err = m.Up(context.TODO())
+
+		if tc.expErr == "" && err != nil || tc.expErr != "" && (err == nil || err.Error() != tc.expErr) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("testcases %d failed %v", i+1, err)
+		}
+	}
+}
+
+func TestUp_DryRun(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		log.Println(err.Error())
+	}
+
+	mock.ExpectQuery("select version, checksum from " + versionTable + " order by version;").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "checksum"}).AddRow(1, "c1"))
+
+	out := &bytes.Buffer{}
+	m := Migrator{db: db, migrations: testMigrations(), out: out, dryRun: true}
+
+	if err := m.Up(context.TODO()); err != nil {
+		t.Errorf("expected no error got %v", err)
+	}
+
+	if exp := "-- 0002 create_b (up)\ncreate table b (id int);\n"; out.String() != exp {
+		t.Errorf("expected %q got %q", exp, out.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDown(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		log.Println(err.Error())
+	}
+
+	mock.ExpectQuery("select get_lock(?, ?);").WithArgs(lockName, lockTimeout).
+		WillReturnRows(sqlmock.NewRows([]string{"get_lock"}).AddRow(1))
+	mock.ExpectExec("create table if not exists " + versionTable + " (version bigint not null primary key, " +
+		"name varchar(255) not null, checksum char(64) not null, applied_at timestamp not null default current_timestamp);").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("select version, checksum from " + versionTable + " order by version;").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "checksum"}).AddRow(1, "c1").AddRow(2, "c2"))
+	mock.ExpectExec("drop table b;").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("delete from " + versionTable + " where version = ?;").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("select release_lock(?);").WithArgs(lockName).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	m := Migrator{db: db, migrations: testMigrations(), out: &bytes.Buffer{}}
+
+	if err := m.Down(context.TODO(), 1); err != nil {
+		t.Errorf("expected no error got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPending(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		log.Println(err.Error())
+	}
+
+	mock.ExpectQuery("select version, checksum from " + versionTable + " order by version;").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "checksum"}).AddRow(1, "c1"))
+
+	m := Migrator{db: db, migrations: testMigrations(), out: &bytes.Buffer{}}
+
+	pending, err := m.Pending(context.TODO())
+	if err != nil || pending != 1 {
+		t.Errorf("expected 1 pending migration got %d, %v", pending, err)
+	}
+}
@@ -0,0 +1,17 @@
+create table if not exists student (
+    id                int          not null auto_increment,
+    first_name        varchar(50)  not null,
+    last_name         varchar(50)  not null default '',
+    gender            char(1)      not null default '',
+    dob               varchar(10)  not null default '',
+    mother_tongue     varchar(50)  not null default '',
+    nationality       varchar(50)  not null,
+    father_name       varchar(50)  not null default '',
+    mother_name       varchar(50)  not null default '',
+    contact_number    bigint       not null,
+    father_occupation varchar(50)  not null default '',
+    mother_occupation varchar(50)  not null default '',
+    family_income     int          not null default 0,
+    primary key (id),
+    key idx_student_name (first_name, last_name)
+);
@@ -0,0 +1,2 @@
+-- Converting back could lose characters outside the old character set, so the table is left as utf8mb4.
+select 1;
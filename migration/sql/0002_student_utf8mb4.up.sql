@@ -0,0 +1 @@
+alter table student convert to character set utf8mb4 collate utf8mb4_unicode_ci;
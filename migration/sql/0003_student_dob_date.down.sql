@@ -0,0 +1,4 @@
+alter table student add column dob_text varchar(10) not null default '' after gender;
+update student set dob_text = date_format(dob, '%m-%d-%Y') where dob is not null;
+alter table student drop column dob;
+alter table student change column dob_text dob varchar(10) not null default '';
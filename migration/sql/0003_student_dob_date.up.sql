@@ -0,0 +1,4 @@
+alter table student add column dob_date date null after gender;
+update student set dob_date = str_to_date(dob, '%m-%d-%Y') where dob <> '';
+alter table student drop column dob;
+alter table student change column dob_date dob date null;
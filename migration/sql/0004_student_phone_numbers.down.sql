@@ -0,0 +1,5 @@
+-- Numbers from outside India cannot be stored as 10 digits and are lost.
+alter table student drop column emergency_contact_number;
+alter table student drop column home_contact_number;
+update student set contact_number = if(contact_number regexp '^\\+91[0-9]{10}$', substring(contact_number, 4), '0');
+alter table student modify contact_number bigint not null;
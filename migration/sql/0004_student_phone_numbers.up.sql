@@ -0,0 +1,6 @@
+-- Contact numbers become E.164 strings. Until now only 10 digit national numbers were accepted, all of them
+-- Indian, so the existing ones are given the +91 country code.
+alter table student modify contact_number varchar(16) not null default '';
+update student set contact_number = concat('+91', contact_number) where contact_number regexp '^[0-9]{10}$';
+alter table student add column home_contact_number varchar(16) not null default '' after contact_number;
+alter table student add column emergency_contact_number varchar(16) not null default '' after home_contact_number;
@@ -0,0 +1 @@
+alter table student drop column version;
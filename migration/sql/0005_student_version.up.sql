@@ -0,0 +1 @@
+alter table student add column version int not null default 1;
@@ -0,0 +1,4 @@
+-- Students in the trash would reappear as active ones, so they are purged first.
+delete from student where deleted_at is not null;
+alter table student drop key idx_student_deleted_at;
+alter table student drop column deleted_at;
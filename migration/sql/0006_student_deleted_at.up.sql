@@ -0,0 +1,2 @@
+alter table student add column deleted_at datetime(6) null;
+alter table student add key idx_student_deleted_at (deleted_at);
@@ -0,0 +1,3 @@
+drop trigger student_audit_no_delete;
+drop trigger student_audit_no_update;
+drop table student_audit;
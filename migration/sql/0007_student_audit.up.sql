@@ -0,0 +1,15 @@
+-- The log outlives the students it describes, which the trash purge deletes, so it has no foreign key.
+create table if not exists student_audit (
+    id         bigint       not null auto_increment,
+    student_id int          not null,
+    version    int          not null,
+    action     varchar(16)  not null,
+    actor      varchar(255) not null,
+    request_id varchar(128) not null default '',
+    at         datetime(6)  not null,
+    changes    json         not null,
+    primary key (id),
+    key idx_student_audit_student (student_id, version)
+);
+create trigger student_audit_no_update before update on student_audit for each row signal sqlstate '45000' set message_text = 'student_audit is append-only';
+create trigger student_audit_no_delete before delete on student_audit for each row signal sqlstate '45000' set message_text = 'student_audit is append-only';
@@ -0,0 +1,8 @@
+create table if not exists users (
+    id            int          not null auto_increment,
+    username      varchar(64)  not null,
+    password_hash varchar(255) not null,
+    created_at    datetime(6)  not null,
+    primary key (id),
+    unique key idx_users_username (username)
+);
@@ -0,0 +1,2 @@
+drop table guardian;
+alter table users drop column role;
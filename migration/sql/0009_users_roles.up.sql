@@ -0,0 +1,9 @@
+alter table users add column role varchar(32) not null default 'readonly' after password_hash;
+create table if not exists guardian (
+    username   varchar(64) not null,
+    student_id int         not null,
+    primary key (username, student_id),
+    key idx_guardian_student (student_id),
+    constraint fk_guardian_user foreign key (username) references users (username) on delete cascade,
+    constraint fk_guardian_student foreign key (student_id) references student (id) on delete cascade
+);
@@ -0,0 +1,14 @@
+-- The application cannot decrypt in SQL: sealed values are lost, and only plaintext ones survive.
+alter table student drop column contact_number_bidx, drop column mother_name_bidx, drop column father_name_bidx;
+update student set father_name = '' where father_name like 'enc:v1:%';
+update student set mother_name = '' where mother_name like 'enc:v1:%';
+update student set contact_number = '' where contact_number like 'enc:v1:%';
+update student set home_contact_number = '' where home_contact_number like 'enc:v1:%';
+update student set emergency_contact_number = '' where emergency_contact_number like 'enc:v1:%';
+update student set family_income = '0' where family_income not regexp '^[0-9]+$';
+alter table student modify father_name varchar(50) not null default '',
+    modify mother_name varchar(50) not null default '',
+    modify contact_number varchar(16) not null default '',
+    modify home_contact_number varchar(16) not null default '',
+    modify emergency_contact_number varchar(16) not null default '',
+    modify family_income int not null default 0;
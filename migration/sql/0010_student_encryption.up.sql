@@ -0,0 +1,15 @@
+-- The sensitive columns are sealed by the application, so they become wide enough for the sealed values and
+-- family_income becomes text. Existing rows stay readable as plaintext until `keys rotate` encrypts them and
+-- fills in the blind indexes, without which the exact-match filters on these columns do not find them.
+alter table student modify father_name varchar(512) not null default '',
+    modify mother_name varchar(512) not null default '',
+    modify contact_number varchar(512) not null default '',
+    modify home_contact_number varchar(512) not null default '',
+    modify emergency_contact_number varchar(512) not null default '',
+    modify family_income varchar(512) not null default '';
+alter table student add column father_name_bidx char(32) null after family_income,
+    add column mother_name_bidx char(32) null after father_name_bidx,
+    add column contact_number_bidx char(32) null after mother_name_bidx,
+    add key idx_student_father_name_bidx (father_name_bidx),
+    add key idx_student_mother_name_bidx (mother_name_bidx),
+    add key idx_student_contact_number_bidx (contact_number_bidx);
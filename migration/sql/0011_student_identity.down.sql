@@ -0,0 +1 @@
+alter table student drop key idx_student_identity, drop column active_identity_key, drop column identity_key;
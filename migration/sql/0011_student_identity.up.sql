@@ -0,0 +1,6 @@
+-- identity_key is the keyed hash of the normalised fields of a student, written by the application. Only the
+-- students outside the trash must be unique, so the unique index is on a generated column that is null in the
+-- trash. Existing rows get their identity key from `keys rotate`; until then they are not checked.
+alter table student add column identity_key char(32) null after contact_number_bidx,
+    add column active_identity_key char(32) as (if(deleted_at is null, identity_key, null)) virtual,
+    add unique key idx_student_identity (active_identity_key);
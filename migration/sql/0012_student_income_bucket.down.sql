@@ -0,0 +1 @@
+alter table student drop key idx_student_family_income_bucket, drop column family_income_bucket;
@@ -0,0 +1,4 @@
+-- family_income is encrypted, so its band is kept in plaintext for the income range filters to match on. Existing
+-- rows get their band from `keys rotate`; until then the range filters miss them.
+alter table student add column family_income_bucket int null after contact_number_bidx,
+    add key idx_student_family_income_bucket (family_income_bucket);
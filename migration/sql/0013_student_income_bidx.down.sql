@@ -0,0 +1,3 @@
+alter table student drop key idx_student_family_income_bidx, drop column family_income_bidx,
+    add column family_income_bucket int null after contact_number_bidx,
+    add key idx_student_family_income_bucket (family_income_bucket);
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditAction is the kind of change an audit entry records.
+type AuditAction string
+
+const (
+	ActionCreate  AuditAction = "create"
+	ActionUpdate  AuditAction = "update"
+	ActionDelete  AuditAction = "delete"
+	ActionRestore AuditAction = "restore"
+	ActionRevert  AuditAction = "revert"
+)
+
+const AuditTableName DbTable = "student_audit"
+
+// AuditEntry records one change to a student, and the version of the student it produced. Entries are only
+// ever appended, never updated or deleted.
+type AuditEntry struct {
+	ID        int64       `json:"id" xml:"id"`
+	StudentID int         `json:"student_id" xml:"student_id"`
+	Version   int         `json:"version" xml:"version"`
+	Action    AuditAction `json:"action" xml:"action"`
+	Actor     string      `json:"actor" xml:"actor"`
+	RequestID string      `json:"request_id,omitempty" xml:"request_id,omitempty"`
+	At        time.Time   `json:"at" xml:"at"`
+	Changes   []Change    `json:"changes" xml:"changes>change"`
+}
+
+// Change is the value of one field, by json name, before and after a change. A field that was not set is null.
+type Change struct {
+	Field  string          `json:"field" xml:"field"`
+	Before json.RawMessage `json:"before" xml:"before"`
+	After  json.RawMessage `json:"after" xml:"after"`
+}
+
+// History is the audit log of a student, oldest change first.
+type History struct {
+	Data []AuditEntry `json:"data" xml:"data>entry"`
+}
@@ -0,0 +1,74 @@
+package models
+
+import "student-management-system/errors"
+
+// BatchMode selects what a batch does when some of its items fail.
+type BatchMode string
+
+const (
+	// Atomic applies every item of the batch or, when any of them fails, none.
+	Atomic BatchMode = "atomic"
+	// BestEffort applies the items that succeed and reports the others.
+	BestEffort BatchMode = "best_effort"
+)
+
+const (
+	// MaxBatch is the most items a batch may have.
+	MaxBatch = 500
+	// MaxImport is the most students an import may have.
+	MaxImport = 10000
+)
+
+// BatchItem is one student of a batch: the student to create, the ID, version and new fields of the student to
+// replace, or the ID and version of the student to delete. A version of 0 matches whatever version is stored.
+type BatchItem struct {
+	ID      int      `json:"id,omitempty" xml:"id,omitempty"`
+	Version int      `json:"version,omitempty" xml:"version,omitempty"`
+	Student *Student `json:"student,omitempty" xml:"student,omitempty"`
+}
+
+// Batch is the body of a batch request. A batch without a mode is Atomic.
+type Batch struct {
+	Mode  BatchMode   `json:"mode" xml:"mode"`
+	Items []BatchItem `json:"items" xml:"items>item"`
+}
+
+// BatchReport is the answer to a batch, with the outcome of every item.
+type BatchReport struct {
+	Data []BatchStatus `json:"data" xml:"data>result"`
+}
+
+// BatchStatus is the outcome of the item at Index of a batch, with the HTTP status it would have been answered
+// with on its own.
+type BatchStatus struct {
+	Index   int              `json:"index" xml:"index"`
+	Status  int              `json:"status" xml:"status"`
+	ID      int              `json:"id,omitempty" xml:"id,omitempty"`
+	Version int              `json:"version,omitempty" xml:"version,omitempty"`
+	Error   *errors.Response `json:"error,omitempty" xml:"error,omitempty"`
+}
+
+// ImportReport is the answer to an import, which counts the rows that were or, in a dry run, would be created.
+type ImportReport struct {
+	DryRun  bool           `json:"dry_run" xml:"dry_run"`
+	Created int            `json:"created" xml:"created"`
+	Failed  int            `json:"failed" xml:"failed"`
+	Data    []ImportStatus `json:"data" xml:"data>result"`
+}
+
+// ImportStatus is the outcome of the row on Line of an imported file: the ID of the student it created, or the
+// error it failed with.
+type ImportStatus struct {
+	Line  int              `json:"line" xml:"line"`
+	ID    int              `json:"id,omitempty" xml:"id,omitempty"`
+	Error *errors.Response `json:"error,omitempty" xml:"error,omitempty"`
+}
+
+// BatchResult is the outcome of the item at Index of a batch: the ID and new version of the student it wrote,
+// or the error it failed with.
+type BatchResult struct {
+	Index   int
+	ID      int
+	Version int
+	Err     error
+}
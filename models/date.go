@@ -0,0 +1,128 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DateLayout is the ISO-8601 calendar date format used by the API and the database.
+const DateLayout = "2006-01-02"
+
+// Date is a calendar date without a time of day or time zone. The zero Date means "not set"; it is written as
+// JSON null and SQL NULL.
+type Date struct {
+	t time.Time
+}
+
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{t: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// ParseDate reads an ISO-8601 date, either YYYY-MM-DD or a full RFC 3339 timestamp of which only the date is
+// kept. Dates that do not exist in the calendar, such as 2001-02-29, are rejected.
+func ParseDate(s string) (Date, error) {
+	if t, err := time.Parse(DateLayout, s); err == nil {
+		return Date{t: t}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return NewDate(t.Date()), nil
+	}
+
+	return Date{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", s)
+}
+
+func (d Date) IsZero() bool {
+	return d.t.IsZero()
+}
+
+// Time returns midnight UTC of the date.
+func (d Date) Time() time.Time {
+	return d.t
+}
+
+func (d Date) Before(other Date) bool {
+	return d.t.Before(other.t)
+}
+
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+
+	return d.t.Format(DateLayout)
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(d.String())
+}
+
+func (d *Date) UnmarshalJSON(b []byte) error {
+	var s *string
+
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("invalid date %s, expected YYYY-MM-DD", b)
+	}
+
+	return d.UnmarshalText([]byte(stringOrEmpty(s)))
+}
+
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Date) UnmarshalText(b []byte) error {
+	if len(b) == 0 {
+		*d = Date{}
+
+		return nil
+	}
+
+	parsed, err := ParseDate(string(b))
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+
+	return nil
+}
+
+func (d Date) Value() (driver.Value, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+
+	return d.t, nil
+}
+
+func (d *Date) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Date{}
+	case time.Time:
+		*d = NewDate(v.Date())
+	case []byte:
+		return d.UnmarshalText(v)
+	case string:
+		return d.UnmarshalText([]byte(v))
+	default:
+		return fmt.Errorf("cannot scan %T into a Date", src)
+	}
+
+	return nil
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
@@ -0,0 +1,53 @@
+package models
+
+const (
+	// IncomeBand is the width of the bands of family income a range filter is matched on: family income is stored
+	// encrypted, so a range can only select whole bands.
+	IncomeBand = 100000
+	// MaxIncomeBands is the most bands a family income range may cover, each of which the query looks up.
+	MaxIncomeBands = 100
+)
+
+// Filter narrows, orders and pages the students returned by a list query. Zero values mean "no constraint",
+// so an empty Filter selects every student.
+type Filter struct {
+	FirstName        string
+	LastName         string
+	Gender           string
+	MotherTongue     string
+	Nationality      string
+	FatherName       string
+	MotherName       string
+	ContactNumber    string
+	FatherOccupation string
+	MotherOccupation string
+	MinFamilyIncome  int
+	MaxFamilyIncome  int
+	DobFrom          Date
+	DobTo            Date
+	Sort             []Sort
+	Limit            int
+	Offset           int
+	// Deleted selects the students in the trash instead of the active ones.
+	Deleted bool
+	// IDs, when not nil, restricts the list to the students with these IDs, and an empty IDs selects none.
+	IDs []int
+}
+
+// Sort orders a list query on a single field, given by its json name.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+type Page struct {
+	Total      int  `json:"total" xml:"total"`
+	Limit      int  `json:"limit" xml:"limit"`
+	Offset     int  `json:"offset" xml:"offset"`
+	NextOffset *int `json:"next_offset,omitempty" xml:"next_offset,omitempty"`
+}
+
+type StudentList struct {
+	Data []Student `json:"data" xml:"data>student"`
+	Meta Page      `json:"meta" xml:"meta"`
+}
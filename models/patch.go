@@ -0,0 +1,11 @@
+package models
+
+// PatchType is the media type of a PATCH request body and selects how it is applied.
+type PatchType string
+
+const (
+	// MergePatch is a JSON Merge Patch (RFC 7396): a partial student whose null fields are cleared.
+	MergePatch PatchType = "application/merge-patch+json"
+	// JSONPatch is a JSON Patch (RFC 6902): a list of add, remove, replace, move, copy and test operations.
+	JSONPatch PatchType = "application/json-patch+json"
+)
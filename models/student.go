@@ -1,19 +1,110 @@
 package models
 
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/cases"
+)
+
 type Student struct {
-	ID               int    `json:"id,omitempty"`
-	FirstName        string `json:"first_name,omitempty"`
-	LastName         string `json:"last_name,omitempty"`
-	Gender           string `json:"gender,omitempty"`
-	Dob              string `json:"dob,omitempty"`
-	MotherTongue     string `json:"mother_tongue,omitempty"`
-	Nationality      string `json:"nationality,omitempty"`
-	FatherName       string `json:"father_name,omitempty"`
-	MotherName       string `json:"mother_name,omitempty"`
-	ContactNumber    int    `json:"contact_number,omitempty"`
-	FatherOccupation string `json:"father_occupation,omitempty"`
-	MotherOccupation string `json:"mother_occupation,omitempty"`
-	FamilyIncome     int    `json:"family_income,omitempty"`
+	ID                     int    `json:"id,omitempty" xml:"id,omitempty"`
+	FirstName              string `json:"first_name,omitempty" xml:"first_name,omitempty"`
+	LastName               string `json:"last_name,omitempty" xml:"last_name,omitempty"`
+	Gender                 string `json:"gender,omitempty" xml:"gender,omitempty"`
+	Dob                    Date   `json:"dob" xml:"dob"`
+	MotherTongue           string `json:"mother_tongue,omitempty" xml:"mother_tongue,omitempty"`
+	Nationality            string `json:"nationality,omitempty" xml:"nationality,omitempty"`
+	FatherName             string `json:"father_name,omitempty" xml:"father_name,omitempty"`
+	MotherName             string `json:"mother_name,omitempty" xml:"mother_name,omitempty"`
+	ContactNumber          string `json:"contact_number,omitempty" xml:"contact_number,omitempty"`
+	HomeContactNumber      string `json:"home_contact_number,omitempty" xml:"home_contact_number,omitempty"`
+	EmergencyContactNumber string `json:"emergency_contact_number,omitempty" xml:"emergency_contact_number,omitempty"`
+	FatherOccupation       string `json:"father_occupation,omitempty" xml:"father_occupation,omitempty"`
+	MotherOccupation       string `json:"mother_occupation,omitempty" xml:"mother_occupation,omitempty"`
+	FamilyIncome           int    `json:"family_income,omitempty" xml:"family_income,omitempty"`
+	// Version counts the updates of the student and is sent as its ETag rather than in the body.
+	Version int `json:"-" xml:"-"`
+	// DeletedAt is set while the student is in the trash.
+	DeletedAt *time.Time `json:"deleted_at,omitempty" xml:"deleted_at,omitempty"`
+}
+
+// UnmarshalJSON reads a student, taking the contact number as a JSON string or, as clients sent it before it
+// became an E.164 string, a JSON integer.
+func (s *Student) UnmarshalJSON(b []byte) error {
+	type student Student
+
+	v := struct {
+		*student
+		ContactNumber json.RawMessage `json:"contact_number,omitempty"`
+	}{student: (*student)(s)}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	if v.ContactNumber == nil {
+		return nil
+	}
+
+	number, err := ContactNumber(v.ContactNumber)
+	if err != nil {
+		return err
+	}
+
+	if number != nil {
+		s.ContactNumber = *number
+	}
+
+	return nil
+}
+
+// ContactNumber reads a contact number sent as a JSON string or integer, and returns nil for null.
+func ContactNumber(b json.RawMessage) (*string, error) {
+	var v interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return &v, nil
+	case json.Number:
+		if n, err := v.Int64(); err == nil && n >= 0 {
+			number := strconv.FormatInt(n, 10)
+
+			return &number, nil
+		}
+	}
+
+	return nil, errors.New("contact_number must be a string or a whole number")
+}
+
+// Identity is the normalised form of every field of the student, the same for students that differ only in
+// letter case or spacing. No two active students may share an identity.
+func (s *Student) Identity() string {
+	fields := []string{s.FirstName, s.LastName, s.Gender, s.Dob.String(), s.MotherTongue, s.Nationality, s.FatherName,
+		s.MotherName, s.ContactNumber, s.HomeContactNumber, s.EmergencyContactNumber, s.FatherOccupation,
+		s.MotherOccupation, strconv.Itoa(s.FamilyIncome)}
+
+	fold := cases.Fold()
+
+	for i, f := range fields {
+		fields[i] = strings.Join(strings.Fields(fold.String(f)), " ")
+	}
+
+	// the unit separator cannot occur in a valid field
+	return strings.Join(fields, "\x1f")
 }
 
 type Gender string
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+const UserTableName DbTable = "users"
+
+// User is an account that can log in to the API. The password is only ever stored as a hash.
+type User struct {
+	ID           int       `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"-"`
+	Role         string    `json:"role"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// GuardianTableName links parents, by username, to the students they may see.
+const GuardianTableName DbTable = "guardian"
+
+// Principal is who an authenticated request acts on behalf of, taken from the claims of its bearer token. Role
+// selects the permissions of the principal.
+type Principal struct {
+	Subject string
+	Role    string
+}
+
+// Credentials is the body of a login request.
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Token is an issued bearer token, in the shape of an OAuth 2.0 token response.
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
@@ -0,0 +1,183 @@
+// Package pb holds the protocol buffer messages of the student API, generated from student.proto, and their
+// conversions from and to the models.
+package pb
+
+import (
+	"student-management-system/errors"
+	"student-management-system/models"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// NewStudent converts a student to its message.
+func NewStudent(s *models.Student) *Student {
+	res := &Student{
+		Id:                     int64(s.ID),
+		FirstName:              s.FirstName,
+		LastName:               s.LastName,
+		Gender:                 s.Gender,
+		Dob:                    s.Dob.String(),
+		MotherTongue:           s.MotherTongue,
+		Nationality:            s.Nationality,
+		FatherName:             s.FatherName,
+		MotherName:             s.MotherName,
+		ContactNumber:          s.ContactNumber,
+		HomeContactNumber:      s.HomeContactNumber,
+		EmergencyContactNumber: s.EmergencyContactNumber,
+		FatherOccupation:       s.FatherOccupation,
+		MotherOccupation:       s.MotherOccupation,
+		FamilyIncome:           int64(s.FamilyIncome),
+		Version:                int64(s.Version),
+	}
+
+	if s.DeletedAt != nil {
+		res.DeletedAt = timestamppb.New(*s.DeletedAt)
+	}
+
+	return res
+}
+
+// ToStudent converts a message to a student, failing with an errors.InvalidParam when its dob is not a date.
+func ToStudent(s *Student) (models.Student, error) {
+	var dob models.Date
+
+	if err := dob.UnmarshalText([]byte(s.GetDob())); err != nil {
+		return models.Student{}, errors.InvalidParam{Field: "dob", Reason: "must be a date in YYYY-MM-DD format"}
+	}
+
+	res := models.Student{
+		ID:                     int(s.GetId()),
+		FirstName:              s.GetFirstName(),
+		LastName:               s.GetLastName(),
+		Gender:                 s.GetGender(),
+		Dob:                    dob,
+		MotherTongue:           s.GetMotherTongue(),
+		Nationality:            s.GetNationality(),
+		FatherName:             s.GetFatherName(),
+		MotherName:             s.GetMotherName(),
+		ContactNumber:          s.GetContactNumber(),
+		HomeContactNumber:      s.GetHomeContactNumber(),
+		EmergencyContactNumber: s.GetEmergencyContactNumber(),
+		FatherOccupation:       s.GetFatherOccupation(),
+		MotherOccupation:       s.GetMotherOccupation(),
+		FamilyIncome:           int(s.GetFamilyIncome()),
+		Version:                int(s.GetVersion()),
+	}
+
+	if s.GetDeletedAt() != nil {
+		t := s.GetDeletedAt().AsTime()
+		res.DeletedAt = &t
+	}
+
+	return res, nil
+}
+
+// NewStudentList converts a page of students to its message.
+func NewStudentList(l *models.StudentList) *StudentList {
+	res := &StudentList{
+		Data: make([]*Student, len(l.Data)),
+		Meta: &Page{Total: int64(l.Meta.Total), Limit: int64(l.Meta.Limit), Offset: int64(l.Meta.Offset)},
+	}
+
+	for i := range l.Data {
+		res.Data[i] = NewStudent(&l.Data[i])
+	}
+
+	if l.Meta.NextOffset != nil {
+		next := int64(*l.Meta.NextOffset)
+		res.Meta.NextOffset = &next
+	}
+
+	return res
+}
+
+// NewHistory converts the history of a student to its message.
+func NewHistory(h *models.History) *History {
+	res := &History{Data: make([]*AuditEntry, len(h.Data))}
+
+	for i, e := range h.Data {
+		entry := &AuditEntry{
+			Id:        e.ID,
+			StudentId: int64(e.StudentID),
+			Version:   int64(e.Version),
+			Action:    string(e.Action),
+			Actor:     e.Actor,
+			RequestId: e.RequestID,
+			At:        timestamppb.New(e.At),
+			Changes:   make([]*Change, len(e.Changes)),
+		}
+
+		for j, c := range e.Changes {
+			entry.Changes[j] = &Change{Field: c.Field, Before: string(c.Before), After: string(c.After)}
+		}
+
+		res.Data[i] = entry
+	}
+
+	return res
+}
+
+// NewError converts an error response to its message.
+func NewError(r *errors.Response) *Error {
+	res := &Error{Code: r.Code, Message: r.Message, Field: r.Field, ExistingId: r.ExistingID, RequestId: r.RequestID,
+		Errors: make([]*FieldError, len(r.Errors))}
+
+	for i, f := range r.Errors {
+		res.Errors[i] = &FieldError{Field: f.Field, Rule: f.Rule, Message: f.Message}
+	}
+
+	return res
+}
+
+// ToBatch converts a message to the body of a batch request, failing like ToStudent.
+func ToBatch(b *Batch) (models.Batch, error) {
+	res := models.Batch{Mode: models.BatchMode(b.GetMode()), Items: make([]models.BatchItem, len(b.GetItems()))}
+
+	for i, item := range b.GetItems() {
+		res.Items[i] = models.BatchItem{ID: int(item.GetId()), Version: int(item.GetVersion())}
+
+		if item.GetStudent() == nil {
+			continue
+		}
+
+		student, err := ToStudent(item.GetStudent())
+		if err != nil {
+			return models.Batch{}, err
+		}
+
+		res.Items[i].Student = &student
+	}
+
+	return res, nil
+}
+
+// NewBatchReport converts the answer to a batch to its message.
+func NewBatchReport(r *models.BatchReport) *BatchReport {
+	res := &BatchReport{Data: make([]*BatchStatus, len(r.Data))}
+
+	for i, s := range r.Data {
+		res.Data[i] = &BatchStatus{Index: int64(s.Index), Status: int32(s.Status), Id: int64(s.ID), Version: int64(s.Version)}
+
+		if s.Error != nil {
+			res.Data[i].Error = NewError(s.Error)
+		}
+	}
+
+	return res
+}
+
+// NewImportReport converts the answer to an import to its message.
+func NewImportReport(r *models.ImportReport) *ImportReport {
+	res := &ImportReport{DryRun: r.DryRun, Created: int64(r.Created), Failed: int64(r.Failed),
+		Data: make([]*ImportStatus, len(r.Data))}
+
+	for i, s := range r.Data {
+		res.Data[i] = &ImportStatus{Line: int64(s.Line), Id: int64(s.ID)}
+
+		if s.Error != nil {
+			res.Data[i].Error = NewError(s.Error)
+		}
+	}
+
+	return res
+}
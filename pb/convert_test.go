@@ -0,0 +1,88 @@
+package pb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"student-management-system/errors"
+	"student-management-system/models"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestStudent(t *testing.T) {
+	deleted := time.Date(2024, time.March, 5, 10, 0, 0, 0, time.UTC)
+
+	testcases := []struct {
+		desc    string
+		student models.Student
+	}{
+		{desc: "every field", student: models.Student{ID: 7, FirstName: "Asha", LastName: "Rao", Gender: "F",
+			Dob: models.NewDate(2010, time.April, 1), MotherTongue: "Kannada", Nationality: "Indian", FatherName: "Ram",
+			MotherName: "Sita", ContactNumber: "+919876543210", HomeContactNumber: "+918012345678",
+			EmergencyContactNumber: "+919812345678", FatherOccupation: "Farmer", MotherOccupation: "Teacher",
+			FamilyIncome: 50000, Version: 3, DeletedAt: &deleted}},
+		{desc: "empty student", student: models.Student{}},
+	}
+
+	for i, tc := range testcases {
+		res, err := ToStudent(NewStudent(&tc.student))
+
+		if err != nil || !reflect.DeepEqual(tc.student, res) {
+			t.Errorf("testcases %d failed expected %v got %v, %v", i+1, tc.student, res, err)
+		}
+	}
+}
+
+func TestToBatch(t *testing.T) {
+	testcases := []struct {
+		desc   string
+		batch  *Batch
+		expRes models.Batch
+		expErr error
+	}{
+		{desc: "items with and without a student", batch: &Batch{Mode: "best_effort", Items: []*BatchItem{
+			{Student: &Student{FirstName: "Asha"}}, {Id: 4, Version: 2}}},
+			expRes: models.Batch{Mode: models.BestEffort, Items: []models.BatchItem{
+				{Student: &models.Student{FirstName: "Asha"}}, {ID: 4, Version: 2}}}},
+		{desc: "student with an invalid dob", batch: &Batch{Items: []*BatchItem{{Student: &Student{Dob: "01/04/2010"}}}},
+			expErr: errors.InvalidParam{Field: "dob", Reason: "must be a date in YYYY-MM-DD format"}},
+	}
+
+	for i, tc := range testcases {
+		res, err := ToBatch(tc.batch)
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+
+		if tc.expErr == nil && !reflect.DeepEqual(tc.expRes, res) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes, res)
+		}
+	}
+}
+
+func TestNewBatchReport(t *testing.T) {
+	res := NewBatchReport(&models.BatchReport{Data: []models.BatchStatus{{Status: 201, ID: 5, Version: 1},
+		{Index: 1, Status: 409, Error: &errors.Response{Code: "ALREADY_EXISTS", ExistingID: "7"}}}})
+
+	exp := &BatchReport{Data: []*BatchStatus{{Status: 201, Id: 5, Version: 1},
+		{Index: 1, Status: 409, Error: &Error{Code: "ALREADY_EXISTS", ExistingId: "7", Errors: []*FieldError{}}}}}
+
+	if !proto.Equal(exp, res) {
+		t.Errorf("expected %v got %v", exp, res)
+	}
+}
+
+func TestNewImportReport(t *testing.T) {
+	res := NewImportReport(&models.ImportReport{DryRun: true, Created: 1, Failed: 1, Data: []models.ImportStatus{{Line: 2, ID: 5},
+		{Line: 3, Error: &errors.Response{Code: "ALREADY_EXISTS", ExistingID: "7"}}}})
+
+	exp := &ImportReport{DryRun: true, Created: 1, Failed: 1, Data: []*ImportStatus{{Line: 2, Id: 5},
+		{Line: 3, Error: &Error{Code: "ALREADY_EXISTS", ExistingId: "7", Errors: []*FieldError{}}}}}
+
+	if !proto.Equal(exp, res) {
+		t.Errorf("expected %v got %v", exp, res)
+	}
+}
@@ -0,0 +1,86 @@
+// Package policy decides what each role may do with students, from a configurable role to permission matrix.
+package policy
+
+import (
+	"context"
+	"fmt"
+
+	"student-management-system/errors"
+	"student-management-system/requestctx"
+)
+
+// Permission names an operation on students that a role can be granted.
+type Permission string
+
+const (
+	// ReadStudents allows reading and listing every student.
+	ReadStudents Permission = "student:read"
+	// ReadOwnStudents allows reading and listing only the students linked to the caller as their guardian.
+	ReadOwnStudents Permission = "student:read:own"
+	CreateStudents  Permission = "student:create"
+	// UpdateStudents allows replacing, patching and reverting students.
+	UpdateStudents Permission = "student:update"
+	DeleteStudents Permission = "student:delete"
+	// ManageTrash allows listing the trash and restoring students from it.
+	ManageTrash Permission = "student:trash"
+	ReadHistory Permission = "student:history"
+	PurgeTrash  Permission = "student:purge"
+)
+
+func known(p Permission) bool {
+	switch p {
+	case ReadStudents, ReadOwnStudents, CreateStudents, UpdateStudents, DeleteStudents, ManageTrash, ReadHistory,
+		PurgeTrash:
+		return true
+	default:
+		return false
+	}
+}
+
+type matrix struct {
+	roles map[string]map[Permission]bool
+}
+
+// New builds the matrix from the permissions of each role, and rejects permissions it does not know, so that a
+// typo in the configuration cannot silently grant or withhold anything.
+func New(roles map[string][]string) (matrix, error) {
+	m := matrix{roles: make(map[string]map[Permission]bool, len(roles))}
+
+	for role, perms := range roles {
+		m.roles[role] = make(map[Permission]bool, len(perms))
+
+		for _, p := range perms {
+			if !known(Permission(p)) {
+				return matrix{}, fmt.Errorf("role %s: unknown permission %q", role, p)
+			}
+
+			m.roles[role][Permission(p)] = true
+		}
+	}
+
+	return m, nil
+}
+
+// HasRole reports whether role is in the matrix.
+func (m matrix) HasRole(role string) bool {
+	_, ok := m.roles[role]
+
+	return ok
+}
+
+// Allowed reports whether the principal of ctx has been granted perm. Requests without a principal have no
+// permissions.
+func (m matrix) Allowed(ctx context.Context, perm Permission) bool {
+	p, ok := requestctx.Principal(ctx)
+
+	return ok && m.roles[p.Role][perm]
+}
+
+// Check returns an errors.Forbidden naming perm when the principal of ctx has not been granted it.
+func (m matrix) Check(ctx context.Context, perm Permission) error {
+	if !m.Allowed(ctx, perm) {
+		return errors.Forbidden{Permission: string(perm)}
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package policy
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"student-management-system/config"
+	"student-management-system/errors"
+	"student-management-system/models"
+	"student-management-system/requestctx"
+)
+
+func TestNew_UnknownPermission(t *testing.T) {
+	_, err := New(map[string][]string{"teacher": {"student:read", "student:reed"}})
+
+	if err == nil || err.Error() != `role teacher: unknown permission "student:reed"` {
+		t.Errorf("expected the unknown permission to be rejected got %v", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	m, err := New(config.Default().Auth.Roles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	as := func(role string) context.Context {
+		return requestctx.WithPrincipal(context.Background(), models.Principal{Subject: "someone", Role: role})
+	}
+
+	testcases := []struct {
+		desc   string
+		ctx    context.Context
+		perm   Permission
+		expErr error
+	}{
+		{desc: "admin may purge", ctx: as("admin"), perm: PurgeTrash},
+		{desc: "registrar may delete", ctx: as("registrar"), perm: DeleteStudents},
+		{desc: "registrar may not purge", ctx: as("registrar"), perm: PurgeTrash,
+			expErr: errors.Forbidden{Permission: "student:purge"}},
+		{desc: "teacher may read", ctx: as("teacher"), perm: ReadStudents},
+		{desc: "teacher may not delete", ctx: as("teacher"), perm: DeleteStudents,
+			expErr: errors.Forbidden{Permission: "student:delete"}},
+		{desc: "parent may only read their own", ctx: as("parent"), perm: ReadStudents,
+			expErr: errors.Forbidden{Permission: "student:read"}},
+		{desc: "unknown role", ctx: as("janitor"), perm: ReadStudents, expErr: errors.Forbidden{Permission: "student:read"}},
+		{desc: "no principal", ctx: context.Background(), perm: ReadStudents,
+			expErr: errors.Forbidden{Permission: "student:read"}},
+	}
+
+	for i, tc := range testcases {
+		err := m.Check(tc.ctx, tc.perm)
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
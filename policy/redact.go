@@ -0,0 +1,184 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"student-management-system/models"
+	"student-management-system/requestctx"
+)
+
+// visibility is how a field redacted for a role is shown to it.
+type visibility int
+
+const (
+	// omitted fields are left out of students and of their history.
+	omitted visibility = iota + 1
+	// masked fields show only their last maskKeep characters, e.g. "*********3210".
+	masked
+)
+
+// maskKeep is how many trailing characters a masked field keeps, when it is long enough to hide the rest.
+const maskKeep = 4
+
+type redaction struct {
+	roles map[string]map[string]visibility
+}
+
+// NewRedaction builds the field visibility rules from the redacted fields of each role, given by json name and
+// suffixed with ":mask" to mask rather than omit them. Unknown fields are rejected, and only text fields can be
+// masked.
+func NewRedaction(rules map[string][]string) (redaction, error) {
+	r := redaction{roles: make(map[string]map[string]visibility, len(rules))}
+	text := textFields(&models.Student{})
+
+	for role, fields := range rules {
+		r.roles[role] = make(map[string]visibility, len(fields))
+
+		for _, f := range fields {
+			field, mask := strings.TrimSuffix(f, ":mask"), strings.HasSuffix(f, ":mask")
+			_, isText := text[field]
+
+			switch {
+			case !isText && field != "dob" && field != "family_income":
+				return redaction{}, fmt.Errorf("role %s: unknown field %q", role, field)
+			case mask && !isText:
+				return redaction{}, fmt.Errorf("role %s: field %q can only be omitted", role, field)
+			case mask:
+				r.roles[role][field] = masked
+			default:
+				r.roles[role][field] = omitted
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// Visible reports whether the caller of ctx sees field unredacted, and may therefore filter and sort on it.
+func (r redaction) Visible(ctx context.Context, field string) bool {
+	return r.rules(ctx)[field] == 0
+}
+
+// Hides reports whether any field is redacted for the caller of ctx.
+func (r redaction) Hides(ctx context.Context) bool {
+	return len(r.rules(ctx)) > 0
+}
+
+// Student redacts the fields of student hidden from the caller of ctx.
+func (r redaction) Student(ctx context.Context, student *models.Student) {
+	text := textFields(student)
+
+	for field, v := range r.rules(ctx) {
+		switch {
+		case field == "dob":
+			student.Dob = models.Date{}
+		case field == "family_income":
+			student.FamilyIncome = 0
+		case v == masked:
+			*text[field] = mask(*text[field])
+		default:
+			*text[field] = ""
+		}
+	}
+}
+
+// Changes redacts a recorded history the same way as the students it describes: changes of omitted fields are
+// dropped and the values of masked fields are masked.
+func (r redaction) Changes(ctx context.Context, changes []models.Change) []models.Change {
+	rules := r.rules(ctx)
+	if len(rules) == 0 {
+		return changes
+	}
+
+	visible := make([]models.Change, 0, len(changes))
+
+	for _, c := range changes {
+		switch rules[c.Field] {
+		case omitted:
+			continue
+		case masked:
+			c.Before, c.After = maskJSON(c.Before), maskJSON(c.After)
+		}
+
+		visible = append(visible, c)
+	}
+
+	return visible
+}
+
+// Keep copies the fields hidden from the caller of ctx from current into student, so that replacing a student
+// does not clear the fields the caller could not see.
+func (r redaction) Keep(ctx context.Context, student, current *models.Student) {
+	text, old := textFields(student), textFields(current)
+
+	for field := range r.rules(ctx) {
+		switch field {
+		case "dob":
+			student.Dob = current.Dob
+		case "family_income":
+			student.FamilyIncome = current.FamilyIncome
+		default:
+			*text[field] = *old[field]
+		}
+	}
+}
+
+func (r redaction) rules(ctx context.Context) map[string]visibility {
+	p, ok := requestctx.Principal(ctx)
+	if !ok {
+		return nil
+	}
+
+	return r.roles[p.Role]
+}
+
+// textFields returns the text fields of student by json name.
+func textFields(student *models.Student) map[string]*string {
+	return map[string]*string{
+		"first_name":               &student.FirstName,
+		"last_name":                &student.LastName,
+		"gender":                   &student.Gender,
+		"mother_tongue":            &student.MotherTongue,
+		"nationality":              &student.Nationality,
+		"father_name":              &student.FatherName,
+		"mother_name":              &student.MotherName,
+		"contact_number":           &student.ContactNumber,
+		"home_contact_number":      &student.HomeContactNumber,
+		"emergency_contact_number": &student.EmergencyContactNumber,
+		"father_occupation":        &student.FatherOccupation,
+		"mother_occupation":        &student.MotherOccupation,
+	}
+}
+
+func mask(s string) string {
+	r := []rune(s)
+	hide := len(r) - maskKeep
+
+	if hide <= maskKeep {
+		hide = len(r)
+	}
+
+	for i := 0; i < hide; i++ {
+		r[i] = '*'
+	}
+
+	return string(r)
+}
+
+// maskJSON masks a recorded JSON string value, and leaves null and empty values alone.
+func maskJSON(raw json.RawMessage) json.RawMessage {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil || s == "" {
+		return raw
+	}
+
+	b, err := json.Marshal(mask(s))
+	if err != nil {
+		return raw
+	}
+
+	return b
+}
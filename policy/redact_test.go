@@ -0,0 +1,66 @@
+package policy
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"student-management-system/models"
+	"student-management-system/requestctx"
+)
+
+func TestNewRedaction_Errors(t *testing.T) {
+	testcases := []struct {
+		desc   string
+		rules  map[string][]string
+		expErr string
+	}{
+		{desc: "unknown field", rules: map[string][]string{"teacher": {"income"}}, expErr: `role teacher: unknown field "income"`},
+		{desc: "masked number", rules: map[string][]string{"teacher": {"family_income:mask"}},
+			expErr: `role teacher: field "family_income" can only be omitted`},
+	}
+
+	for i, tc := range testcases {
+		_, err := NewRedaction(tc.rules)
+
+		if err == nil || err.Error() != tc.expErr {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
+
+func TestRedaction_Student(t *testing.T) {
+	r, err := NewRedaction(map[string][]string{"teacher": {"dob", "family_income", "mother_name", "contact_number:mask",
+		"home_contact_number:mask"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	student := models.Student{FirstName: "Asha", Dob: models.NewDate(2010, 4, 1), MotherName: "Meera",
+		ContactNumber: "+919876543210", HomeContactNumber: "1234567", FamilyIncome: 50000}
+
+	testcases := []struct {
+		desc   string
+		role   string
+		expRes models.Student
+	}{
+		{desc: "redacted role", role: "teacher", expRes: models.Student{FirstName: "Asha", ContactNumber: "*********3210",
+			HomeContactNumber: "*******"}},
+		{desc: "role without rules", role: "admin", expRes: student},
+	}
+
+	for i, tc := range testcases {
+		ctx := requestctx.WithPrincipal(context.Background(), models.Principal{Subject: "someone", Role: tc.role})
+		res := student
+
+		r.Student(ctx, &res)
+
+		if !reflect.DeepEqual(tc.expRes, res) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes, res)
+		}
+	}
+
+	if !r.Visible(context.Background(), "family_income") {
+		t.Errorf("expected no redaction without a principal")
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"student-management-system/config"
+)
+
+type purger interface {
+	Purge(ctx context.Context, before time.Time) (int, error)
+}
+
+// purgeTrash permanently deletes the students that have been in the trash for longer than the retention, at
+// startup and then every purge interval, until ctx is cancelled. A purge interval of 0 disables it.
+func purgeTrash(ctx context.Context, p purger, cfg *config.Trash) {
+	if cfg.PurgeInterval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(cfg.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		n, err := p.Purge(ctx, time.Now().Add(-cfg.Retention))
+		if err != nil && ctx.Err() == nil {
+			log.Println("purging trash:", err.Error())
+		}
+
+		if n > 0 {
+			log.Printf("purged %d students deleted more than %s ago", n, cfg.Retention)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
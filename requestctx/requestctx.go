@@ -0,0 +1,48 @@
+// Package requestctx carries request scoped values, such as the request ID, from the HTTP layer down to the
+// service and store layers.
+package requestctx
+
+import (
+	"context"
+
+	"student-management-system/models"
+)
+
+type key int
+
+const (
+	requestIDKey key = iota
+	principalKey
+)
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID returns the ID of the request ctx belongs to, or "" outside of a request.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+
+	return id
+}
+
+func WithPrincipal(ctx context.Context, p models.Principal) context.Context {
+	return context.WithValue(ctx, principalKey, p)
+}
+
+// Principal returns who the request ctx belongs to was authenticated as, and false when it was not.
+func Principal(ctx context.Context) (models.Principal, bool) {
+	p, ok := ctx.Value(principalKey).(models.Principal)
+
+	return p, ok
+}
+
+// Actor names who made the request ctx belongs to, for the audit log: the subject of its principal, or
+// "anonymous" when the request is not authenticated.
+func Actor(ctx context.Context) string {
+	if p, ok := Principal(ctx); ok && p.Subject != "" {
+		return p.Subject
+	}
+
+	return "anonymous"
+}
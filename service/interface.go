@@ -2,14 +2,38 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"student-management-system/models"
 )
 
+// Student manages students. Put takes the version it is based on in student.Version, and Delete and Patch take
+// it as an argument; 0 updates whatever version is stored. Delete moves the student to the trash, from where
+// Restore brings it back until Purge removes it for good. Every change is recorded in the audit log that History
+// returns, and Revert undoes the changes made since an earlier version. The batch methods apply one of these to
+// many students at once and report the result of each, Import creates students in bulk with a dry run, and
+// Export streams the students Get would list, without paging them.
 type Student interface {
-	Delete(ctx context.Context, id int) error
-	Get(ctx context.Context, firstName, lastName string) ([]models.Student, error)
+	Delete(ctx context.Context, id, version int) error
+	DeleteBatch(ctx context.Context, items []models.BatchItem, mode models.BatchMode) ([]models.BatchResult, error)
+	Export(ctx context.Context, filter *models.Filter, fn func(student models.Student) error) error
+	Get(ctx context.Context, filter *models.Filter) (models.StudentList, error)
 	GetByID(ctx context.Context, id int) (models.Student, error)
+	History(ctx context.Context, id int) ([]models.AuditEntry, error)
+	Import(ctx context.Context, items []models.BatchItem, dryRun bool) ([]models.BatchResult, error)
+	Patch(ctx context.Context, id, version int, patchType models.PatchType, patch []byte) (models.Student, error)
 	Post(ctx context.Context, student *models.Student) (models.Student, error)
+	PostBatch(ctx context.Context, items []models.BatchItem, mode models.BatchMode) ([]models.BatchResult, error)
+	Purge(ctx context.Context, before time.Time) (int, error)
 	Put(ctx context.Context, id int, student *models.Student) (models.Student, error)
+	PutBatch(ctx context.Context, items []models.BatchItem, mode models.BatchMode) ([]models.BatchResult, error)
+	Restore(ctx context.Context, id int) (models.Student, error)
+	Revert(ctx context.Context, id, version, to int) (models.Student, error)
+}
+
+// User manages the accounts that can log in to the API.
+type User interface {
+	Create(ctx context.Context, username, password, role string) (models.User, error)
+	Link(ctx context.Context, username string, studentID int) error
+	Login(ctx context.Context, credentials *models.Credentials) (models.Token, error)
 }
@@ -8,6 +8,7 @@ import (
 	context "context"
 	reflect "reflect"
 	models "student-management-system/models"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -36,32 +37,61 @@ func (m *MockStudent) EXPECT() *MockStudentMockRecorder {
 }
 
 // Delete mocks base method.
-func (m *MockStudent) Delete(ctx context.Context, id int) error {
+func (m *MockStudent) Delete(ctx context.Context, id, version int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret := m.ctrl.Call(m, "Delete", ctx, id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockStudentMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+func (mr *MockStudentMockRecorder) Delete(ctx, id, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockStudent)(nil).Delete), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockStudent)(nil).Delete), ctx, id, version)
+}
+
+// DeleteBatch mocks base method.
+func (m *MockStudent) DeleteBatch(ctx context.Context, items []models.BatchItem, mode models.BatchMode) ([]models.BatchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBatch", ctx, items, mode)
+	ret0, _ := ret[0].([]models.BatchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteBatch indicates an expected call of DeleteBatch.
+func (mr *MockStudentMockRecorder) DeleteBatch(ctx, items, mode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBatch", reflect.TypeOf((*MockStudent)(nil).DeleteBatch), ctx, items, mode)
+}
+
+// Export mocks base method.
+func (m *MockStudent) Export(ctx context.Context, filter *models.Filter, fn func(models.Student) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Export", ctx, filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Export indicates an expected call of Export.
+func (mr *MockStudentMockRecorder) Export(ctx, filter, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockStudent)(nil).Export), ctx, filter, fn)
 }
 
 // Get mocks base method.
-func (m *MockStudent) Get(ctx context.Context, firstName, lastName string) ([]models.Student, error) {
+func (m *MockStudent) Get(ctx context.Context, filter *models.Filter) (models.StudentList, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", ctx, firstName, lastName)
-	ret0, _ := ret[0].([]models.Student)
+	ret := m.ctrl.Call(m, "Get", ctx, filter)
+	ret0, _ := ret[0].(models.StudentList)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Get indicates an expected call of Get.
-func (mr *MockStudentMockRecorder) Get(ctx, firstName, lastName interface{}) *gomock.Call {
+func (mr *MockStudentMockRecorder) Get(ctx, filter interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockStudent)(nil).Get), ctx, firstName, lastName)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockStudent)(nil).Get), ctx, filter)
 }
 
 // GetByID mocks base method.
@@ -79,6 +109,51 @@ func (mr *MockStudentMockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockStudent)(nil).GetByID), ctx, id)
 }
 
+// History mocks base method.
+func (m *MockStudent) History(ctx context.Context, id int) ([]models.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "History", ctx, id)
+	ret0, _ := ret[0].([]models.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// History indicates an expected call of History.
+func (mr *MockStudentMockRecorder) History(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "History", reflect.TypeOf((*MockStudent)(nil).History), ctx, id)
+}
+
+// Import mocks base method.
+func (m *MockStudent) Import(ctx context.Context, items []models.BatchItem, dryRun bool) ([]models.BatchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Import", ctx, items, dryRun)
+	ret0, _ := ret[0].([]models.BatchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Import indicates an expected call of Import.
+func (mr *MockStudentMockRecorder) Import(ctx, items, dryRun interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Import", reflect.TypeOf((*MockStudent)(nil).Import), ctx, items, dryRun)
+}
+
+// Patch mocks base method.
+func (m *MockStudent) Patch(ctx context.Context, id, version int, patchType models.PatchType, patch []byte) (models.Student, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Patch", ctx, id, version, patchType, patch)
+	ret0, _ := ret[0].(models.Student)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Patch indicates an expected call of Patch.
+func (mr *MockStudentMockRecorder) Patch(ctx, id, version, patchType, patch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockStudent)(nil).Patch), ctx, id, version, patchType, patch)
+}
+
 // Post mocks base method.
 func (m *MockStudent) Post(ctx context.Context, student *models.Student) (models.Student, error) {
 	m.ctrl.T.Helper()
@@ -94,6 +169,36 @@ func (mr *MockStudentMockRecorder) Post(ctx, student interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Post", reflect.TypeOf((*MockStudent)(nil).Post), ctx, student)
 }
 
+// PostBatch mocks base method.
+func (m *MockStudent) PostBatch(ctx context.Context, items []models.BatchItem, mode models.BatchMode) ([]models.BatchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostBatch", ctx, items, mode)
+	ret0, _ := ret[0].([]models.BatchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PostBatch indicates an expected call of PostBatch.
+func (mr *MockStudentMockRecorder) PostBatch(ctx, items, mode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostBatch", reflect.TypeOf((*MockStudent)(nil).PostBatch), ctx, items, mode)
+}
+
+// Purge mocks base method.
+func (m *MockStudent) Purge(ctx context.Context, before time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", ctx, before)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockStudentMockRecorder) Purge(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockStudent)(nil).Purge), ctx, before)
+}
+
 // Put mocks base method.
 func (m *MockStudent) Put(ctx context.Context, id int, student *models.Student) (models.Student, error) {
 	m.ctrl.T.Helper()
@@ -108,3 +213,115 @@ func (mr *MockStudentMockRecorder) Put(ctx, id, student interface{}) *gomock.Cal
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockStudent)(nil).Put), ctx, id, student)
 }
+
+// PutBatch mocks base method.
+func (m *MockStudent) PutBatch(ctx context.Context, items []models.BatchItem, mode models.BatchMode) ([]models.BatchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutBatch", ctx, items, mode)
+	ret0, _ := ret[0].([]models.BatchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PutBatch indicates an expected call of PutBatch.
+func (mr *MockStudentMockRecorder) PutBatch(ctx, items, mode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutBatch", reflect.TypeOf((*MockStudent)(nil).PutBatch), ctx, items, mode)
+}
+
+// Restore mocks base method.
+func (m *MockStudent) Restore(ctx context.Context, id int) (models.Student, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, id)
+	ret0, _ := ret[0].(models.Student)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockStudentMockRecorder) Restore(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockStudent)(nil).Restore), ctx, id)
+}
+
+// Revert mocks base method.
+func (m *MockStudent) Revert(ctx context.Context, id, version, to int) (models.Student, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revert", ctx, id, version, to)
+	ret0, _ := ret[0].(models.Student)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revert indicates an expected call of Revert.
+func (mr *MockStudentMockRecorder) Revert(ctx, id, version, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revert", reflect.TypeOf((*MockStudent)(nil).Revert), ctx, id, version, to)
+}
+
+// MockUser is a mock of User interface.
+type MockUser struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserMockRecorder
+}
+
+// MockUserMockRecorder is the mock recorder for MockUser.
+type MockUserMockRecorder struct {
+	mock *MockUser
+}
+
+// NewMockUser creates a new mock instance.
+func NewMockUser(ctrl *gomock.Controller) *MockUser {
+	mock := &MockUser{ctrl: ctrl}
+	mock.recorder = &MockUserMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUser) EXPECT() *MockUserMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockUser) Create(ctx context.Context, username, password, role string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, username, password, role)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockUserMockRecorder) Create(ctx, username, password, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUser)(nil).Create), ctx, username, password, role)
+}
+
+// Link mocks base method.
+func (m *MockUser) Link(ctx context.Context, username string, studentID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Link", ctx, username, studentID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Link indicates an expected call of Link.
+func (mr *MockUserMockRecorder) Link(ctx, username, studentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Link", reflect.TypeOf((*MockUser)(nil).Link), ctx, username, studentID)
+}
+
+// Login mocks base method.
+func (m *MockUser) Login(ctx context.Context, credentials *models.Credentials) (models.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Login", ctx, credentials)
+	ret0, _ := ret[0].(models.Token)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Login indicates an expected call of Login.
+func (mr *MockUserMockRecorder) Login(ctx, credentials interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockUser)(nil).Login), ctx, credentials)
+}
@@ -0,0 +1,171 @@
+package student
+
+import (
+	"context"
+	"encoding/json"
+
+	"student-management-system/errors"
+	"student-management-system/models"
+	"student-management-system/requestctx"
+)
+
+// write runs change and appends what it did to the audit log in one transaction, so that no change goes
+// unrecorded. before is the student as it was, the zero Student for a new one, or nil when the change moves
+// the student in or out of the trash without touching its fields.
+func (s service) write(ctx context.Context, action models.AuditAction, before *models.Student,
+	change func(ctx context.Context) (models.Student, error)) (models.Student, error) {
+	var after models.Student
+
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		var err error
+
+		if after, err = change(ctx); err != nil {
+			return err
+		}
+
+		return s.record(ctx, action, before, &after)
+	})
+
+	return after, err
+}
+
+// record appends a change from before, as for write, to after to the audit log.
+func (s service) record(ctx context.Context, action models.AuditAction, before, after *models.Student) error {
+	entry := models.AuditEntry{StudentID: after.ID, Version: after.Version, Action: action, Actor: requestctx.Actor(ctx),
+		RequestID: requestctx.RequestID(ctx), At: s.now().UTC(), Changes: []models.Change{}}
+
+	if before != nil {
+		var err error
+
+		if entry.Changes, err = diff(before, after); err != nil {
+			return err
+		}
+	}
+
+	return s.audit.Create(ctx, &entry)
+}
+
+// History returns the changes made to a student, oldest first. Students created before the audit log was
+// introduced may have an empty or partial history.
+func (s service) History(ctx context.Context, id int) ([]models.AuditEntry, error) {
+	entries, err := s.audit.GetByStudentID(ctx, id)
+	if err != nil {
+		return nil, errors.Internal{Err: err}
+	}
+
+	if len(entries) == 0 {
+		if _, err := s.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
+
+// Revert brings the fields of a student back to what they were at an earlier version, by undoing the changes
+// in its history since then, and records that as a new version. A version other than 0 must match the stored one.
+func (s service) Revert(ctx context.Context, id, version, to int) (models.Student, error) {
+	current, err := s.GetByID(ctx, id)
+	if err != nil {
+		return models.Student{}, err
+	}
+
+	if err := checkVersion(id, version, current.Version); err != nil {
+		return models.Student{}, err
+	}
+
+	if to < 1 || to >= current.Version {
+		return models.Student{}, errors.InvalidParam{Field: "version", Reason: "must be an earlier version of the student"}
+	}
+
+	entries, err := s.audit.GetByStudentID(ctx, id)
+	if err != nil {
+		return models.Student{}, errors.Internal{Err: err}
+	}
+
+	student, err := rewind(&current, entries, to)
+	if err != nil {
+		return models.Student{}, err
+	}
+
+	if err := s.isValidate(&student, student.Dob != current.Dob); err != nil {
+		return models.Student{}, err
+	}
+
+	return s.update(ctx, models.ActionRevert, &current, &student)
+}
+
+// rewind undoes, newest first, the changes that took the student from version to up to its current version.
+// It fails if the history misses any of them, such as changes made before the audit log was introduced.
+func rewind(current *models.Student, entries []models.AuditEntry, to int) (models.Student, error) {
+	fields, err := toFields(current)
+	if err != nil {
+		return models.Student{}, errors.Internal{Err: err}
+	}
+
+	version := current.Version
+
+	for i := len(entries) - 1; i >= 0 && version > to; i-- {
+		if entries[i].Version != version {
+			continue
+		}
+
+		for _, c := range entries[i].Changes {
+			fields[c.Field] = c.Before
+		}
+
+		version--
+	}
+
+	if version != to {
+		return models.Student{}, errors.InvalidParam{Field: "version", Reason: "is not in the recorded history of the student"}
+	}
+
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return models.Student{}, errors.Internal{Err: err}
+	}
+
+	var doc document
+
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return models.Student{}, errors.Internal{Err: err}
+	}
+
+	student := models.Student(doc)
+	student.Version = current.Version
+
+	return student, nil
+}
+
+// diff lists the fields that differ between before and after, with their JSON values.
+func diff(before, after *models.Student) ([]models.Change, error) {
+	old, err := toFields(before)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := toFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]models.Change, 0)
+
+	for _, field := range changedFields(before, after) {
+		changes = append(changes, models.Change{Field: field, Before: old[field], After: updated[field]})
+	}
+
+	return changes, nil
+}
+
+func toFields(student *models.Student) (map[string]json.RawMessage, error) {
+	b, err := json.Marshal(document(*student))
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+
+	return fields, json.Unmarshal(b, &fields)
+}
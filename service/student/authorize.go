@@ -0,0 +1,419 @@
+package student
+
+import (
+	"context"
+	"time"
+
+	"student-management-system/errors"
+	"student-management-system/models"
+	"student-management-system/policy"
+	"student-management-system/requestctx"
+	svc "student-management-system/service"
+)
+
+type permissions interface {
+	Allowed(ctx context.Context, perm policy.Permission) bool
+	Check(ctx context.Context, perm policy.Permission) error
+}
+
+type guardians interface {
+	GetStudentIDs(ctx context.Context, username string) ([]int, error)
+}
+
+type redactor interface {
+	Hides(ctx context.Context) bool
+	Visible(ctx context.Context, field string) bool
+	Student(ctx context.Context, student *models.Student)
+	Changes(ctx context.Context, changes []models.Change) []models.Change
+	Keep(ctx context.Context, student, current *models.Student)
+}
+
+// authorized checks every call against the permissions of the role of the caller before passing it on, so that
+// the rules hold for every transport and not only for HTTP. It likewise redacts every student it returns.
+type authorized struct {
+	student   svc.Student
+	policy    permissions
+	guardians guardians
+	redact    redactor
+}
+
+// Authorize wraps s with the permission checks of p and the field redaction of r. Callers that may only read
+// their own students see the students g links them to as a guardian.
+func Authorize(s svc.Student, p permissions, g guardians, r redactor) authorized {
+	return authorized{student: s, policy: p, guardians: g, redact: r}
+}
+
+func (a authorized) Delete(ctx context.Context, id, version int) error {
+	if err := a.policy.Check(ctx, policy.DeleteStudents); err != nil {
+		return err
+	}
+
+	return a.student.Delete(ctx, id, version)
+}
+
+func (a authorized) DeleteBatch(ctx context.Context, items []models.BatchItem, mode models.BatchMode) ([]models.BatchResult, error) {
+	if err := a.policy.Check(ctx, policy.DeleteStudents); err != nil {
+		return nil, err
+	}
+
+	return a.student.DeleteBatch(ctx, items, mode)
+}
+
+// Get lists students. Callers with only policy.ReadOwnStudents get their own students out of those matching
+// the filter, and the trash needs policy.ManageTrash. Filtering or sorting on a field redacted for the caller
+// is rejected, as it would reveal the values it hides.
+func (a authorized) Get(ctx context.Context, filter *models.Filter) (models.StudentList, error) {
+	if err := a.scope(ctx, filter); err != nil {
+		return models.StudentList{}, err
+	}
+
+	list, err := a.student.Get(ctx, filter)
+
+	return a.list(ctx, list, err)
+}
+
+// Export exports the students Get would list, each redacted for the caller before fn sees it.
+func (a authorized) Export(ctx context.Context, filter *models.Filter, fn func(student models.Student) error) error {
+	if err := a.scope(ctx, filter); err != nil {
+		return err
+	}
+
+	return a.student.Export(ctx, filter, func(student models.Student) error {
+		a.redact.Student(ctx, &student)
+
+		return fn(student)
+	})
+}
+
+func (a authorized) GetByID(ctx context.Context, id int) (models.Student, error) {
+	if !a.policy.Allowed(ctx, policy.ReadStudents) {
+		ids, err := a.own(ctx)
+		if err != nil {
+			return models.Student{}, err
+		}
+
+		if !contains(ids, id) {
+			return models.Student{}, errors.Forbidden{Permission: string(policy.ReadStudents)}
+		}
+	}
+
+	res, err := a.student.GetByID(ctx, id)
+
+	return a.one(ctx, res, err)
+}
+
+func (a authorized) History(ctx context.Context, id int) ([]models.AuditEntry, error) {
+	if err := a.policy.Check(ctx, policy.ReadHistory); err != nil {
+		return nil, err
+	}
+
+	entries, err := a.student.History(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		entries[i].Changes = a.redact.Changes(ctx, entries[i].Changes)
+	}
+
+	return entries, nil
+}
+
+func (a authorized) Import(ctx context.Context, items []models.BatchItem, dryRun bool) ([]models.BatchResult, error) {
+	if err := a.policy.Check(ctx, policy.CreateStudents); err != nil {
+		return nil, err
+	}
+
+	return a.student.Import(ctx, items, dryRun)
+}
+
+func (a authorized) Patch(ctx context.Context, id, version int, patchType models.PatchType, patch []byte) (models.Student, error) {
+	if err := a.policy.Check(ctx, policy.UpdateStudents); err != nil {
+		return models.Student{}, err
+	}
+
+	if a.redact.Hides(ctx) {
+		var err error
+
+		if patchType, patch, err = a.redactedPatch(ctx, id, patchType, patch); err != nil {
+			return models.Student{}, err
+		}
+	}
+
+	res, err := a.student.Patch(ctx, id, version, patchType, patch)
+
+	return a.one(ctx, res, err)
+}
+
+// redactedPatch applies the patch to the student as the caller sees it, so that it can neither copy, move nor
+// test the values of hidden fields, keeps the stored values of those fields like Put, and returns the result as
+// a merge patch of the stored student that changes only the fields the caller can see.
+func (a authorized) redactedPatch(ctx context.Context, id int, patchType models.PatchType, patch []byte) (models.PatchType, []byte, error) {
+	current, err := a.student.GetByID(ctx, id)
+	if err != nil {
+		return "", nil, err
+	}
+
+	view := current
+	a.redact.Student(ctx, &view)
+
+	student, err := applyPatch(view, patchType, patch)
+	if err != nil {
+		return "", nil, err
+	}
+
+	a.redact.Keep(ctx, &student, &current)
+
+	merge, err := mergePatch(current, student)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return models.MergePatch, merge, nil
+}
+
+func (a authorized) Post(ctx context.Context, student *models.Student) (models.Student, error) {
+	if err := a.policy.Check(ctx, policy.CreateStudents); err != nil {
+		return models.Student{}, err
+	}
+
+	res, err := a.student.Post(ctx, student)
+
+	return a.one(ctx, res, err)
+}
+
+func (a authorized) PostBatch(ctx context.Context, items []models.BatchItem, mode models.BatchMode) ([]models.BatchResult, error) {
+	if err := a.policy.Check(ctx, policy.CreateStudents); err != nil {
+		return nil, err
+	}
+
+	return a.student.PostBatch(ctx, items, mode)
+}
+
+func (a authorized) Purge(ctx context.Context, before time.Time) (int, error) {
+	if err := a.policy.Check(ctx, policy.PurgeTrash); err != nil {
+		return 0, err
+	}
+
+	return a.student.Purge(ctx, before)
+}
+
+// Put replaces a student. The fields redacted for the caller keep their stored values, since the caller never
+// saw them.
+func (a authorized) Put(ctx context.Context, id int, student *models.Student) (models.Student, error) {
+	if err := a.policy.Check(ctx, policy.UpdateStudents); err != nil {
+		return models.Student{}, err
+	}
+
+	if a.redact.Hides(ctx) {
+		current, err := a.student.GetByID(ctx, id)
+		if err != nil {
+			return models.Student{}, err
+		}
+
+		a.redact.Keep(ctx, student, &current)
+	}
+
+	res, err := a.student.Put(ctx, id, student)
+
+	return a.one(ctx, res, err)
+}
+
+// PutBatch replaces students like Put. An item for a student that does not exist is left for the batch to
+// report.
+func (a authorized) PutBatch(ctx context.Context, items []models.BatchItem, mode models.BatchMode) ([]models.BatchResult, error) {
+	if err := a.policy.Check(ctx, policy.UpdateStudents); err != nil {
+		return nil, err
+	}
+
+	if a.redact.Hides(ctx) {
+		for _, item := range items {
+			if item.Student == nil {
+				continue
+			}
+
+			current, err := a.student.GetByID(ctx, item.ID)
+			if _, ok := err.(errors.EntityNotFound); ok {
+				continue
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			a.redact.Keep(ctx, item.Student, &current)
+		}
+	}
+
+	return a.student.PutBatch(ctx, items, mode)
+}
+
+func (a authorized) Restore(ctx context.Context, id int) (models.Student, error) {
+	if err := a.policy.Check(ctx, policy.ManageTrash); err != nil {
+		return models.Student{}, err
+	}
+
+	res, err := a.student.Restore(ctx, id)
+
+	return a.one(ctx, res, err)
+}
+
+func (a authorized) Revert(ctx context.Context, id, version, to int) (models.Student, error) {
+	if err := a.policy.Check(ctx, policy.UpdateStudents); err != nil {
+		return models.Student{}, err
+	}
+
+	if a.redact.Hides(ctx) {
+		if err := a.revertsVisible(ctx, id, to); err != nil {
+			return models.Student{}, err
+		}
+	}
+
+	res, err := a.student.Revert(ctx, id, version, to)
+
+	return a.one(ctx, res, err)
+}
+
+// revertsVisible fails when reverting the student to version to would undo a change of a field hidden from the
+// caller, which would overwrite a value the caller cannot see with one it never saw.
+func (a authorized) revertsVisible(ctx context.Context, id, to int) error {
+	entries, err := a.student.History(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.Version <= to {
+			continue
+		}
+
+		for _, c := range entry.Changes {
+			if !a.redact.Visible(ctx, c.Field) {
+				return errors.InvalidParam{Field: c.Field, Reason: "is hidden from the role of the caller"}
+			}
+		}
+	}
+
+	return nil
+}
+
+// own returns the IDs of the students the caller is a guardian of, and fails when the caller may not even read
+// those.
+func (a authorized) own(ctx context.Context) ([]int, error) {
+	if !a.policy.Allowed(ctx, policy.ReadOwnStudents) {
+		return nil, errors.Forbidden{Permission: string(policy.ReadStudents)}
+	}
+
+	p, _ := requestctx.Principal(ctx)
+
+	ids, err := a.guardians.GetStudentIDs(ctx, p.Subject)
+	if err != nil {
+		return nil, errors.Internal{Err: err}
+	}
+
+	return ids, nil
+}
+
+// one redacts the student returned by a call for the caller of ctx.
+func (a authorized) one(ctx context.Context, student models.Student, err error) (models.Student, error) {
+	if err != nil {
+		return models.Student{}, err
+	}
+
+	a.redact.Student(ctx, &student)
+
+	return student, nil
+}
+
+// list redacts the students listed by a call for the caller of ctx.
+func (a authorized) list(ctx context.Context, list models.StudentList, err error) (models.StudentList, error) {
+	if err != nil {
+		return models.StudentList{}, err
+	}
+
+	for i := range list.Data {
+		a.redact.Student(ctx, &list.Data[i])
+	}
+
+	return list, nil
+}
+
+// scope checks that the caller may list the students filter selects, and narrows it to their own students when
+// they may only read those.
+func (a authorized) scope(ctx context.Context, filter *models.Filter) error {
+	if err := a.visible(ctx, filter); err != nil {
+		return err
+	}
+
+	if filter.Deleted {
+		return a.policy.Check(ctx, policy.ManageTrash)
+	}
+
+	if !a.policy.Allowed(ctx, policy.ReadStudents) {
+		ids, err := a.own(ctx)
+		if err != nil {
+			return err
+		}
+
+		filter.IDs = ids
+	}
+
+	return nil
+}
+
+// visible rejects filters and sorts on the fields redacted for the caller.
+func (a authorized) visible(ctx context.Context, filter *models.Filter) error {
+	fields := filtered(filter)
+	for _, s := range filter.Sort {
+		fields = append(fields, s.Field)
+	}
+
+	for _, field := range fields {
+		if !a.redact.Visible(ctx, field) {
+			return errors.InvalidParam{Field: field, Reason: "is hidden from the role of the caller"}
+		}
+	}
+
+	return nil
+}
+
+// filtered returns the json names of the fields filter constrains.
+func filtered(filter *models.Filter) []string {
+	constraints := []struct {
+		field string
+		set   bool
+	}{
+		{"first_name", filter.FirstName != ""},
+		{"last_name", filter.LastName != ""},
+		{"gender", filter.Gender != ""},
+		{"dob", !filter.DobFrom.IsZero() || !filter.DobTo.IsZero()},
+		{"mother_tongue", filter.MotherTongue != ""},
+		{"nationality", filter.Nationality != ""},
+		{"father_name", filter.FatherName != ""},
+		{"mother_name", filter.MotherName != ""},
+		{"contact_number", filter.ContactNumber != ""},
+		{"father_occupation", filter.FatherOccupation != ""},
+		{"mother_occupation", filter.MotherOccupation != ""},
+		{"family_income", filter.MinFamilyIncome != 0 || filter.MaxFamilyIncome != 0},
+	}
+
+	var fields []string
+
+	for _, c := range constraints {
+		if c.set {
+			fields = append(fields, c.field)
+		}
+	}
+
+	return fields
+}
+
+func contains(ids []int, id int) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,316 @@
+package student
+
+import (
+	"context"
+	stdErrors "errors"
+	"strconv"
+
+	"student-management-system/errors"
+	"student-management-system/models"
+	"student-management-system/store"
+)
+
+// errDryRun rolls back the transaction of a dry run.
+var errDryRun = stdErrors.New("dry run")
+
+// PostBatch creates the students of items in one transaction, inserting them with multi-row statements. Every
+// item is validated first, and an item duplicating an active student or an earlier item fails. A best-effort
+// batch creates the other students, while an atomic batch with a failed item creates none.
+func (s service) PostBatch(ctx context.Context, items []models.BatchItem, mode models.BatchMode) ([]models.BatchResult, error) {
+	if err := checkBatch(len(items), mode); err != nil {
+		return nil, err
+	}
+
+	results := s.checkItems(items, true)
+	students, pending := s.unique(items, results)
+
+	if mode == models.Atomic && failed(results) {
+		return abort(results), nil
+	}
+
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		return s.insert(ctx, mode, students, pending, results)
+	})
+
+	var d store.Duplicates
+	if stdErrors.As(err, &d) {
+		return abort(results), nil
+	}
+
+	if err != nil {
+		return nil, errors.Internal{Err: err}
+	}
+
+	return results, nil
+}
+
+// Import creates the students of items like a best-effort PostBatch, in transactions of up to models.MaxBatch
+// students so that large files do not hold a transaction for long. A dry run reports the same results,
+// duplicates included, without creating any student: its inserts are rolled back.
+func (s service) Import(ctx context.Context, items []models.BatchItem, dryRun bool) ([]models.BatchResult, error) {
+	if len(items) < 1 || len(items) > models.MaxImport {
+		return nil, errors.InvalidParam{Field: "items", Reason: "must have between 1 and " + strconv.Itoa(models.MaxImport) + " items"}
+	}
+
+	results := s.checkItems(items, true)
+	students, pending := s.unique(items, results)
+
+	for start := 0; start < len(pending); start += models.MaxBatch {
+		end := start + models.MaxBatch
+		if end > len(pending) {
+			end = len(pending)
+		}
+
+		err := s.tx.InTx(ctx, func(ctx context.Context) error {
+			if err := s.insert(ctx, models.BestEffort, students, pending[start:end], results); err != nil {
+				return err
+			}
+
+			if dryRun {
+				return errDryRun
+			}
+
+			return nil
+		})
+		if err != nil && err != errDryRun {
+			return nil, errors.Internal{Err: err}
+		}
+	}
+
+	if dryRun {
+		for i := range results {
+			results[i].ID, results[i].Version = 0, 0
+		}
+	}
+
+	return results, nil
+}
+
+// unique normalises the students of the items that passed the checks of results, and fails those with the
+// identity of an earlier one. It returns the students and the indexes of those left to create.
+func (s service) unique(items []models.BatchItem, results []models.BatchResult) ([]models.Student, []int) {
+	students := make([]models.Student, len(items))
+	identities := make(map[string]bool, len(items))
+	pending := make([]int, 0, len(items))
+
+	for i := range items {
+		if results[i].Err != nil {
+			continue
+		}
+
+		students[i] = *items[i].Student
+		normalise(&students[i])
+		s.formatPhones(&students[i])
+
+		if identity := students[i].Identity(); identities[identity] {
+			results[i].Err = errors.EntityAlreadyExists{Entity: entity}
+		} else {
+			identities[identity] = true
+			pending = append(pending, i)
+		}
+	}
+
+	return students, pending
+}
+
+// insert creates the students at the pending indexes with a multi-row statement. The duplicates of active
+// students fail, and the others are inserted again without them unless the batch is atomic.
+func (s service) insert(ctx context.Context, mode models.BatchMode, students []models.Student, pending []int,
+	results []models.BatchResult) error {
+	for len(pending) > 0 {
+		batch := make([]models.Student, len(pending))
+		for j, i := range pending {
+			batch[j] = students[i]
+		}
+
+		created, err := s.student.PostBatch(ctx, batch)
+
+		var d store.Duplicates
+		if !stdErrors.As(err, &d) {
+			if err != nil {
+				return err
+			}
+
+			return s.created(ctx, pending, created, results)
+		}
+
+		// a failed statement is rolled back on its own, so the transaction goes on without the duplicates
+		rest := pending[:0]
+
+		for j, i := range pending {
+			if id, ok := d.IDs[j]; ok {
+				results[i].Err = errors.EntityAlreadyExists{Entity: entity, ID: strconv.Itoa(id)}
+			} else {
+				rest = append(rest, i)
+			}
+		}
+
+		if mode == models.Atomic {
+			return d
+		}
+
+		pending = rest
+	}
+
+	return nil
+}
+
+// PutBatch replaces the students of items, each at the version of its item. An atomic batch replaces them in
+// one transaction, and none of them once an item fails; a best-effort batch replaces each on its own.
+func (s service) PutBatch(ctx context.Context, items []models.BatchItem, mode models.BatchMode) ([]models.BatchResult, error) {
+	if err := checkBatch(len(items), mode); err != nil {
+		return nil, err
+	}
+
+	return s.each(ctx, items, mode, s.checkItems(items, false), func(ctx context.Context, item *models.BatchItem) (models.Student, error) {
+		student := *item.Student
+		student.Version = item.Version
+
+		return s.Put(ctx, item.ID, &student)
+	})
+}
+
+// DeleteBatch moves the students of items to the trash, each if still at the version of its item, like
+// PutBatch. Their results carry no version.
+func (s service) DeleteBatch(ctx context.Context, items []models.BatchItem, mode models.BatchMode) ([]models.BatchResult, error) {
+	if err := checkBatch(len(items), mode); err != nil {
+		return nil, err
+	}
+
+	results := make([]models.BatchResult, len(items))
+	for i := range results {
+		results[i].Index = i
+	}
+
+	return s.each(ctx, items, mode, results, func(ctx context.Context, item *models.BatchItem) (models.Student, error) {
+		return models.Student{ID: item.ID}, s.Delete(ctx, item.ID, item.Version)
+	})
+}
+
+// checkItems validates the students of items, so that a batch reports every invalid item and not only the
+// first. The age range is checked for new students only; Put checks it for a changed dob.
+func (s service) checkItems(items []models.BatchItem, create bool) []models.BatchResult {
+	results := make([]models.BatchResult, len(items))
+
+	for i := range items {
+		results[i].Index = i
+
+		if items[i].Student == nil {
+			results[i].Err = errors.InvalidParam{Field: "student", Reason: "is required"}
+
+			continue
+		}
+
+		student := *items[i].Student
+		normalise(&student)
+		s.formatPhones(&student)
+
+		results[i].Err = s.isValidate(&student, create)
+	}
+
+	return results
+}
+
+// each applies apply to the items that passed the checks of results. An atomic batch applies them in one
+// transaction, rolled back at the first failure, and a best-effort batch applies each in its own.
+func (s service) each(ctx context.Context, items []models.BatchItem, mode models.BatchMode, results []models.BatchResult,
+	apply func(ctx context.Context, item *models.BatchItem) (models.Student, error)) ([]models.BatchResult, error) {
+	if mode == models.BestEffort {
+		for i := range items {
+			if results[i].Err == nil {
+				res, err := apply(ctx, &items[i])
+				results[i] = result(i, res, err)
+			}
+		}
+
+		return results, nil
+	}
+
+	if failed(results) {
+		return abort(results), nil
+	}
+
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		for i := range items {
+			res, err := apply(ctx, &items[i])
+			if results[i] = result(i, res, err); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	switch {
+	case err != nil && failed(results):
+		return abort(results), nil
+	case err != nil:
+		return nil, errors.Internal{Err: err}
+	}
+
+	return results, nil
+}
+
+// created records the students created for the items at the given indexes in the audit log and in results.
+func (s service) created(ctx context.Context, indexes []int, students []models.Student, results []models.BatchResult) error {
+	for j, i := range indexes {
+		if err := s.record(ctx, models.ActionCreate, &models.Student{}, &students[j]); err != nil {
+			return err
+		}
+
+		results[i] = result(i, students[j], nil)
+	}
+
+	return nil
+}
+
+func checkBatch(n int, mode models.BatchMode) error {
+	switch {
+	case mode != models.Atomic && mode != models.BestEffort:
+		return errors.InvalidParam{Field: "mode", Reason: "must be one of " + string(models.Atomic) + " or " + string(models.BestEffort)}
+	case n < 1 || n > models.MaxBatch:
+		return errors.InvalidParam{Field: "items", Reason: "must have between 1 and " + strconv.Itoa(models.MaxBatch) + " items"}
+	}
+
+	return nil
+}
+
+func result(i int, student models.Student, err error) models.BatchResult {
+	if err != nil {
+		return models.BatchResult{Index: i, Err: err}
+	}
+
+	return models.BatchResult{Index: i, ID: student.ID, Version: student.Version}
+}
+
+func failed(results []models.BatchResult) bool {
+	for i := range results {
+		if results[i].Err != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// abort fails every item of an atomic batch that did not fail itself with errors.Aborted, naming the first
+// item that did, since the batch wrote none of them.
+func abort(results []models.BatchResult) []models.BatchResult {
+	first := -1
+
+	for i := range results {
+		if results[i].Err != nil {
+			first = i
+
+			break
+		}
+	}
+
+	for i := range results {
+		if results[i].Err == nil {
+			results[i] = models.BatchResult{Index: i, Err: errors.Aborted{Index: first}}
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,213 @@
+package student
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"student-management-system/errors"
+	"student-management-system/models"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// document is the JSON form of a student that patches are applied to. It has the fields of models.Student
+// without omitempty, so that a JSON Patch can test, replace or remove a field that is not set.
+type document struct {
+	ID                     int         `json:"id"`
+	FirstName              string      `json:"first_name"`
+	LastName               string      `json:"last_name"`
+	Gender                 string      `json:"gender"`
+	Dob                    models.Date `json:"dob"`
+	MotherTongue           string      `json:"mother_tongue"`
+	Nationality            string      `json:"nationality"`
+	FatherName             string      `json:"father_name"`
+	MotherName             string      `json:"mother_name"`
+	ContactNumber          string      `json:"contact_number"`
+	HomeContactNumber      string      `json:"home_contact_number"`
+	EmergencyContactNumber string      `json:"emergency_contact_number"`
+	FatherOccupation       string      `json:"father_occupation"`
+	MotherOccupation       string      `json:"mother_occupation"`
+	FamilyIncome           int         `json:"family_income"`
+	Version                int         `json:"-"`
+	DeletedAt              *time.Time  `json:"-"`
+}
+
+// Patch applies a JSON Merge Patch or a JSON Patch to the student, validates the result as a whole and
+// updates only the fields that changed. A version other than 0 must match the stored one.
+func (s service) Patch(ctx context.Context, id, version int, patchType models.PatchType, patch []byte) (models.Student, error) {
+	current, err := s.GetByID(ctx, id)
+	if err != nil {
+		return models.Student{}, err
+	}
+
+	if err := checkVersion(id, version, current.Version); err != nil {
+		return models.Student{}, err
+	}
+
+	student, err := applyPatch(current, patchType, patch)
+	if err != nil {
+		return models.Student{}, err
+	}
+
+	if student.ID != id {
+		return models.Student{}, errors.InvalidParam{Field: "id", Reason: "cannot be changed"}
+	}
+
+	normalise(&student)
+	s.formatPhones(&student)
+
+	if err := s.isValidate(&student, student.Dob != current.Dob); err != nil {
+		return models.Student{}, err
+	}
+
+	return s.update(ctx, models.ActionUpdate, &current, &student)
+}
+
+// update writes the fields of student that differ from current, on condition that the stored student is still
+// at the version of current, and leaves the student and its version as they are when no field differs. The
+// identity key is written along only when the fields change the identity, and not for a change of letter case
+// or spacing.
+func (s service) update(ctx context.Context, action models.AuditAction, current, student *models.Student) (models.Student, error) {
+	fields := changedFields(current, student)
+	if len(fields) == 0 {
+		return *current, nil
+	}
+
+	if current.Identity() != student.Identity() {
+		fields = append(fields, "identity_key")
+	}
+
+	student.Version = current.Version
+
+	res, err := s.write(ctx, action, current, func(ctx context.Context) (models.Student, error) {
+		return s.student.Patch(ctx, current.ID, student, fields)
+	})
+	if err != nil {
+		return models.Student{}, writeErr(current.ID, err)
+	}
+
+	return res, nil
+}
+
+func applyPatch(current models.Student, patchType models.PatchType, patch []byte) (models.Student, error) {
+	original, err := json.Marshal(document(current))
+	if err != nil {
+		return models.Student{}, errors.Internal{Err: err}
+	}
+
+	var patched []byte
+
+	switch patchType {
+	case models.MergePatch:
+		patched, err = jsonpatch.MergePatch(original, patch)
+	case models.JSONPatch:
+		var ops jsonpatch.Patch
+
+		ops, err = jsonpatch.DecodePatch(patch)
+		if err == nil {
+			patched, err = ops.Apply(original)
+		}
+	default:
+		return models.Student{}, errors.UnsupportedMediaType{MediaType: string(patchType)}
+	}
+
+	if err == nil {
+		patched, err = textContactNumber(patched)
+	}
+
+	if err != nil {
+		return models.Student{}, errors.InvalidParam{Field: "body", Reason: err.Error()}
+	}
+
+	var doc document
+
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&doc); err != nil {
+		return models.Student{}, errors.InvalidParam{Field: "body", Reason: err.Error()}
+	}
+
+	return models.Student(doc), nil
+}
+
+// textContactNumber turns a contact number the patch set to a JSON integer into a string, as a student sent whole
+// is read.
+func textContactNumber(doc []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+
+	if err := json.Unmarshal(doc, &fields); err != nil {
+		return nil, err
+	}
+
+	raw, ok := fields["contact_number"]
+	if !ok {
+		return doc, nil
+	}
+
+	number, err := models.ContactNumber(raw)
+	if err != nil || number == nil {
+		return doc, err
+	}
+
+	if fields["contact_number"], err = json.Marshal(*number); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(fields)
+}
+
+// mergePatch returns the JSON Merge Patch that takes before to after.
+func mergePatch(before, after models.Student) ([]byte, error) {
+	original, err := json.Marshal(document(before))
+	if err != nil {
+		return nil, errors.Internal{Err: err}
+	}
+
+	modified, err := json.Marshal(document(after))
+	if err != nil {
+		return nil, errors.Internal{Err: err}
+	}
+
+	patch, err := jsonpatch.CreateMergePatch(original, modified)
+	if err != nil {
+		return nil, errors.Internal{Err: err}
+	}
+
+	return patch, nil
+}
+
+// changedFields lists, in column order, the json names of the fields that differ between before and after.
+func changedFields(before, after *models.Student) []string {
+	fields := []struct {
+		name    string
+		changed bool
+	}{
+		{"first_name", before.FirstName != after.FirstName},
+		{"last_name", before.LastName != after.LastName},
+		{"gender", before.Gender != after.Gender},
+		{"dob", before.Dob != after.Dob},
+		{"mother_tongue", before.MotherTongue != after.MotherTongue},
+		{"nationality", before.Nationality != after.Nationality},
+		{"father_name", before.FatherName != after.FatherName},
+		{"mother_name", before.MotherName != after.MotherName},
+		{"contact_number", before.ContactNumber != after.ContactNumber},
+		{"home_contact_number", before.HomeContactNumber != after.HomeContactNumber},
+		{"emergency_contact_number", before.EmergencyContactNumber != after.EmergencyContactNumber},
+		{"father_occupation", before.FatherOccupation != after.FatherOccupation},
+		{"mother_occupation", before.MotherOccupation != after.MotherOccupation},
+		{"family_income", before.FamilyIncome != after.FamilyIncome},
+	}
+
+	var changed []string
+
+	for _, f := range fields {
+		if f.changed {
+			changed = append(changed, f.name)
+		}
+	}
+
+	return changed
+}
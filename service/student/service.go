@@ -2,218 +2,479 @@ package student
 
 import (
 	"context"
-	"errors"
+	"database/sql"
+	stdErrors "errors"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"student-management-system/config"
+	"student-management-system/errors"
 	"student-management-system/models"
 	"student-management-system/store"
+
+	"github.com/nyaruka/phonenumbers"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+
+	entity = "student"
 )
 
 type service struct {
-	student store.Student
+	student   store.Student
+	audit     store.Audit
+	tx        store.Transactor
+	maxLength map[string]int
+	minAge    int
+	maxAge    int
+	region    string
+	now       func() time.Time
 }
 
-func New(s store.Student) service {
-	return service{student: s}
+func New(s store.Student, a store.Audit, t store.Transactor, v config.Validation) service {
+	return service{student: s, audit: a, tx: t, maxLength: v.MaxLength, minAge: v.MinAge, maxAge: v.MaxAge, region: v.PhoneRegion,
+		now: time.Now}
 }
 
 func (s service) Post(ctx context.Context, student *models.Student) (models.Student, error) {
-	if err := isValidate(student); err != nil {
-		return models.Student{}, err
-	}
+	normalise(student)
+	s.formatPhones(student)
 
-	students, err := s.student.Get(ctx)
-	if err != nil {
+	if err := s.isValidate(student, true); err != nil {
 		return models.Student{}, err
 	}
 
-	for i := range students {
-		if isDuplicate(&students[i], student) {
-			return models.Student{}, errors.New("student already exists")
-		}
+	res, err := s.write(ctx, models.ActionCreate, &models.Student{}, func(ctx context.Context) (models.Student, error) {
+		return s.student.Post(ctx, student)
+	})
+	if err != nil {
+		return models.Student{}, duplicateErr(err)
 	}
 
-	return s.student.Post(ctx, student)
+	return res, nil
 }
 
 func (s service) Put(ctx context.Context, id int, student *models.Student) (models.Student, error) {
-	if err := isValidate(student); err != nil {
+	normalise(student)
+	s.formatPhones(student)
+
+	if err := s.isValidate(student, false); err != nil {
 		return models.Student{}, err
 	}
 
-	_, err := s.student.GetByID(ctx, id)
+	current, err := s.GetByID(ctx, id)
 	if err != nil {
 		return models.Student{}, err
 	}
 
-	return s.student.Put(ctx, id, student)
-}
+	if err := checkVersion(id, student.Version, current.Version); err != nil {
+		return models.Student{}, err
+	}
 
-func (s service) Get(ctx context.Context, firstName, lastName string) ([]models.Student, error) {
-	if firstName != "" && lastName != "" {
-		students, err := s.student.GetByFirstAndLastName(ctx, firstName, lastName)
-		if err != nil {
-			return nil, errors.New("no rows present in database with this query params")
+	if student.Dob != current.Dob {
+		if err := s.isValidate(student, true); err != nil {
+			return models.Student{}, err
 		}
+	}
 
-		return students, nil
+	student.Version = current.Version
+
+	res, err := s.write(ctx, models.ActionUpdate, &current, func(ctx context.Context) (models.Student, error) {
+		return s.student.Put(ctx, id, student)
+	})
+	if err != nil {
+		return models.Student{}, writeErr(id, err)
 	}
 
-	if firstName != "" {
-		students, err := s.student.GetByFirstName(ctx, firstName)
-		if err != nil {
-			return nil, errors.New("no rows present in database with this query param")
-		}
+	return res, nil
+}
 
-		return students, nil
+func (s service) Get(ctx context.Context, filter *models.Filter) (models.StudentList, error) {
+	if filter.Limit == 0 {
+		filter.Limit = defaultLimit
 	}
 
-	if lastName != "" {
-		students, err := s.student.GetByLastName(ctx, lastName)
-		if err != nil {
-			return nil, errors.New("no rows present in database with this query param")
-		}
+	if err := s.checkFilter(filter); err != nil {
+		return models.StudentList{}, err
+	}
+
+	total, err := s.student.Count(ctx, filter)
+	if err != nil {
+		return models.StudentList{}, errors.Internal{Err: err}
+	}
+
+	students, err := s.student.Get(ctx, filter)
+	if err != nil {
+		return models.StudentList{}, errors.Internal{Err: err}
+	}
+
+	page := models.Page{Total: total, Limit: filter.Limit, Offset: filter.Offset}
+
+	if next := filter.Offset + len(students); next < total {
+		page.NextOffset = &next
+	}
+
+	return models.StudentList{Data: students, Meta: page}, nil
+}
 
-		return students, nil
+// Export calls fn with every student matching the filter, as they are read from the store. The filter is
+// checked like a list query but not paged. An error of fn stops the export and is returned as it is.
+func (s service) Export(ctx context.Context, filter *models.Filter, fn func(student models.Student) error) error {
+	filter.Limit, filter.Offset = 0, 0
+
+	if err := s.checkFilter(filter); err != nil {
+		return err
+	}
+
+	var fnErr error
+
+	err := s.student.Each(ctx, filter, func(student models.Student) error {
+		fnErr = fn(student)
+
+		return fnErr
+	})
+
+	switch {
+	case fnErr != nil:
+		return fnErr
+	case err != nil:
+		return errors.Internal{Err: err}
 	}
 
-	return nil, errors.New("invalid query params")
+	return nil
 }
 
 func (s service) GetByID(ctx context.Context, id int) (models.Student, error) {
 	student, err := s.student.GetByID(ctx, id)
+	if err == sql.ErrNoRows {
+		return models.Student{}, errors.EntityNotFound{Entity: entity, ID: strconv.Itoa(id)}
+	}
+
 	if err != nil {
-		return models.Student{}, err
+		return models.Student{}, errors.Internal{Err: err}
 	}
 
 	return student, nil
 }
 
-func (s service) Delete(ctx context.Context, id int) error {
-	_, err := s.student.GetByID(ctx, id)
+func (s service) Delete(ctx context.Context, id, version int) error {
+	current, err := s.GetByID(ctx, id)
 	if err != nil {
-		return errors.New("no sql rows present in db result set")
+		return err
+	}
+
+	if err := checkVersion(id, version, current.Version); err != nil {
+		return err
 	}
 
-	return s.student.Delete(ctx, id)
+	_, err = s.write(ctx, models.ActionDelete, nil, func(ctx context.Context) (models.Student, error) {
+		if err := s.student.Delete(ctx, id, current.Version); err != nil {
+			return models.Student{}, err
+		}
+
+		current.Version++
+
+		return current, nil
+	})
+	if err != nil {
+		return writeErr(id, err)
+	}
+
+	return nil
 }
 
-func isDuplicate(s1, s2 *models.Student) bool {
-	return s1.FirstName == s2.FirstName && s1.LastName == s2.LastName && s1.Gender == s2.Gender && s1.Dob ==
-		s2.Dob && s1.MotherTongue == s2.MotherTongue && s1.Nationality == s2.Nationality && s1.FatherName ==
-		s2.FatherName && s1.MotherName == s2.MotherName && s1.ContactNumber == s2.ContactNumber && s1.FatherOccupation ==
-		s2.FatherOccupation && s1.MotherOccupation == s2.MotherOccupation && s1.FamilyIncome == s2.FamilyIncome
+// Restore takes a deleted student out of the trash.
+func (s service) Restore(ctx context.Context, id int) (models.Student, error) {
+	res, err := s.write(ctx, models.ActionRestore, nil, func(ctx context.Context) (models.Student, error) {
+		if err := s.student.Restore(ctx, id); err != nil {
+			return models.Student{}, err
+		}
+
+		return s.student.GetByID(ctx, id)
+	})
+	if err == sql.ErrNoRows {
+		return models.Student{}, errors.EntityNotFound{Entity: "deleted " + entity, ID: strconv.Itoa(id)}
+	}
+
+	if err != nil {
+		return models.Student{}, duplicateErr(err)
+	}
+
+	return res, nil
 }
 
-func isValidate(student *models.Student) error {
-	switch {
-	case !checkMandatoryFields(student.FirstName):
-		return errors.New("invalid first name")
-	case student.LastName != "" && !checkOptionalFields(student.LastName):
-		return errors.New("invalid last name")
-	case student.Gender != "" && !checkGender(models.Gender(student.Gender)):
-		return errors.New("invalid gender")
-	case student.Dob != "" && !checkDob(student.Dob):
-		return errors.New("invalid dob")
-	case student.MotherTongue != "" && !checkOptionalFields(student.MotherTongue):
-		return errors.New("invalid mother tongue")
-	case !checkMandatoryFields(student.Nationality):
-		return errors.New("invalid nationality")
-	case student.FatherName != "" && !checkOptionalFields(student.FatherName):
-		return errors.New("invalid father name")
-	case student.MotherName != "" && !checkOptionalFields(student.MotherName):
-		return errors.New("invalid mother name")
-	case !checkContactNumber(student.ContactNumber):
-		return errors.New("invalid contact number")
-	case student.FatherOccupation != "" && !checkOptionalFields(student.FatherOccupation):
-		return errors.New("invalid father occupation")
-	case student.MotherOccupation != "" && !checkOptionalFields(student.MotherOccupation):
-		return errors.New("invalid mother occupation")
-	case student.FamilyIncome != 0 && !checkFamilyIncome(student.FamilyIncome):
-		return errors.New("invalid family income")
-	default:
-		return nil
+// Purge permanently deletes the students moved to the trash before the given time.
+func (s service) Purge(ctx context.Context, before time.Time) (int, error) {
+	n, err := s.student.Purge(ctx, before)
+	if err != nil {
+		return n, errors.Internal{Err: err}
 	}
+
+	return n, nil
 }
 
-func checkGender(gender models.Gender) bool {
-	return gender == models.Male || gender == models.Female || gender == models.Other
+// checkVersion fails when the caller based its change on another version than the stored one. A version of 0
+// means the caller did not say, and matches any.
+func checkVersion(id, version, current int) error {
+	if version != 0 && version != current {
+		return errors.PreconditionFailed{Entity: entity, ID: strconv.Itoa(id)}
+	}
+
+	return nil
 }
 
-func checkMandatoryFields(value string) bool {
-	if value == "" {
-		return false
+// writeErr maps the error of a conditional write. sql.ErrNoRows means the student changed or was deleted
+// between reading and writing it.
+func writeErr(id int, err error) error {
+	if err == sql.ErrNoRows {
+		return errors.PreconditionFailed{Entity: entity, ID: strconv.Itoa(id)}
 	}
 
-	for _, v := range value {
-		if !((v >= 65 && v <= 90) || (v >= 97 && v <= 122)) {
-			return false
+	return duplicateErr(err)
+}
+
+// duplicateErr maps the error of a write, naming the student it would have duplicated if there is one.
+func duplicateErr(err error) error {
+	var d store.Duplicate
+	if stdErrors.As(err, &d) {
+		e := errors.EntityAlreadyExists{Entity: entity}
+		if d.ID != 0 {
+			e.ID = strconv.Itoa(d.ID)
 		}
+
+		return e
 	}
 
-	return true
+	return errors.Internal{Err: err}
 }
 
-func checkOptionalFields(value string) bool {
-	for _, v := range value {
-		if !((v >= 65 && v <= 90) || (v >= 97 && v <= 122)) {
-			return false
+// checkFilter validates a list query.
+func (s service) checkFilter(filter *models.Filter) error {
+	if filter.ContactNumber != "" {
+		e164, ok := formatPhone(filter.ContactNumber, s.region)
+		if !ok {
+			return errors.InvalidParam{Field: "contact_number", Reason: "must be a valid phone number"}
 		}
+
+		filter.ContactNumber = e164
+	}
+
+	switch {
+	case filter.Limit < 0 || filter.Limit > maxLimit:
+		return errors.InvalidParam{Field: "limit", Reason: "must be between 1 and " + strconv.Itoa(maxLimit)}
+	case filter.Offset < 0:
+		return errors.InvalidParam{Field: "offset", Reason: "must not be negative"}
+	case filter.Gender != "" && !checkGender(models.Gender(filter.Gender)):
+		return errors.InvalidParam{Field: "gender"}
+	case filter.MinFamilyIncome < 0:
+		return errors.InvalidParam{Field: "min_family_income", Reason: "must not be negative"}
+	case filter.MinFamilyIncome%models.IncomeBand != 0:
+		return errors.InvalidParam{Field: "min_family_income", Reason: "must be a multiple of " + strconv.Itoa(models.IncomeBand)}
+	case filter.MaxFamilyIncome < 0 || filter.MaxFamilyIncome != 0 && filter.MinFamilyIncome > filter.MaxFamilyIncome:
+		return errors.InvalidParam{Field: "max_family_income", Reason: "must not be less than min_family_income"}
+	case filter.MaxFamilyIncome != 0 && (filter.MaxFamilyIncome+1)%models.IncomeBand != 0:
+		return errors.InvalidParam{Field: "max_family_income", Reason: "must be one less than a multiple of " +
+			strconv.Itoa(models.IncomeBand)}
+	case filter.MinFamilyIncome != 0 && filter.MaxFamilyIncome == 0:
+		return errors.InvalidParam{Field: "max_family_income", Reason: "is required with min_family_income"}
+	case (filter.MaxFamilyIncome+1-filter.MinFamilyIncome)/models.IncomeBand > models.MaxIncomeBands:
+		return errors.InvalidParam{Field: "max_family_income", Reason: "must be within " +
+			strconv.Itoa(models.MaxIncomeBands*models.IncomeBand) + " of min_family_income"}
+	case !filter.DobTo.IsZero() && filter.DobTo.Before(filter.DobFrom):
+		return errors.InvalidParam{Field: "dob_to", Reason: "must not be before dob_from"}
 	}
 
-	return true
+	for _, sort := range filter.Sort {
+		if !checkSortField(sort.Field) {
+			return errors.InvalidParam{Field: "sort", Reason: "cannot sort on " + sort.Field}
+		}
+	}
+
+	return nil
 }
 
-func checkDob(dob string) bool {
-	mm, err := strconv.Atoi(strings.Split(dob, "-")[0])
-	if err != nil {
+func checkSortField(field string) bool {
+	switch field {
+	case "id", "first_name", "last_name", "gender", "dob", "mother_tongue", "nationality", "father_occupation",
+		"mother_occupation", "deleted_at":
+		return true
+	default:
 		return false
 	}
+}
 
-	if mm < 1 || mm > 12 {
-		return false
+// isValidate checks every field of student and reports all failures together as an errors.Validation. The age
+// range is only checked for a new dob, of a student being created or changing it, so that a student who has aged
+// out of the range can still be updated.
+func (s service) isValidate(student *models.Student, newDob bool) error {
+	var errs []errors.FieldError
+
+	fail := func(field, rule, message string) {
+		errs = append(errs, errors.FieldError{Field: field, Rule: rule, Message: message})
 	}
 
-	dd, err := strconv.Atoi(strings.Split(dob, "-")[1])
-	if err != nil {
-		return false
+	names := []struct {
+		field     string
+		label     string
+		value     string
+		mandatory bool
+	}{
+		{"first_name", "first name", student.FirstName, true},
+		{"last_name", "last name", student.LastName, false},
+		{"mother_tongue", "mother tongue", student.MotherTongue, false},
+		{"nationality", "nationality", student.Nationality, true},
+		{"father_name", "father name", student.FatherName, false},
+		{"mother_name", "mother name", student.MotherName, false},
+		{"father_occupation", "father occupation", student.FatherOccupation, false},
+		{"mother_occupation", "mother occupation", student.MotherOccupation, false},
 	}
 
-	if dd < 1 || dd > 31 {
-		return false
+	for _, n := range names {
+		max, limited := s.maxLength[n.field]
+
+		switch {
+		case n.mandatory && n.value == "":
+			fail(n.field, "required", n.label+" is required")
+		case n.value != "" && !checkName(n.value):
+			fail(n.field, "name", n.label+" may only contain letters, spaces, hyphens and apostrophes")
+		case limited && utf8.RuneCountInString(n.value) > max:
+			fail(n.field, "max_length", n.label+" must be at most "+strconv.Itoa(max)+" characters")
+		}
 	}
 
-	yyyy, err := strconv.Atoi(strings.Split(dob, "-")[2])
-	if err != nil {
-		return false
+	if student.Gender != "" && !checkGender(models.Gender(student.Gender)) {
+		fail("gender", "one_of", "gender must be one of M, F or O")
 	}
 
-	if yyyy < 1000 || yyyy > 9999 {
-		return false
+	if !student.Dob.IsZero() {
+		today := s.now()
+
+		switch age := ageOn(student.Dob, today); {
+		case models.NewDate(today.Date()).Before(student.Dob):
+			fail("dob", "past", "dob must not be in the future")
+		case newDob && (age < s.minAge || age > s.maxAge):
+			fail("dob", "age_range",
+				"age must be between "+strconv.Itoa(s.minAge)+" and "+strconv.Itoa(s.maxAge)+" years")
+		}
 	}
 
-	if mm == 4 || mm == 6 || mm == 9 || mm == 11 {
-		if dd <= 30 {
-			return true
+	phones := []struct {
+		field     string
+		label     string
+		value     string
+		mandatory bool
+	}{
+		{"contact_number", "contact number", student.ContactNumber, true},
+		{"home_contact_number", "home contact number", student.HomeContactNumber, false},
+		{"emergency_contact_number", "emergency contact number", student.EmergencyContactNumber, false},
+	}
+
+	for _, p := range phones {
+		switch {
+		case p.mandatory && p.value == "":
+			fail(p.field, "required", p.label+" is required")
+		case p.value != "" && !checkPhone(p.value, s.region):
+			fail(p.field, "phone", p.label+" must be a valid phone number")
 		}
+	}
 
-		return false
+	if student.FamilyIncome != 0 && !checkFamilyIncome(student.FamilyIncome) {
+		fail("family_income", "positive", "family income must be positive")
+	}
+
+	if len(errs) > 0 {
+		return errors.Validation{Errors: errs}
+	}
+
+	return nil
+}
+
+func checkGender(gender models.Gender) bool {
+	return gender == models.Male || gender == models.Female || gender == models.Other
+}
+
+// normalise trims the free text fields and brings them to Unicode NFC, so that the same name typed with
+// precomposed or combining characters is stored, compared and measured the same way.
+func normalise(student *models.Student) {
+	for _, f := range []*string{&student.FirstName, &student.LastName, &student.MotherTongue, &student.Nationality,
+		&student.FatherName, &student.MotherName, &student.FatherOccupation, &student.MotherOccupation} {
+		*f = norm.NFC.String(strings.TrimSpace(*f))
+	}
+}
+
+// checkName accepts letters of any script with their combining marks, such as the vowel signs of Devanagari
+// or Tamil, separated by single spaces, hyphens or apostrophes: "José", "O'Brien", "Mary-Jane", "Van der Berg".
+func checkName(value string) bool {
+	separated := true
+
+	for _, r := range value {
+		switch {
+		case unicode.IsLetter(r):
+			separated = false
+		case unicode.Is(unicode.M, r):
+			if separated {
+				return false
+			}
+		case r == ' ' || r == '-' || r == '\'' || r == '\u2019':
+			if separated {
+				return false
+			}
+
+			separated = true
+		default:
+			return false
+		}
+	}
+
+	return !separated
+}
+
+// ageOn returns the age in whole years of someone born on dob, counting a birthday only once it has been
+// reached, so a child born on 29 February turns a year older on 1 March in common years.
+func ageOn(dob models.Date, today time.Time) int {
+	year, month, day := today.Date()
+	age := year - dob.Time().Year()
+
+	if month < dob.Time().Month() || month == dob.Time().Month() && day < dob.Time().Day() {
+		age--
 	}
 
-	return true
+	return age
 }
 
 func checkFamilyIncome(familyIncome int) bool {
 	return familyIncome > 0
 }
 
-func checkContactNumber(contactNumber int) bool {
-	contactNum := strconv.Itoa(contactNumber)
+// formatPhones rewrites every valid contact number in E.164 form, e.g. "+919876543210", so that numbers are
+// stored and compared the same way however they were typed. Invalid numbers are left for isValidate to report.
+func (s service) formatPhones(student *models.Student) {
+	for _, f := range []*string{&student.ContactNumber, &student.HomeContactNumber, &student.EmergencyContactNumber} {
+		if e164, ok := formatPhone(*f, s.region); ok {
+			*f = e164
+		}
+	}
+}
 
-	if len(contactNum) != 10 {
-		return false
+// formatPhone parses a phone number, in international form or as a national number of region, and returns it
+// in E.164 form if it is a valid number for its country.
+func formatPhone(value, region string) (string, bool) {
+	number, err := phonenumbers.Parse(strings.TrimSpace(value), region)
+	if err != nil || !phonenumbers.IsValidNumber(number) {
+		return "", false
 	}
 
-	return true
+	return phonenumbers.Format(number, phonenumbers.E164), true
+}
+
+func checkPhone(value, region string) bool {
+	_, ok := formatPhone(value, region)
+
+	return ok
 }
@@ -2,11 +2,19 @@ package student
 
 import (
 	"context"
-	"errors"
+	"database/sql"
+	"encoding/json"
+	stdErrors "errors"
 	"reflect"
 	"testing"
+	"time"
 
+	"student-management-system/config"
+	"student-management-system/errors"
 	"student-management-system/models"
+	"student-management-system/policy"
+	"student-management-system/requestctx"
+	svc "student-management-system/service"
 	"student-management-system/store"
 
 	"github.com/golang/mock/gomock"
@@ -17,26 +25,28 @@ func TestPost(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockStore := store.NewMockStudent(ctrl)
-	mock := New(mockStore)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
+
+	mockAudit.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 
 	testcases := []struct {
-		desc      string
-		reqData   models.Student
-		expRes    models.Student
-		expGetRes []models.Student
-		expGetErr error
-		expErr    error
+		desc        string
+		reqData     models.Student
+		expRes      models.Student
+		expStoreErr error
+		expErr      error
 	}{
 		{desc: "success:valid details posted successfully", reqData: models.Student{
 			FirstName:        "arvind",
 			LastName:         "yadav",
 			Gender:           "M",
-			Dob:              "09-10-2000",
+			Dob:              models.NewDate(2000, time.September, 10),
 			MotherTongue:     "Hindi",
 			Nationality:      "indian",
 			FatherName:       "Kailash",
 			MotherName:       "Indrawati",
-			ContactNumber:    7348761063,
+			ContactNumber:    "+917348761063",
 			FatherOccupation: "agriculture",
 			MotherOccupation: "housewife",
 		}, expRes: models.Student{
@@ -44,12 +54,12 @@ func TestPost(t *testing.T) {
 			FirstName:        "arvind",
 			LastName:         "yadav",
 			Gender:           "M",
-			Dob:              "09-10-2000",
+			Dob:              models.NewDate(2000, time.September, 10),
 			MotherTongue:     "Hindi",
 			Nationality:      "indian",
 			FatherName:       "Kailash",
 			MotherName:       "Indrawati",
-			ContactNumber:    7348761063,
+			ContactNumber:    "+917348761063",
 			FatherOccupation: "agriculture",
 			MotherOccupation: "housewife",
 		}},
@@ -57,21 +67,21 @@ func TestPost(t *testing.T) {
 			FirstName:        "arvind",
 			LastName:         "yadav",
 			Gender:           "M",
-			Dob:              "10-10-2000",
+			Dob:              models.NewDate(2000, time.October, 10),
 			MotherTongue:     "Hindi",
 			Nationality:      "indian",
 			FatherName:       "Kailash",
 			MotherName:       "Indrawati",
-			ContactNumber:    7348761063,
+			ContactNumber:    "+917348761063",
 			FatherOccupation: "agriculture",
 			MotherOccupation: "housewife",
-		}, expRes: models.Student{}, expErr: errors.New("query error")},
+		}, expRes: models.Student{}, expStoreErr: stdErrors.New("query error"),
+			expErr: errors.Internal{Err: stdErrors.New("query error")}},
 	}
 
 	for i, tc := range testcases {
 		ctx := context.Background()
-		mockStore.EXPECT().Get(ctx).Return(tc.expGetRes, tc.expGetErr)
-		mockStore.EXPECT().Post(ctx, &tc.reqData).Return(tc.expRes, tc.expErr)
+		mockStore.EXPECT().Post(ctx, &tc.reqData).Return(tc.expRes, tc.expStoreErr)
 
 		res, err := mock.Post(ctx, &tc.reqData)
 
@@ -85,71 +95,38 @@ func TestPost(t *testing.T) {
 	}
 }
 
-func TestPost_GetErr(t *testing.T) {
+func TestPost_Duplicate(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockStore := store.NewMockStudent(ctrl)
-	mock := New(mockStore)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
+
+	student := models.Student{
+		FirstName:     "arvind",
+		LastName:      "yadav",
+		Nationality:   "indian",
+		ContactNumber: "+917348761063",
+	}
 
 	testcases := []struct {
-		desc      string
-		reqData   models.Student
-		expGetRes []models.Student
-		expRes    models.Student
-		expGetErr error
-		expErr    error
+		desc        string
+		expStoreErr error
+		expErr      error
 	}{
-		{desc: "failure:student details already exists", reqData: models.Student{
-			FirstName:        "arvind",
-			LastName:         "yadav",
-			Gender:           "M",
-			Dob:              "10-10-2000",
-			MotherTongue:     "Hindi",
-			Nationality:      "indian",
-			FatherName:       "Kailash",
-			MotherName:       "Indrawati",
-			ContactNumber:    7348761063,
-			FatherOccupation: "agriculture",
-			MotherOccupation: "housewife",
-		}, expGetRes: []models.Student{{
-			ID:               1,
-			FirstName:        "arvind",
-			LastName:         "yadav",
-			Gender:           "M",
-			Dob:              "10-10-2000",
-			MotherTongue:     "Hindi",
-			Nationality:      "indian",
-			FatherName:       "Kailash",
-			MotherName:       "Indrawati",
-			ContactNumber:    7348761063,
-			FatherOccupation: "agriculture",
-			MotherOccupation: "housewife",
-		}}, expErr: errors.New("student already exists")},
-		{desc: "failure:get method will return error", reqData: models.Student{
-			FirstName:        "arvind",
-			LastName:         "yadav",
-			Gender:           "M",
-			Dob:              "10-10-2000",
-			MotherTongue:     "Hindi",
-			Nationality:      "indian",
-			FatherName:       "Kailash",
-			MotherName:       "Indrawati",
-			ContactNumber:    7348761063,
-			FatherOccupation: "agriculture",
-			MotherOccupation: "housewife",
-		}, expGetErr: errors.New("query error"), expErr: errors.New("query error")},
+		{desc: "failure:student details already exists", expStoreErr: store.Duplicate{ID: 1},
+			expErr: errors.EntityAlreadyExists{Entity: "student", ID: "1"}},
+		{desc: "failure:duplicate deleted meanwhile", expStoreErr: store.Duplicate{},
+			expErr: errors.EntityAlreadyExists{Entity: "student"}},
 	}
 
 	for i, tc := range testcases {
 		ctx := context.Background()
-		mockStore.EXPECT().Get(ctx).Return(tc.expGetRes, tc.expGetErr)
-
-		res, err := mock.Post(ctx, &tc.reqData)
+		reqData := student
+		mockStore.EXPECT().Post(ctx, &reqData).Return(models.Student{}, tc.expStoreErr)
 
-		if !reflect.DeepEqual(tc.expRes, res) {
-			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes, res)
-		}
+		_, err := mock.Post(ctx, &reqData)
 
 		if !reflect.DeepEqual(tc.expErr, err) {
 			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
@@ -162,7 +139,9 @@ func TestPost_BodyCheckErr1(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockStore := store.NewMockStudent(ctrl)
-	mock := New(mockStore)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
+	mock.now = today
 
 	testcases := []struct {
 		desc    string
@@ -173,61 +152,37 @@ func TestPost_BodyCheckErr1(t *testing.T) {
 		{desc: "failure:invalid first name", reqData: models.Student{
 			FirstName:     "a12",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid first name")},
+			ContactNumber: "+917348761063",
+		}, expErr: validationErr("first_name", "name", "first name may only contain letters, spaces, hyphens and apostrophes")},
 		{desc: "failure:invalid first name", reqData: models.Student{
 			FirstName:     "",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid first name")},
+			ContactNumber: "+917348761063",
+		}, expErr: validationErr("first_name", "required", "first name is required")},
 		{desc: "failure:invalid last name", reqData: models.Student{
 			FirstName:     "arvind",
 			LastName:      "ya12",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid last name")},
-		{desc: "failure:invalid dob", reqData: models.Student{
-			FirstName:     "arvind",
-			Dob:           "04-31-2008",
-			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid dob")},
-		{desc: "failure:invalid dob ..strconv error", reqData: models.Student{
-			FirstName:     "arvind",
-			Dob:           "ab-31-2008",
-			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid dob")},
-		{desc: "failure:invalid dob ..strconv error", reqData: models.Student{
-			FirstName:     "arvind",
-			Dob:           "04-ab-2008",
-			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid dob")},
-		{desc: "failure:invalid dob ..strconv error", reqData: models.Student{
-			FirstName:     "arvind",
-			Dob:           "04-30-abc",
-			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid dob")},
-		{desc: "failure:invalid dob.. month is not correct", reqData: models.Student{
+			ContactNumber: "+917348761063",
+		}, expErr: validationErr("last_name", "name", "last name may only contain letters, spaces, hyphens and apostrophes")},
+		{desc: "failure:future dob", reqData: models.Student{
 			FirstName:     "arvind",
-			Dob:           "13-30-2000",
+			Dob:           models.NewDate(2024, time.March, 2),
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid dob")},
-		{desc: "failure:invalid dob.. date is not correct", reqData: models.Student{
+			ContactNumber: "+917348761063",
+		}, expErr: validationErr("dob", "past", "dob must not be in the future")},
+		{desc: "failure:too young", reqData: models.Student{
 			FirstName:     "arvind",
-			Dob:           "12-32-2000",
+			Dob:           models.NewDate(2021, time.March, 2),
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid dob")},
-		{desc: "failure:invalid dob.. year is not correct", reqData: models.Student{
+			ContactNumber: "+917348761063",
+		}, expErr: validationErr("dob", "age_range", "age must be between 3 and 60 years")},
+		{desc: "failure:too old", reqData: models.Student{
 			FirstName:     "arvind",
-			Dob:           "3-24-999",
+			Dob:           models.NewDate(1960, time.January, 1),
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid dob")},
+			ContactNumber: "+917348761063",
+		}, expErr: validationErr("dob", "age_range", "age must be between 3 and 60 years")},
 	}
 
 	for i, tc := range testcases {
@@ -249,7 +204,8 @@ func TestPost_BodyCheckErr2(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockStore := store.NewMockStudent(ctrl)
-	mock := New(mockStore)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
 
 	testcases := []struct {
 		desc    string
@@ -261,59 +217,59 @@ func TestPost_BodyCheckErr2(t *testing.T) {
 			FirstName:     "arvind",
 			Gender:        "K",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid gender")},
+			ContactNumber: "+917348761063",
+		}, expErr: validationErr("gender", "one_of", "gender must be one of M, F or O")},
 		{desc: "failure:invalid mother tongue", reqData: models.Student{
 			FirstName:     "arvind",
 			MotherTongue:  "a12",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid mother tongue")},
+			ContactNumber: "+917348761063",
+		}, expErr: validationErr("mother_tongue", "name", "mother tongue may only contain letters, spaces, hyphens and apostrophes")},
 		{desc: "failure:invalid nationality", reqData: models.Student{
 			FirstName:     "arvind",
 			Nationality:   "India123",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid nationality")},
+			ContactNumber: "+917348761063",
+		}, expErr: validationErr("nationality", "name", "nationality may only contain letters, spaces, hyphens and apostrophes")},
 		{desc: "failure:invalid nationality", reqData: models.Student{
 			FirstName:     "arvind",
 			Nationality:   "",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid nationality")},
+			ContactNumber: "+917348761063",
+		}, expErr: validationErr("nationality", "required", "nationality is required")},
 		{desc: "failure:invalid father name", reqData: models.Student{
 			FirstName:     "arvind",
 			FatherName:    "123",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid father name")},
+			ContactNumber: "+917348761063",
+		}, expErr: validationErr("father_name", "name", "father name may only contain letters, spaces, hyphens and apostrophes")},
 		{desc: "failure:invalid mother name", reqData: models.Student{
 			FirstName:     "arvind",
 			MotherName:    "123",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid mother name")},
+			ContactNumber: "+917348761063",
+		}, expErr: validationErr("mother_name", "name", "mother name may only contain letters, spaces, hyphens and apostrophes")},
 		{desc: "failure:invalid contact number", reqData: models.Student{
 			FirstName:     "arvind",
 			Nationality:   "Indian",
-			ContactNumber: 734876106,
-		}, expErr: errors.New("invalid contact number")},
+			ContactNumber: "0000000000",
+		}, expErr: validationErr("contact_number", "phone", "contact number must be a valid phone number")},
 		{desc: "failure:invalid father occupation", reqData: models.Student{
 			FirstName:        "arvind",
 			FatherOccupation: "a12",
 			Nationality:      "Indian",
-			ContactNumber:    7348761063,
-		}, expErr: errors.New("invalid father occupation")},
+			ContactNumber:    "+917348761063",
+		}, expErr: validationErr("father_occupation", "name", "father occupation may only contain letters, spaces, hyphens and apostrophes")},
 		{desc: "failure:invalid mother occupation", reqData: models.Student{
 			FirstName:        "arvind",
 			MotherOccupation: "a12",
 			Nationality:      "Indian",
-			ContactNumber:    7348761063,
-		}, expErr: errors.New("invalid mother occupation")},
+			ContactNumber:    "+917348761063",
+		}, expErr: validationErr("mother_occupation", "name", "mother occupation may only contain letters, spaces, hyphens and apostrophes")},
 		{desc: "failure:invalid family income", reqData: models.Student{
 			FirstName:     "arvind",
 			FamilyIncome:  -123,
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid family income")},
+			ContactNumber: "+917348761063",
+		}, expErr: validationErr("family_income", "positive", "family income must be positive")},
 	}
 
 	for i, tc := range testcases {
@@ -335,28 +291,33 @@ func TestGetByID(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockStore := store.NewMockStudent(ctrl)
-	mock := New(mockStore)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
 
 	testcases := []struct {
-		desc   string
-		id     int
-		expRes models.Student
-		expErr error
+		desc      string
+		id        int
+		expGetErr error
+		expRes    models.Student
+		expErr    error
 	}{
 		{desc: "success:fetch student details with valid id", id: 1, expRes: models.Student{
 			ID:            1,
 			FirstName:     "arvind",
 			LastName:      "yadav",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
+			ContactNumber: "+917348761063",
 		}},
 
-		{desc: "failure: invalid id not present in result set", id: 1111, expErr: errors.New("no rows in db result set")},
+		{desc: "failure: invalid id not present in result set", id: 1111, expGetErr: sql.ErrNoRows,
+			expErr: errors.EntityNotFound{Entity: "student", ID: "1111"}},
+		{desc: "failure: query error", id: 1, expGetErr: stdErrors.New("query error"),
+			expErr: errors.Internal{Err: stdErrors.New("query error")}},
 	}
 
 	for i, tc := range testcases {
 		ctx := context.Background()
-		mockStore.EXPECT().GetByID(ctx, tc.id).Return(tc.expRes, tc.expErr)
+		mockStore.EXPECT().GetByID(ctx, tc.id).Return(tc.expRes, tc.expGetErr)
 
 		res, err := mock.GetByID(ctx, tc.id)
 
@@ -370,109 +331,56 @@ func TestGetByID(t *testing.T) {
 	}
 }
 
-func TestGet_FirstAndLastName(t *testing.T) {
+func TestGet(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockStore := store.NewMockStudent(ctrl)
-	mock := New(mockStore)
-
-	testcases := []struct {
-		desc      string
-		firstName string
-		lastName  string
-		expRes    []models.Student
-		expErr    error
-	}{
-		{desc: "success:valid Query params firstName and lastName", firstName: "arvind", lastName: "yadav",
-			expRes: []models.Student{
-				{ID: 1, FirstName: "arvind", LastName: "yadav", Nationality: "Indian", ContactNumber: 7348761063},
-			}},
-		{desc: "failure:invalid Query params firstName and lastName", firstName: "123a", lastName: "345a",
-			expErr: errors.New("no rows present in database with this query params")},
-	}
-
-	for i, tc := range testcases {
-		ctx := context.Background()
-		mockStore.EXPECT().GetByFirstAndLastName(ctx, tc.firstName, tc.lastName).Return(tc.expRes, tc.expErr)
-
-		res, err := mock.Get(ctx, tc.firstName, tc.lastName)
-
-		if !reflect.DeepEqual(tc.expRes, res) {
-			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes, res)
-		}
-
-		if !reflect.DeepEqual(tc.expErr, err) {
-			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
-		}
-	}
-}
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
 
-func TestGet_FirstName(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
-
-	mockStore := store.NewMockStudent(ctrl)
-	mock := New(mockStore)
+	next := 1
 
 	testcases := []struct {
-		desc      string
-		firstName string
-		lastName  string
-		expRes    []models.Student
-		expErr    error
+		desc        string
+		filter      models.Filter
+		expFilter   models.Filter
+		expCount    int
+		expCountErr error
+		expGetRes   []models.Student
+		expGetErr   error
+		expRes      models.StudentList
+		expErr      error
 	}{
-		{desc: "success:valid Query params firstName ", firstName: "arvind",
-			expRes: []models.Student{
-				{ID: 1, FirstName: "arvind", Nationality: "Indian", ContactNumber: 7348761063},
+		{desc: "success:default page size with next page", filter: models.Filter{FirstName: "arvind"},
+			expFilter: models.Filter{FirstName: "arvind", Limit: 20}, expCount: 2,
+			expGetRes: []models.Student{{ID: 1, FirstName: "arvind", Nationality: "Indian", ContactNumber: "+917348761063"}},
+			expRes: models.StudentList{
+				Data: []models.Student{{ID: 1, FirstName: "arvind", Nationality: "Indian", ContactNumber: "+917348761063"}},
+				Meta: models.Page{Total: 2, Limit: 20, NextOffset: &next},
+			}},
+		{desc: "success:last page", filter: models.Filter{Limit: 5, Offset: 1, Sort: []models.Sort{{Field: "dob", Desc: true}}},
+			expFilter: models.Filter{Limit: 5, Offset: 1, Sort: []models.Sort{{Field: "dob", Desc: true}}}, expCount: 2,
+			expGetRes: []models.Student{{ID: 2, FirstName: "anuj", Nationality: "Indian", ContactNumber: "+917348761064"}},
+			expRes: models.StudentList{
+				Data: []models.Student{{ID: 2, FirstName: "anuj", Nationality: "Indian", ContactNumber: "+917348761064"}},
+				Meta: models.Page{Total: 2, Limit: 5, Offset: 1},
 			}},
-		{desc: "failure:invalid Query param firstName ", firstName: "123a",
-			expErr: errors.New("no rows present in database with this query param")},
+		{desc: "failure:count error", filter: models.Filter{Limit: 5}, expFilter: models.Filter{Limit: 5},
+			expCountErr: stdErrors.New("query error"), expErr: errors.Internal{Err: stdErrors.New("query error")}},
+		{desc: "failure:get error", filter: models.Filter{Limit: 5}, expFilter: models.Filter{Limit: 5}, expCount: 2,
+			expGetErr: stdErrors.New("query error"), expErr: errors.Internal{Err: stdErrors.New("query error")}},
 	}
 
 	for i, tc := range testcases {
 		ctx := context.Background()
-		mockStore.EXPECT().GetByFirstName(ctx, tc.firstName).Return(tc.expRes, tc.expErr)
+		mockStore.EXPECT().Count(ctx, &tc.expFilter).Return(tc.expCount, tc.expCountErr)
 
-		res, err := mock.Get(ctx, tc.firstName, tc.lastName)
-
-		if !reflect.DeepEqual(tc.expRes, res) {
-			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes, res)
-		}
-
-		if !reflect.DeepEqual(tc.expErr, err) {
-			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		if tc.expCountErr == nil {
+			mockStore.EXPECT().Get(ctx, &tc.expFilter).Return(tc.expGetRes, tc.expGetErr)
 		}
-	}
-}
-
-func TestGet_LastName(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
-
-	mockStore := store.NewMockStudent(ctrl)
-	mock := New(mockStore)
-
-	testcases := []struct {
-		desc      string
-		firstName string
-		lastName  string
-		expRes    []models.Student
-		expErr    error
-	}{
-		{desc: "success:valid Query param  lastName", lastName: "yadav",
-			expRes: []models.Student{
-				{ID: 1, FirstName: "arvind", LastName: "yadav", Nationality: "Indian", ContactNumber: 7348761063},
-			}},
-		{desc: "failure:invalid Query param lastName ", lastName: "123a",
-			expErr: errors.New("no rows present in database with this query param")},
-	}
-
-	for i, tc := range testcases {
-		ctx := context.Background()
-		mockStore.EXPECT().GetByLastName(ctx, tc.lastName).Return(tc.expRes, tc.expErr)
 
-		res, err := mock.Get(ctx, tc.firstName, tc.lastName)
+		res, err := mock.Get(ctx, &tc.filter)
 
 		if !reflect.DeepEqual(tc.expRes, res) {
 			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes, res)
@@ -484,30 +392,47 @@ func TestGet_LastName(t *testing.T) {
 	}
 }
 
-func TestGet_MissingQueryParams(t *testing.T) {
+func TestGet_InvalidFilter(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockStore := store.NewMockStudent(ctrl)
-	mock := New(mockStore)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
 
 	testcases := []struct {
-		desc      string
-		firstName string
-		lastName  string
-		expRes    []models.Student
-		expErr    error
+		desc   string
+		filter models.Filter
+		expErr error
 	}{
-		{desc: "failure:missing Query param firstName and lastName ",
-			expErr: errors.New("invalid query params")},
+		{desc: "failure:limit too large", filter: models.Filter{Limit: 1000}, expErr: errors.InvalidParam{Field: "limit", Reason: "must be between 1 and 100"}},
+		{desc: "failure:negative offset", filter: models.Filter{Offset: -1}, expErr: errors.InvalidParam{Field: "offset", Reason: "must not be negative"}},
+		{desc: "failure:invalid gender", filter: models.Filter{Gender: "K"}, expErr: errors.InvalidParam{Field: "gender"}},
+		{desc: "failure:inverted income range", filter: models.Filter{MinFamilyIncome: 300000, MaxFamilyIncome: 199999},
+			expErr: errors.InvalidParam{Field: "max_family_income", Reason: "must not be less than min_family_income"}},
+		{desc: "failure:income range within a band", filter: models.Filter{MinFamilyIncome: 150000, MaxFamilyIncome: 299999},
+			expErr: errors.InvalidParam{Field: "min_family_income", Reason: "must be a multiple of 100000"}},
+		{desc: "failure:income range ending within a band", filter: models.Filter{MaxFamilyIncome: 150000},
+			expErr: errors.InvalidParam{Field: "max_family_income", Reason: "must be one less than a multiple of 100000"}},
+		{desc: "failure:open income range", filter: models.Filter{MinFamilyIncome: 100000},
+			expErr: errors.InvalidParam{Field: "max_family_income", Reason: "is required with min_family_income"}},
+		{desc: "failure:income range too wide", filter: models.Filter{MinFamilyIncome: 100000, MaxFamilyIncome: 10199999},
+			expErr: errors.InvalidParam{Field: "max_family_income", Reason: "must be within 10000000 of min_family_income"}},
+		{desc: "failure:inverted dob range", filter: models.Filter{DobFrom: models.NewDate(2005, time.January, 1),
+			DobTo: models.NewDate(2000, time.January, 1)}, expErr: errors.InvalidParam{Field: "dob_to", Reason: "must not be before dob_from"}},
+		{desc: "failure:invalid contact number", filter: models.Filter{ContactNumber: "12345"},
+			expErr: errors.InvalidParam{Field: "contact_number", Reason: "must be a valid phone number"}},
+		{desc: "failure:unknown sort field", filter: models.Filter{Sort: []models.Sort{{Field: "password"}}},
+			expErr: errors.InvalidParam{Field: "sort", Reason: "cannot sort on password"}},
+		{desc: "failure:encrypted sort field", filter: models.Filter{Sort: []models.Sort{{Field: "contact_number"}}},
+			expErr: errors.InvalidParam{Field: "sort", Reason: "cannot sort on contact_number"}},
 	}
 
 	for i, tc := range testcases {
-		ctx := context.Background()
-		res, err := mock.Get(ctx, tc.firstName, tc.lastName)
+		res, err := mock.Get(context.Background(), &tc.filter)
 
-		if !reflect.DeepEqual(tc.expRes, res) {
-			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes, res)
+		if !reflect.DeepEqual(models.StudentList{}, res) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, models.StudentList{}, res)
 		}
 
 		if !reflect.DeepEqual(tc.expErr, err) {
@@ -521,30 +446,35 @@ func TestDelete(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockStore := store.NewMockStudent(ctrl)
-	mock := New(mockStore)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
+
+	mockAudit.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 
 	testcases := []struct {
-		desc      string
-		id        int
-		expGetRes models.Student
-		expGetErr error
-		expErr    error
+		desc        string
+		id          int
+		expGetRes   models.Student
+		expGetErr   error
+		expStoreErr error
+		expErr      error
 	}{
 		{desc: "success:deleted successfully", id: 1, expGetRes: models.Student{
-			ID: 1, FirstName: "arvind", Nationality: "Indian", ContactNumber: 7348761063,
+			ID: 1, FirstName: "arvind", Nationality: "Indian", ContactNumber: "+917348761063",
 		}},
 		{desc: "failure:query error", id: 2, expGetRes: models.Student{
-			ID: 2, FirstName: "arvind", Nationality: "Indian", ContactNumber: 7348761063,
-		}, expErr: errors.New("query error")},
+			ID: 2, FirstName: "arvind", Nationality: "Indian", ContactNumber: "+917348761063",
+		}, expStoreErr: stdErrors.New("query error"),
+			expErr: errors.Internal{Err: stdErrors.New("query error")}},
 	}
 
 	for i, tc := range testcases {
 		ctx := context.Background()
 
 		mockStore.EXPECT().GetByID(ctx, tc.id).Return(tc.expGetRes, tc.expGetErr)
-		mockStore.EXPECT().Delete(ctx, tc.id).Return(tc.expErr)
+		mockStore.EXPECT().Delete(ctx, tc.id, 0).Return(tc.expStoreErr)
 
-		err := mock.Delete(ctx, tc.id)
+		err := mock.Delete(ctx, tc.id, 0)
 
 		if !reflect.DeepEqual(tc.expErr, err) {
 			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
@@ -557,7 +487,8 @@ func TestDelete_GetErr(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockStore := store.NewMockStudent(ctrl)
-	mock := New(mockStore)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
 
 	testcases := []struct {
 		desc      string
@@ -567,8 +498,7 @@ func TestDelete_GetErr(t *testing.T) {
 		expErr    error
 	}{
 		{desc: "failure:id not present in db result set", id: 1111,
-			expGetErr: errors.New("id is not present in db result set"),
-			expErr:    errors.New("no sql rows present in db result set")},
+			expGetErr: sql.ErrNoRows, expErr: errors.EntityNotFound{Entity: "student", ID: "1111"}},
 	}
 
 	for i, tc := range testcases {
@@ -576,7 +506,7 @@ func TestDelete_GetErr(t *testing.T) {
 
 		mockStore.EXPECT().GetByID(ctx, tc.id).Return(tc.expGetRes, tc.expGetErr)
 
-		err := mock.Delete(ctx, tc.id)
+		err := mock.Delete(ctx, tc.id, 0)
 
 		if !reflect.DeepEqual(tc.expErr, err) {
 			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
@@ -589,7 +519,10 @@ func TestPut(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockStore := store.NewMockStudent(ctrl)
-	mock := New(mockStore)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
+
+	mockAudit.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 
 	testcases := []struct {
 		desc          string
@@ -598,18 +531,19 @@ func TestPut(t *testing.T) {
 		expRes        models.Student
 		expGetByIDRes models.Student
 		expGetByIDErr error
+		expStoreErr   error
 		expErr        error
 	}{
 		{desc: "success:valid details updated successfully", id: 1, reqData: models.Student{
 			FirstName:        "arvind",
 			LastName:         "yadav",
 			Gender:           "M",
-			Dob:              "09-10-2000",
+			Dob:              models.NewDate(2000, time.September, 10),
 			MotherTongue:     "Hindi",
 			Nationality:      "indian",
 			FatherName:       "Kailash",
 			MotherName:       "Indrawati",
-			ContactNumber:    7348761063,
+			ContactNumber:    "+917348761063",
 			FatherOccupation: "agriculture",
 			MotherOccupation: "housewife",
 		}, expRes: models.Student{
@@ -617,24 +551,24 @@ func TestPut(t *testing.T) {
 			FirstName:        "arvind",
 			LastName:         "yadav",
 			Gender:           "M",
-			Dob:              "09-10-2000",
+			Dob:              models.NewDate(2000, time.September, 10),
 			MotherTongue:     "Hindi",
 			Nationality:      "indian",
 			FatherName:       "Kailash",
 			MotherName:       "Indrawati",
-			ContactNumber:    7348761063,
+			ContactNumber:    "+917348761063",
 			FatherOccupation: "agriculture",
 			MotherOccupation: "housewife",
 		}, expGetByIDRes: models.Student{
 			FirstName:        "anuj",
 			LastName:         "yadav",
 			Gender:           "M",
-			Dob:              "09-10-2000",
+			Dob:              models.NewDate(2000, time.September, 10),
 			MotherTongue:     "Hindi",
 			Nationality:      "indian",
 			FatherName:       "Kailash",
 			MotherName:       "Indrawati",
-			ContactNumber:    7348761063,
+			ContactNumber:    "+917348761063",
 			FatherOccupation: "agriculture",
 			MotherOccupation: "housewife",
 		}},
@@ -642,33 +576,34 @@ func TestPut(t *testing.T) {
 			FirstName:        "arvind",
 			LastName:         "yadav",
 			Gender:           "M",
-			Dob:              "10-10-2000",
+			Dob:              models.NewDate(2000, time.October, 10),
 			MotherTongue:     "Hindi",
 			Nationality:      "indian",
 			FatherName:       "Kailash",
 			MotherName:       "Indrawati",
-			ContactNumber:    7348761063,
+			ContactNumber:    "+917348761063",
 			FatherOccupation: "agriculture",
 			MotherOccupation: "housewife",
 		}, expGetByIDRes: models.Student{
 			FirstName:        "arvind",
 			LastName:         "yadav",
 			Gender:           "M",
-			Dob:              "09-10-2000",
+			Dob:              models.NewDate(2000, time.September, 10),
 			MotherTongue:     "Hindi",
 			Nationality:      "indian",
 			FatherName:       "Kailash",
 			MotherName:       "Indrawati",
-			ContactNumber:    7348761063,
+			ContactNumber:    "+917348761063",
 			FatherOccupation: "agriculture",
 			MotherOccupation: "housewife",
-		}, expErr: errors.New("query error")},
+		}, expStoreErr: stdErrors.New("query error"),
+			expErr: errors.Internal{Err: stdErrors.New("query error")}},
 	}
 
 	for i, tc := range testcases {
 		ctx := context.Background()
 		mockStore.EXPECT().GetByID(ctx, tc.id).Return(tc.expGetByIDRes, tc.expGetByIDErr)
-		mockStore.EXPECT().Put(ctx, tc.id, &tc.reqData).Return(tc.expRes, tc.expErr)
+		mockStore.EXPECT().Put(ctx, tc.id, &tc.reqData).Return(tc.expRes, tc.expStoreErr)
 
 		res, err := mock.Put(ctx, tc.id, &tc.reqData)
 
@@ -687,7 +622,8 @@ func TestPut_GetByIDErr(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockStore := store.NewMockStudent(ctrl)
-	mock := New(mockStore)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
 
 	testcases := []struct {
 		desc          string
@@ -702,15 +638,15 @@ func TestPut_GetByIDErr(t *testing.T) {
 			FirstName:        "arvind",
 			LastName:         "yadav",
 			Gender:           "M",
-			Dob:              "10-10-2000",
+			Dob:              models.NewDate(2000, time.October, 10),
 			MotherTongue:     "Hindi",
 			Nationality:      "indian",
 			FatherName:       "Kailash",
 			MotherName:       "Indrawati",
-			ContactNumber:    7348761063,
+			ContactNumber:    "+917348761063",
 			FatherOccupation: "agriculture",
 			MotherOccupation: "housewife",
-		}, expGetByIDErr: errors.New("query error"), expErr: errors.New("query error")},
+		}, expGetByIDErr: sql.ErrNoRows, expErr: errors.EntityNotFound{Entity: "student", ID: "1111"}},
 	}
 
 	for i, tc := range testcases {
@@ -734,7 +670,9 @@ func TestPut_BodyCheckErr1(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockStore := store.NewMockStudent(ctrl)
-	mock := New(mockStore)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
+	mock.now = today
 
 	testcases := []struct {
 		desc    string
@@ -746,61 +684,25 @@ func TestPut_BodyCheckErr1(t *testing.T) {
 		{desc: "failure:invalid first name", id: 1, reqData: models.Student{
 			FirstName:     "a12",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid first name")},
+			ContactNumber: "+917348761063",
+		}, expErr: validationErr("first_name", "name", "first name may only contain letters, spaces, hyphens and apostrophes")},
 		{desc: "failure:invalid first name", id: 1, reqData: models.Student{
 			FirstName:     "",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid first name")},
+			ContactNumber: "+917348761063",
+		}, expErr: validationErr("first_name", "required", "first name is required")},
 		{desc: "failure:invalid last name", id: 1, reqData: models.Student{
 			FirstName:     "arvind",
 			LastName:      "ya12",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid last name")},
-		{desc: "failure:invalid dob", id: 1, reqData: models.Student{
+			ContactNumber: "+917348761063",
+		}, expErr: validationErr("last_name", "name", "last name may only contain letters, spaces, hyphens and apostrophes")},
+		{desc: "failure:future dob", id: 1, reqData: models.Student{
 			FirstName:     "arvind",
-			Dob:           "04-31-2008",
+			Dob:           models.NewDate(2024, time.March, 2),
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid dob")},
-		{desc: "failure:invalid dob ..strconv error", id: 1, reqData: models.Student{
-			FirstName:     "arvind",
-			Dob:           "ab-31-2008",
-			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid dob")},
-		{desc: "failure:invalid dob ..strconv error", id: 1, reqData: models.Student{
-			FirstName:     "arvind",
-			Dob:           "04-ab-2008",
-			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid dob")},
-		{desc: "failure:invalid dob ..strconv error", id: 1, reqData: models.Student{
-			FirstName:     "arvind",
-			Dob:           "04-30-abc",
-			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid dob")},
-		{desc: "failure:invalid dob.. month is not correct", id: 1, reqData: models.Student{
-			FirstName:     "arvind",
-			Dob:           "13-30-2000",
-			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid dob")},
-		{desc: "failure:invalid dob.. date is not correct", id: 1, reqData: models.Student{
-			FirstName:     "arvind",
-			Dob:           "12-32-2000",
-			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid dob")},
-		{desc: "failure:invalid dob.. year is not correct", id: 1, reqData: models.Student{
-			FirstName:     "arvind",
-			Dob:           "3-24-999",
-			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid dob")},
+			ContactNumber: "+917348761063",
+		}, expErr: validationErr("dob", "past", "dob must not be in the future")},
 	}
 
 	for i, tc := range testcases {
@@ -822,7 +724,8 @@ func TestPut_BodyCheckErr2(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockStore := store.NewMockStudent(ctrl)
-	mock := New(mockStore)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
 
 	testcases := []struct {
 		desc    string
@@ -835,59 +738,59 @@ func TestPut_BodyCheckErr2(t *testing.T) {
 			FirstName:     "arvind",
 			Gender:        "K",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid gender")},
+			ContactNumber: "+917348761063",
+		}, expErr: validationErr("gender", "one_of", "gender must be one of M, F or O")},
 		{desc: "failure:invalid mother tongue", id: 1, reqData: models.Student{
 			FirstName:     "arvind",
 			MotherTongue:  "a12",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid mother tongue")},
+			ContactNumber: "+917348761063",
+		}, expErr: validationErr("mother_tongue", "name", "mother tongue may only contain letters, spaces, hyphens and apostrophes")},
 		{desc: "failure:invalid nationality", id: 1, reqData: models.Student{
 			FirstName:     "arvind",
 			Nationality:   "India123",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid nationality")},
+			ContactNumber: "+917348761063",
+		}, expErr: validationErr("nationality", "name", "nationality may only contain letters, spaces, hyphens and apostrophes")},
 		{desc: "failure:invalid nationality", id: 1, reqData: models.Student{
 			FirstName:     "arvind",
 			Nationality:   "",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid nationality")},
+			ContactNumber: "+917348761063",
+		}, expErr: validationErr("nationality", "required", "nationality is required")},
 		{desc: "failure:invalid father name", id: 1, reqData: models.Student{
 			FirstName:     "arvind",
 			FatherName:    "123",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid father name")},
+			ContactNumber: "+917348761063",
+		}, expErr: validationErr("father_name", "name", "father name may only contain letters, spaces, hyphens and apostrophes")},
 		{desc: "failure:invalid mother name", id: 1, reqData: models.Student{
 			FirstName:     "arvind",
 			MotherName:    "123",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid mother name")},
+			ContactNumber: "+917348761063",
+		}, expErr: validationErr("mother_name", "name", "mother name may only contain letters, spaces, hyphens and apostrophes")},
 		{desc: "failure:invalid contact number", id: 1, reqData: models.Student{
 			FirstName:     "arvind",
 			Nationality:   "Indian",
-			ContactNumber: 734876106,
-		}, expErr: errors.New("invalid contact number")},
+			ContactNumber: "0000000000",
+		}, expErr: validationErr("contact_number", "phone", "contact number must be a valid phone number")},
 		{desc: "failure:invalid father occupation", id: 1, reqData: models.Student{
 			FirstName:        "arvind",
 			FatherOccupation: "a12",
 			Nationality:      "Indian",
-			ContactNumber:    7348761063,
-		}, expErr: errors.New("invalid father occupation")},
+			ContactNumber:    "+917348761063",
+		}, expErr: validationErr("father_occupation", "name", "father occupation may only contain letters, spaces, hyphens and apostrophes")},
 		{desc: "failure:invalid mother occupation", id: 1, reqData: models.Student{
 			FirstName:        "arvind",
 			MotherOccupation: "a12",
 			Nationality:      "Indian",
-			ContactNumber:    7348761063,
-		}, expErr: errors.New("invalid mother occupation")},
+			ContactNumber:    "+917348761063",
+		}, expErr: validationErr("mother_occupation", "name", "mother occupation may only contain letters, spaces, hyphens and apostrophes")},
 		{desc: "failure:invalid family income", id: 1, reqData: models.Student{
 			FirstName:     "arvind",
 			FamilyIncome:  -123,
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
-		}, expErr: errors.New("invalid family income")},
+			ContactNumber: "+917348761063",
+		}, expErr: validationErr("family_income", "positive", "family income must be positive")},
 	}
 
 	for i, tc := range testcases {
@@ -903,3 +806,1196 @@ func TestPut_BodyCheckErr2(t *testing.T) {
 		}
 	}
 }
+
+// today pins the clock of the age checks to 1 March 2024.
+func today() time.Time {
+	return time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+}
+
+// inline runs the function of a transaction without starting one.
+type inline struct{}
+
+func (inline) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func validationErr(field, rule, message string) error {
+	return errors.Validation{Errors: []errors.FieldError{{Field: field, Rule: rule, Message: message}}}
+}
+
+func TestPost_AllFieldErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := New(store.NewMockStudent(ctrl), nil, nil, config.Default().Validation)
+
+	student := models.Student{FirstName: "a12", Gender: "K", Dob: models.NewDate(2999, time.January, 1), ContactNumber: "123",
+		EmergencyContactNumber: "+44 20", FamilyIncome: -1}
+
+	expErr := errors.Validation{Errors: []errors.FieldError{
+		{Field: "first_name", Rule: "name", Message: "first name may only contain letters, spaces, hyphens and apostrophes"},
+		{Field: "nationality", Rule: "required", Message: "nationality is required"},
+		{Field: "gender", Rule: "one_of", Message: "gender must be one of M, F or O"},
+		{Field: "dob", Rule: "past", Message: "dob must not be in the future"},
+		{Field: "contact_number", Rule: "phone", Message: "contact number must be a valid phone number"},
+		{Field: "emergency_contact_number", Rule: "phone", Message: "emergency contact number must be a valid phone number"},
+		{Field: "family_income", Rule: "positive", Message: "family income must be positive"},
+	}}
+
+	_, err := mock.Post(context.Background(), &student)
+
+	if !reflect.DeepEqual(expErr, err) {
+		t.Errorf("expected %v got %v", expErr, err)
+	}
+}
+
+func TestCheckName(t *testing.T) {
+	testcases := []struct {
+		desc  string
+		value string
+		exp   bool
+	}{
+		{desc: "ascii", value: "arvind", exp: true},
+		{desc: "accented", value: "José", exp: true},
+		{desc: "apostrophe", value: "O'Brien", exp: true},
+		{desc: "typographic apostrophe", value: "O’Brien", exp: true},
+		{desc: "hyphen", value: "Mary-Jane", exp: true},
+		{desc: "spaces", value: "Van der Berg", exp: true},
+		{desc: "devanagari with vowel signs", value: "अरविंद", exp: true},
+		{desc: "tamil", value: "தமிழ்", exp: true},
+		{desc: "combining accent", value: "Jose\u0301", exp: true},
+		{desc: "digits", value: "a12", exp: false},
+		{desc: "leading hyphen", value: "-arvind", exp: false},
+		{desc: "trailing space", value: "arvind ", exp: false},
+		{desc: "double separator", value: "Mary--Jane", exp: false},
+		{desc: "leading combining mark", value: "\u0301Jose", exp: false},
+		{desc: "punctuation", value: "arvind!", exp: false},
+	}
+
+	for i, tc := range testcases {
+		if res := checkName(tc.value); res != tc.exp {
+			t.Errorf("testcases %d failed %q expected %v got %v", i+1, tc.value, tc.exp, res)
+		}
+	}
+}
+
+func TestNormalise(t *testing.T) {
+	student := models.Student{FirstName: "  Jose\u0301 ", Nationality: "Indian"}
+
+	normalise(&student)
+
+	if student.FirstName != "Jos\u00e9" {
+		t.Errorf("expected the precomposed, trimmed name got %q", student.FirstName)
+	}
+}
+
+func TestIsValidate_MaxLength(t *testing.T) {
+	s := New(nil, nil, nil, config.Validation{MaxLength: map[string]int{"first_name": 4}})
+
+	testcases := []struct {
+		desc      string
+		firstName string
+		expErr    error
+	}{
+		{desc: "counts characters, not bytes", firstName: "अरवि"},
+		{desc: "too long", firstName: "arvind", expErr: validationErr("first_name", "max_length",
+			"first name must be at most 4 characters")},
+	}
+
+	for i, tc := range testcases {
+		err := s.isValidate(&models.Student{FirstName: tc.firstName, Nationality: "Indian", ContactNumber: "+917348761063"}, true)
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
+
+func TestAgeOn(t *testing.T) {
+	testcases := []struct {
+		desc  string
+		dob   models.Date
+		today time.Time
+		exp   int
+	}{
+		{desc: "day before birthday", dob: models.NewDate(2010, time.June, 15), today: time.Date(2020, time.June, 14, 0, 0, 0, 0, time.UTC), exp: 9},
+		{desc: "on birthday", dob: models.NewDate(2010, time.June, 15), today: time.Date(2020, time.June, 15, 0, 0, 0, 0, time.UTC), exp: 10},
+		{desc: "leap day in common year", dob: models.NewDate(2020, time.February, 29), today: time.Date(2021, time.February, 28, 0, 0, 0, 0, time.UTC), exp: 0},
+		{desc: "day after leap day", dob: models.NewDate(2020, time.February, 29), today: time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC), exp: 1},
+		{desc: "leap day in leap year", dob: models.NewDate(2020, time.February, 29), today: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC), exp: 4},
+	}
+
+	for i, tc := range testcases {
+		if got := ageOn(tc.dob, tc.today); got != tc.exp {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.exp, got)
+		}
+	}
+}
+
+func TestFormatPhone(t *testing.T) {
+	testcases := []struct {
+		desc   string
+		value  string
+		region string
+		exp    string
+		expOk  bool
+	}{
+		{desc: "national number", value: "7348761063", region: "IN", exp: "+917348761063", expOk: true},
+		{desc: "national number with trunk prefix", value: "07348 761063", region: "IN", exp: "+917348761063", expOk: true},
+		{desc: "international number", value: "+44 20 7946 0958", region: "IN", exp: "+442079460958", expOk: true},
+		{desc: "leading zero kept", value: "020 7946 0958", region: "GB", exp: "+442079460958", expOk: true},
+		{desc: "all zeros", value: "0000000000", region: "IN"},
+		{desc: "too short", value: "12345", region: "IN"},
+		{desc: "not a number", value: "call me", region: "IN"},
+		{desc: "empty", value: "", region: "IN"},
+	}
+
+	for i, tc := range testcases {
+		got, ok := formatPhone(tc.value, tc.region)
+
+		if got != tc.exp || ok != tc.expOk {
+			t.Errorf("testcases %d failed expected %v %v got %v %v", i+1, tc.exp, tc.expOk, got, ok)
+		}
+	}
+}
+
+func TestPatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := store.NewMockStudent(ctrl)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
+
+	mockAudit.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	current := models.Student{ID: 1, FirstName: "arvind", LastName: "yadav", Nationality: "Indian",
+		ContactNumber: "+917348761063"}
+
+	testcases := []struct {
+		desc       string
+		patchType  models.PatchType
+		patch      string
+		expStudent models.Student
+		expColumns []string
+		expStore   bool
+		expErr     error
+	}{
+		{desc: "success:merge patch touches only changed fields", patchType: models.MergePatch,
+			patch: `{"last_name":"kumar","contact_number":"073487 61064"}`,
+			expStudent: models.Student{ID: 1, FirstName: "arvind", LastName: "kumar", Nationality: "Indian",
+				ContactNumber: "+917348761064"}, expColumns: []string{"last_name", "contact_number", "identity_key"}, expStore: true},
+		{desc: "success:contact number as a json integer", patchType: models.MergePatch,
+			patch: `{"contact_number":7348761064}`,
+			expStudent: models.Student{ID: 1, FirstName: "arvind", LastName: "yadav", Nationality: "Indian",
+				ContactNumber: "+917348761064"}, expColumns: []string{"contact_number", "identity_key"}, expStore: true},
+		{desc: "success:merge patch null clears a field", patchType: models.MergePatch, patch: `{"last_name":null}`,
+			expStudent: models.Student{ID: 1, FirstName: "arvind", Nationality: "Indian", ContactNumber: "+917348761063"},
+			expColumns: []string{"last_name", "identity_key"}, expStore: true},
+		{desc: "success:json patch on an unset field", patchType: models.JSONPatch,
+			patch: `[{"op":"test","path":"/gender","value":""},{"op":"replace","path":"/gender","value":"M"}]`,
+			expStudent: models.Student{ID: 1, FirstName: "arvind", LastName: "yadav", Gender: "M", Nationality: "Indian",
+				ContactNumber: "+917348761063"}, expColumns: []string{"gender", "identity_key"}, expStore: true},
+		{desc: "success:letter case keeps the identity", patchType: models.MergePatch, patch: `{"first_name":"Arvind"}`,
+			expStudent: models.Student{ID: 1, FirstName: "Arvind", LastName: "yadav", Nationality: "Indian",
+				ContactNumber: "+917348761063"}, expColumns: []string{"first_name"}, expStore: true},
+		{desc: "success:nothing changed", patchType: models.MergePatch, patch: `{"first_name":" arvind "}`,
+			expStudent: current},
+		{desc: "failure:failed test operation", patchType: models.JSONPatch,
+			patch:  `[{"op":"test","path":"/last_name","value":"kumar"}]`,
+			expErr: errors.InvalidParam{Field: "body", Reason: "testing value /last_name failed: test failed"}},
+		{desc: "failure:id cannot change", patchType: models.MergePatch, patch: `{"id":2}`,
+			expErr: errors.InvalidParam{Field: "id", Reason: "cannot be changed"}},
+		{desc: "failure:unknown field", patchType: models.MergePatch, patch: `{"password":"x"}`,
+			expErr: errors.InvalidParam{Field: "body", Reason: `json: unknown field "password"`}},
+		{desc: "failure:fractional contact number", patchType: models.MergePatch, patch: `{"contact_number":7348.5}`,
+			expErr: errors.InvalidParam{Field: "body", Reason: "contact_number must be a string or a whole number"}},
+		{desc: "failure:result is validated", patchType: models.MergePatch, patch: `{"first_name":null}`,
+			expErr: validationErr("first_name", "required", "first name is required")},
+		{desc: "failure:unsupported patch type", patchType: "text/plain", patch: "kumar",
+			expErr: errors.UnsupportedMediaType{MediaType: "text/plain"}},
+	}
+
+	for i, tc := range testcases {
+		ctx := context.Background()
+
+		mockStore.EXPECT().GetByID(ctx, 1).Return(current, nil)
+
+		if tc.expStore {
+			mockStore.EXPECT().Patch(ctx, 1, &tc.expStudent, tc.expColumns).Return(tc.expStudent, nil)
+		}
+
+		res, err := mock.Patch(ctx, 1, 0, tc.patchType, []byte(tc.patch))
+
+		if tc.expErr == nil && !reflect.DeepEqual(tc.expStudent, res) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expStudent, res)
+		}
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
+
+func TestUpdate_AgeRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := store.NewMockStudent(ctrl)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
+	mock.now = today
+
+	mockAudit.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	// the student has aged out of the range since it was created
+	current := models.Student{ID: 1, FirstName: "arvind", Dob: models.NewDate(1960, time.January, 1), Nationality: "Indian",
+		ContactNumber: "+917348761063", Version: 2}
+	renamed := current
+	renamed.LastName = "kumar"
+
+	testcases := []struct {
+		desc   string
+		update func(ctx context.Context) (models.Student, error)
+		expErr error
+	}{
+		{desc: "success:put keeping the dob", update: func(ctx context.Context) (models.Student, error) {
+			student := renamed
+			mockStore.EXPECT().Put(ctx, 1, &student).Return(renamed, nil)
+
+			return mock.Put(ctx, 1, &student)
+		}},
+		{desc: "success:patch of another field", update: func(ctx context.Context) (models.Student, error) {
+			mockStore.EXPECT().Patch(ctx, 1, &renamed, []string{"last_name", "identity_key"}).Return(renamed, nil)
+
+			return mock.Patch(ctx, 1, 0, models.MergePatch, []byte(`{"last_name":"kumar"}`))
+		}},
+		{desc: "failure:put of a dob too young", update: func(ctx context.Context) (models.Student, error) {
+			student := renamed
+			student.Dob = models.NewDate(2021, time.March, 2)
+
+			return mock.Put(ctx, 1, &student)
+		}, expErr: validationErr("dob", "age_range", "age must be between 3 and 60 years")},
+		{desc: "failure:patch of a dob too old", update: func(ctx context.Context) (models.Student, error) {
+			return mock.Patch(ctx, 1, 0, models.MergePatch, []byte(`{"dob":"1960-01-02"}`))
+		}, expErr: validationErr("dob", "age_range", "age must be between 3 and 60 years")},
+	}
+
+	for i, tc := range testcases {
+		ctx := context.Background()
+
+		mockStore.EXPECT().GetByID(ctx, 1).Return(current, nil)
+
+		_, err := tc.update(ctx)
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
+
+func TestPatch_StoreErr(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := store.NewMockStudent(ctrl)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
+
+	ctx := context.Background()
+	notFound := errors.EntityNotFound{Entity: "student", ID: "1"}
+
+	mockStore.EXPECT().GetByID(ctx, 1).Return(models.Student{}, sql.ErrNoRows)
+
+	if _, err := mock.Patch(ctx, 1, 0, models.MergePatch, []byte(`{}`)); !reflect.DeepEqual(notFound, err) {
+		t.Errorf("expected %v got %v", notFound, err)
+	}
+
+	current := models.Student{ID: 1, FirstName: "arvind", Nationality: "Indian", ContactNumber: "+917348761063"}
+	storeErr := stdErrors.New("connection reset")
+
+	mockStore.EXPECT().GetByID(ctx, 1).Return(current, nil)
+	mockStore.EXPECT().Patch(ctx, 1, gomock.Any(), []string{"last_name", "identity_key"}).Return(models.Student{}, storeErr)
+
+	if _, err := mock.Patch(ctx, 1, 0, models.MergePatch, []byte(`{"last_name":"yadav"}`)); !reflect.DeepEqual(errors.Internal{Err: storeErr}, err) {
+		t.Errorf("expected %v got %v", errors.Internal{Err: storeErr}, err)
+	}
+}
+
+func TestVersionConflicts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := store.NewMockStudent(ctrl)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
+
+	ctx := context.Background()
+	current := models.Student{ID: 1, FirstName: "arvind", Nationality: "Indian", ContactNumber: "+917348761063", Version: 3}
+	expErr := errors.PreconditionFailed{Entity: "student", ID: "1"}
+
+	testcases := []struct {
+		desc string
+		call func() error
+	}{
+		{desc: "put on an older version", call: func() error {
+			_, err := mock.Put(ctx, 1, &models.Student{FirstName: "arvind", Nationality: "Indian", ContactNumber: "+917348761063",
+				Version: 2})
+
+			return err
+		}},
+		{desc: "patch on an older version", call: func() error {
+			_, err := mock.Patch(ctx, 1, 2, models.MergePatch, []byte(`{"last_name":"yadav"}`))
+
+			return err
+		}},
+		{desc: "delete of an older version", call: func() error {
+			return mock.Delete(ctx, 1, 2)
+		}},
+		{desc: "put racing another update", call: func() error {
+			mockStore.EXPECT().Put(ctx, 1, gomock.Any()).Return(models.Student{}, sql.ErrNoRows)
+
+			_, err := mock.Put(ctx, 1, &models.Student{FirstName: "arvind", Nationality: "Indian", ContactNumber: "+917348761063"})
+
+			return err
+		}},
+		{desc: "delete racing another update", call: func() error {
+			mockStore.EXPECT().Delete(ctx, 1, 3).Return(sql.ErrNoRows)
+
+			return mock.Delete(ctx, 1, 3)
+		}},
+	}
+
+	for i, tc := range testcases {
+		mockStore.EXPECT().GetByID(ctx, 1).Return(current, nil)
+
+		if err := tc.call(); !reflect.DeepEqual(expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, expErr, err)
+		}
+	}
+}
+
+func TestRestore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := store.NewMockStudent(ctrl)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
+
+	mockAudit.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	restored := models.Student{ID: 1, FirstName: "arvind", Nationality: "Indian", ContactNumber: "+917348761063", Version: 3}
+
+	testcases := []struct {
+		desc       string
+		restoreErr error
+		expRes     models.Student
+		expErr     error
+	}{
+		{desc: "success:restored", expRes: restored},
+		{desc: "failure:not in the trash", restoreErr: sql.ErrNoRows,
+			expErr: errors.EntityNotFound{Entity: "deleted student", ID: "1"}},
+		{desc: "failure:duplicate of an active student", restoreErr: store.Duplicate{ID: 2},
+			expErr: errors.EntityAlreadyExists{Entity: "student", ID: "2"}},
+		{desc: "failure:store error", restoreErr: stdErrors.New("connection reset"),
+			expErr: errors.Internal{Err: stdErrors.New("connection reset")}},
+	}
+
+	for i, tc := range testcases {
+		ctx := context.Background()
+
+		mockStore.EXPECT().Restore(ctx, 1).Return(tc.restoreErr)
+
+		if tc.restoreErr == nil {
+			mockStore.EXPECT().GetByID(ctx, 1).Return(restored, nil)
+		}
+
+		res, err := mock.Restore(ctx, 1)
+
+		if !reflect.DeepEqual(tc.expRes, res) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes, res)
+		}
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
+
+func TestPurge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := store.NewMockStudent(ctrl)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
+
+	ctx := context.Background()
+	before := today().Add(-30 * 24 * time.Hour)
+
+	mockStore.EXPECT().Purge(ctx, before).Return(4, nil)
+
+	if n, err := mock.Purge(ctx, before); n != 4 || err != nil {
+		t.Errorf("expected 4 got %v, %v", n, err)
+	}
+
+	mockStore.EXPECT().Purge(ctx, before).Return(2, stdErrors.New("lock wait timeout"))
+
+	if n, err := mock.Purge(ctx, before); n != 2 || !reflect.DeepEqual(errors.Internal{Err: stdErrors.New("lock wait timeout")}, err) {
+		t.Errorf("expected 2 and an internal error got %v, %v", n, err)
+	}
+}
+
+func TestAudit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := store.NewMockStudent(ctrl)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
+	mock.now = today
+
+	ctx := requestctx.WithPrincipal(requestctx.WithRequestID(context.Background(), "req-1"),
+		models.Principal{Subject: "admin"})
+	current := models.Student{ID: 1, FirstName: "arvind", LastName: "yadav", Nationality: "Indian",
+		ContactNumber: "+917348761063", Version: 3}
+	updated := current
+	updated.LastName = "kumar"
+
+	testcases := []struct {
+		desc     string
+		call     func() error
+		expEntry models.AuditEntry
+	}{
+		{desc: "post records the fields set", call: func() error {
+			mockStore.EXPECT().Post(ctx, gomock.Any()).Return(models.Student{ID: 1, FirstName: "arvind", Nationality: "Indian",
+				ContactNumber: "+917348761063", Version: 1}, nil)
+
+			_, err := mock.Post(ctx, &models.Student{FirstName: "arvind", Nationality: "Indian", ContactNumber: "+917348761063"})
+
+			return err
+		}, expEntry: models.AuditEntry{StudentID: 1, Version: 1, Action: models.ActionCreate, Changes: []models.Change{
+			{Field: "first_name", Before: json.RawMessage(`""`), After: json.RawMessage(`"arvind"`)},
+			{Field: "nationality", Before: json.RawMessage(`""`), After: json.RawMessage(`"Indian"`)},
+			{Field: "contact_number", Before: json.RawMessage(`""`), After: json.RawMessage(`"+917348761063"`)},
+		}}},
+		{desc: "patch records the fields changed", call: func() error {
+			mockStore.EXPECT().GetByID(ctx, 1).Return(current, nil)
+			mockStore.EXPECT().Patch(ctx, 1, &updated, []string{"last_name", "identity_key"}).DoAndReturn(
+				func(_ context.Context, _ int, s *models.Student, _ []string) (models.Student, error) {
+					res := *s
+					res.Version++
+
+					return res, nil
+				})
+
+			_, err := mock.Patch(ctx, 1, 3, models.MergePatch, []byte(`{"last_name":"kumar"}`))
+
+			return err
+		}, expEntry: models.AuditEntry{StudentID: 1, Version: 4, Action: models.ActionUpdate, Changes: []models.Change{
+			{Field: "last_name", Before: json.RawMessage(`"yadav"`), After: json.RawMessage(`"kumar"`)},
+		}}},
+		{desc: "delete records no fields", call: func() error {
+			mockStore.EXPECT().GetByID(ctx, 1).Return(current, nil)
+			mockStore.EXPECT().Delete(ctx, 1, 3).Return(nil)
+
+			return mock.Delete(ctx, 1, 3)
+		}, expEntry: models.AuditEntry{StudentID: 1, Version: 4, Action: models.ActionDelete, Changes: []models.Change{}}},
+	}
+
+	for i, tc := range testcases {
+		tc.expEntry.Actor, tc.expEntry.RequestID, tc.expEntry.At = "admin", "req-1", today()
+
+		mockAudit.EXPECT().Create(ctx, &tc.expEntry).Return(nil)
+
+		if err := tc.call(); err != nil {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, nil, err)
+		}
+	}
+}
+
+func TestAudit_CreateErr(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := store.NewMockStudent(ctrl)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
+
+	ctx := context.Background()
+	auditErr := stdErrors.New("connection reset")
+
+	mockStore.EXPECT().GetByID(ctx, 1).Return(models.Student{ID: 1, FirstName: "arvind", Nationality: "Indian",
+		ContactNumber: "+917348761063"}, nil)
+	mockStore.EXPECT().Delete(ctx, 1, 0).Return(nil)
+	mockAudit.EXPECT().Create(ctx, gomock.Any()).Return(auditErr)
+
+	if err := mock.Delete(ctx, 1, 0); !reflect.DeepEqual(errors.Internal{Err: auditErr}, err) {
+		t.Errorf("expected %v got %v", errors.Internal{Err: auditErr}, err)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := store.NewMockStudent(ctrl)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
+
+	entries := []models.AuditEntry{{ID: 7, StudentID: 1, Version: 1, Action: models.ActionCreate}}
+
+	testcases := []struct {
+		desc       string
+		id         int
+		entries    []models.AuditEntry
+		getByIDErr error
+		expRes     []models.AuditEntry
+		expErr     error
+	}{
+		{desc: "success:history", id: 1, entries: entries, expRes: entries},
+		{desc: "success:no history yet", id: 2, entries: []models.AuditEntry{}, expRes: []models.AuditEntry{}},
+		{desc: "failure:no such student", id: 3, entries: []models.AuditEntry{}, getByIDErr: sql.ErrNoRows,
+			expErr: errors.EntityNotFound{Entity: "student", ID: "3"}},
+	}
+
+	for i, tc := range testcases {
+		ctx := context.Background()
+
+		mockAudit.EXPECT().GetByStudentID(ctx, tc.id).Return(tc.entries, nil)
+
+		if len(tc.entries) == 0 {
+			mockStore.EXPECT().GetByID(ctx, tc.id).Return(models.Student{}, tc.getByIDErr)
+		}
+
+		res, err := mock.History(ctx, tc.id)
+
+		if tc.expErr == nil && !reflect.DeepEqual(tc.expRes, res) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes, res)
+		}
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
+
+func TestRevert(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := store.NewMockStudent(ctrl)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
+
+	current := models.Student{ID: 1, FirstName: "arvind", LastName: "kumar", Gender: "M", Nationality: "Indian",
+		ContactNumber: "+917348761063", Version: 3}
+	history := []models.AuditEntry{
+		{StudentID: 1, Version: 1, Action: models.ActionCreate, Changes: []models.Change{
+			{Field: "first_name", Before: json.RawMessage(`""`), After: json.RawMessage(`"arvind"`)},
+			{Field: "last_name", Before: json.RawMessage(`""`), After: json.RawMessage(`"yadav"`)},
+		}},
+		{StudentID: 1, Version: 2, Action: models.ActionUpdate, Changes: []models.Change{
+			{Field: "last_name", Before: json.RawMessage(`"yadav"`), After: json.RawMessage(`"kumar"`)},
+		}},
+		{StudentID: 1, Version: 3, Action: models.ActionUpdate, Changes: []models.Change{
+			{Field: "gender", Before: json.RawMessage(`""`), After: json.RawMessage(`"M"`)},
+		}},
+	}
+
+	testcases := []struct {
+		desc       string
+		to         int
+		history    []models.AuditEntry
+		expStudent models.Student
+		expColumns []string
+		expErr     error
+	}{
+		{desc: "success:back to the first version", to: 1, history: history,
+			expStudent: models.Student{ID: 1, FirstName: "arvind", LastName: "yadav", Nationality: "Indian",
+				ContactNumber: "+917348761063", Version: 3}, expColumns: []string{"last_name", "gender", "identity_key"}},
+		{desc: "success:back one version", to: 2, history: history,
+			expStudent: models.Student{ID: 1, FirstName: "arvind", LastName: "kumar", Nationality: "Indian",
+				ContactNumber: "+917348761063", Version: 3}, expColumns: []string{"gender", "identity_key"}},
+		{desc: "failure:not an earlier version", to: 3,
+			expErr: errors.InvalidParam{Field: "version", Reason: "must be an earlier version of the student"}},
+		{desc: "failure:changes missing from the history", to: 1, history: history[2:],
+			expErr: errors.InvalidParam{Field: "version", Reason: "is not in the recorded history of the student"}},
+	}
+
+	for i, tc := range testcases {
+		ctx := context.Background()
+
+		mockStore.EXPECT().GetByID(ctx, 1).Return(current, nil)
+
+		if tc.history != nil {
+			mockAudit.EXPECT().GetByStudentID(ctx, 1).Return(tc.history, nil)
+		}
+
+		if tc.expErr == nil {
+			mockStore.EXPECT().Patch(ctx, 1, &tc.expStudent, tc.expColumns).Return(tc.expStudent, nil)
+			mockAudit.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+		}
+
+		_, err := mock.Revert(ctx, 1, 0, tc.to)
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := svc.NewMockStudent(ctrl)
+	mockUser := store.NewMockUser(ctrl)
+
+	matrix, err := policy.New(config.Default().Auth.Roles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redaction, err := policy.NewRedaction(config.Default().Auth.Redact)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock := Authorize(mockService, matrix, mockUser, redaction)
+
+	as := func(role string) context.Context {
+		return requestctx.WithPrincipal(context.Background(), models.Principal{Subject: "user-" + role, Role: role})
+	}
+
+	testcases := []struct {
+		desc   string
+		call   func() error
+		expErr error
+	}{
+		{desc: "teacher cannot delete", call: func() error {
+			return mock.Delete(as("teacher"), 1, 0)
+		}, expErr: errors.Forbidden{Permission: "student:delete"}},
+		{desc: "registrar can delete", call: func() error {
+			ctx := as("registrar")
+			mockService.EXPECT().Delete(ctx, 1, 0).Return(nil)
+
+			return mock.Delete(ctx, 1, 0)
+		}},
+		{desc: "teacher lists every student", call: func() error {
+			ctx := as("teacher")
+			mockService.EXPECT().Get(ctx, &models.Filter{}).Return(models.StudentList{}, nil)
+
+			_, err := mock.Get(ctx, &models.Filter{})
+
+			return err
+		}},
+		{desc: "parent lists only their children", call: func() error {
+			ctx := as("parent")
+			mockUser.EXPECT().GetStudentIDs(ctx, "user-parent").Return([]int{7}, nil)
+			mockService.EXPECT().Get(ctx, &models.Filter{Gender: "F", IDs: []int{7}}).Return(models.StudentList{}, nil)
+
+			_, err := mock.Get(ctx, &models.Filter{Gender: "F"})
+
+			return err
+		}},
+		{desc: "parent reads their child", call: func() error {
+			ctx := as("parent")
+			mockUser.EXPECT().GetStudentIDs(ctx, "user-parent").Return([]int{7}, nil)
+			mockService.EXPECT().GetByID(ctx, 7).Return(models.Student{ID: 7}, nil)
+
+			_, err := mock.GetByID(ctx, 7)
+
+			return err
+		}},
+		{desc: "parent cannot read another child", call: func() error {
+			ctx := as("parent")
+			mockUser.EXPECT().GetStudentIDs(ctx, "user-parent").Return([]int{7}, nil)
+
+			_, err := mock.GetByID(ctx, 8)
+
+			return err
+		}, expErr: errors.Forbidden{Permission: "student:read"}},
+		{desc: "readonly cannot see the trash", call: func() error {
+			_, err := mock.Get(as("readonly"), &models.Filter{Deleted: true})
+
+			return err
+		}, expErr: errors.Forbidden{Permission: "student:trash"}},
+		{desc: "teacher cannot see the history", call: func() error {
+			_, err := mock.History(as("teacher"), 1)
+
+			return err
+		}, expErr: errors.Forbidden{Permission: "student:history"}},
+		{desc: "registrar cannot purge", call: func() error {
+			_, err := mock.Purge(as("registrar"), today())
+
+			return err
+		}, expErr: errors.Forbidden{Permission: "student:purge"}},
+		{desc: "unknown role cannot read", call: func() error {
+			_, err := mock.GetByID(as("janitor"), 1)
+
+			return err
+		}, expErr: errors.Forbidden{Permission: "student:read"}},
+		{desc: "teacher cannot create a batch", call: func() error {
+			_, err := mock.PostBatch(as("teacher"), []models.BatchItem{{Student: &models.Student{}}}, models.Atomic)
+
+			return err
+		}, expErr: errors.Forbidden{Permission: "student:create"}},
+		{desc: "registrar can delete a batch", call: func() error {
+			ctx := as("registrar")
+			items := []models.BatchItem{{ID: 1, Version: 2}}
+			mockService.EXPECT().DeleteBatch(ctx, items, models.BestEffort).Return([]models.BatchResult{{ID: 1}}, nil)
+
+			_, err := mock.DeleteBatch(ctx, items, models.BestEffort)
+
+			return err
+		}},
+		{desc: "unauthenticated caller cannot create", call: func() error {
+			_, err := mock.Post(context.Background(), &models.Student{})
+
+			return err
+		}, expErr: errors.Forbidden{Permission: "student:create"}},
+	}
+
+	for i, tc := range testcases {
+		if err := tc.call(); !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
+
+func TestAuthorize_Redaction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := svc.NewMockStudent(ctrl)
+
+	cfg := config.Default().Auth
+	cfg.Redact["registrar"] = []string{"family_income", "contact_number:mask"}
+
+	matrix, err := policy.New(cfg.Roles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redaction, err := policy.NewRedaction(cfg.Redact)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock := Authorize(mockService, matrix, store.NewMockUser(ctrl), redaction)
+
+	as := func(role string) context.Context {
+		return requestctx.WithPrincipal(context.Background(), models.Principal{Subject: "user-" + role, Role: role})
+	}
+
+	stored := models.Student{ID: 1, FirstName: "Asha", ContactNumber: "+919876543210", FatherOccupation: "Farmer",
+		FamilyIncome: 50000, Version: 2}
+	hidden := errors.InvalidParam{Field: "contact_number", Reason: "is hidden from the role of the caller"}
+
+	testcases := []struct {
+		desc   string
+		call   func() (interface{}, error)
+		expRes interface{}
+		expErr error
+	}{
+		{desc: "teacher reads a redacted student", call: func() (interface{}, error) {
+			ctx := as("teacher")
+			mockService.EXPECT().GetByID(ctx, 1).Return(stored, nil)
+
+			return mock.GetByID(ctx, 1)
+		}, expRes: models.Student{ID: 1, FirstName: "Asha", ContactNumber: "*********3210", Version: 2}},
+		{desc: "admin reads the whole student", call: func() (interface{}, error) {
+			ctx := as("admin")
+			mockService.EXPECT().Get(ctx, &models.Filter{}).Return(models.StudentList{Data: []models.Student{stored}}, nil)
+
+			return mock.Get(ctx, &models.Filter{})
+		}, expRes: models.StudentList{Data: []models.Student{stored}}},
+		{desc: "teacher cannot filter on a hidden field", call: func() (interface{}, error) {
+			return mock.Get(as("teacher"), &models.Filter{ContactNumber: "+919876543210"})
+		}, expRes: models.StudentList{}, expErr: hidden},
+		{desc: "teacher cannot sort on a hidden field", call: func() (interface{}, error) {
+			return mock.Get(as("teacher"), &models.Filter{Sort: []models.Sort{{Field: "family_income", Desc: true}}})
+		}, expRes: models.StudentList{}, expErr: errors.InvalidParam{Field: "family_income",
+			Reason: "is hidden from the role of the caller"}},
+		{desc: "teacher exports redacted students", call: func() (interface{}, error) {
+			ctx := as("teacher")
+			mockService.EXPECT().Export(ctx, &models.Filter{}, gomock.Any()).DoAndReturn(
+				func(ctx context.Context, filter *models.Filter, fn func(models.Student) error) error {
+					return fn(stored)
+				})
+
+			var students []models.Student
+
+			err := mock.Export(ctx, &models.Filter{}, func(student models.Student) error {
+				students = append(students, student)
+
+				return nil
+			})
+
+			return students, err
+		}, expRes: []models.Student{{ID: 1, FirstName: "Asha", ContactNumber: "*********3210", Version: 2}}},
+		{desc: "teacher cannot export filtered on a hidden field", call: func() (interface{}, error) {
+			return nil, mock.Export(as("teacher"), &models.Filter{ContactNumber: "+919876543210"}, nil)
+		}, expErr: hidden},
+		{desc: "registrar reads a redacted history", call: func() (interface{}, error) {
+			ctx := as("registrar")
+			mockService.EXPECT().History(ctx, 1).Return([]models.AuditEntry{{Changes: []models.Change{
+				{Field: "first_name", Before: json.RawMessage(`"Asa"`), After: json.RawMessage(`"Asha"`)},
+				{Field: "family_income", Before: json.RawMessage(`0`), After: json.RawMessage(`50000`)},
+				{Field: "contact_number", Before: json.RawMessage(`null`), After: json.RawMessage(`"+919876543210"`)},
+			}}}, nil)
+
+			return mock.History(ctx, 1)
+		}, expRes: []models.AuditEntry{{Changes: []models.Change{
+			{Field: "first_name", Before: json.RawMessage(`"Asa"`), After: json.RawMessage(`"Asha"`)},
+			{Field: "contact_number", Before: json.RawMessage(`null`), After: json.RawMessage(`"*********3210"`)},
+		}}}},
+		{desc: "registrar replacing a student keeps the hidden fields", call: func() (interface{}, error) {
+			ctx := as("registrar")
+			mockService.EXPECT().GetByID(ctx, 1).Return(stored, nil)
+			mockService.EXPECT().Put(ctx, 1, &models.Student{FirstName: "Asha", ContactNumber: "+919876543210",
+				FamilyIncome: 50000}).Return(stored, nil)
+
+			return mock.Put(ctx, 1, &models.Student{FirstName: "Asha", ContactNumber: "*********3210"})
+		}, expRes: models.Student{ID: 1, FirstName: "Asha", ContactNumber: "*********3210", FatherOccupation: "Farmer",
+			Version: 2}},
+		{desc: "registrar replacing a batch keeps the hidden fields", call: func() (interface{}, error) {
+			ctx := as("registrar")
+			mockService.EXPECT().GetByID(ctx, 1).Return(stored, nil)
+			mockService.EXPECT().GetByID(ctx, 9).Return(models.Student{}, errors.EntityNotFound{Entity: "student", ID: "9"})
+			mockService.EXPECT().PutBatch(ctx, []models.BatchItem{
+				{ID: 1, Student: &models.Student{FirstName: "Asha", ContactNumber: "+919876543210", FamilyIncome: 50000}},
+				{ID: 9, Student: &models.Student{FirstName: "Ravi"}},
+			}, models.Atomic).Return([]models.BatchResult{{ID: 1, Version: 3}, {Index: 1, ID: 9, Version: 1}}, nil)
+
+			return mock.PutBatch(ctx, []models.BatchItem{{ID: 1, Student: &models.Student{FirstName: "Asha", ContactNumber: "*********3210"}},
+				{ID: 9, Student: &models.Student{FirstName: "Ravi"}}}, models.Atomic)
+		}, expRes: []models.BatchResult{{ID: 1, Version: 3}, {Index: 1, ID: 9, Version: 1}}},
+		{desc: "registrar copies only the masked value of a hidden field", call: func() (interface{}, error) {
+			ctx := as("registrar")
+			mockService.EXPECT().GetByID(ctx, 1).Return(stored, nil)
+			mockService.EXPECT().Patch(ctx, 1, 2, models.MergePatch, []byte(`{"father_occupation":"*********3210"}`)).
+				Return(stored, nil)
+
+			return mock.Patch(ctx, 1, 2, models.JSONPatch,
+				[]byte(`[{"op":"copy","from":"/contact_number","path":"/father_occupation"}]`))
+		}, expRes: models.Student{ID: 1, FirstName: "Asha", ContactNumber: "*********3210", FatherOccupation: "Farmer",
+			Version: 2}},
+		{desc: "registrar cannot test the value of a hidden field", call: func() (interface{}, error) {
+			ctx := as("registrar")
+			mockService.EXPECT().GetByID(ctx, 1).Return(stored, nil)
+
+			return mock.Patch(ctx, 1, 2, models.JSONPatch, []byte(`[{"op":"test","path":"/family_income","value":50000}]`))
+		}, expRes: models.Student{}, expErr: errors.InvalidParam{Field: "body",
+			Reason: "testing value /family_income failed: test failed"}},
+		{desc: "registrar merge patch keeps the hidden fields", call: func() (interface{}, error) {
+			ctx := as("registrar")
+			mockService.EXPECT().GetByID(ctx, 1).Return(stored, nil)
+			mockService.EXPECT().Patch(ctx, 1, 0, models.MergePatch, []byte(`{"first_name":"Ashaa"}`)).Return(stored, nil)
+
+			return mock.Patch(ctx, 1, 0, models.MergePatch,
+				[]byte(`{"first_name":"Ashaa","family_income":0,"contact_number":"+910000000000"}`))
+		}, expRes: models.Student{ID: 1, FirstName: "Asha", ContactNumber: "*********3210", FatherOccupation: "Farmer",
+			Version: 2}},
+		{desc: "registrar cannot revert a change of a hidden field", call: func() (interface{}, error) {
+			ctx := as("registrar")
+			mockService.EXPECT().History(ctx, 1).Return([]models.AuditEntry{
+				{Version: 1, Changes: []models.Change{{Field: "family_income", After: json.RawMessage(`50000`)}}},
+				{Version: 2, Changes: []models.Change{{Field: "contact_number", Before: json.RawMessage(`""`),
+					After: json.RawMessage(`"+919876543210"`)}}},
+			}, nil)
+
+			return mock.Revert(ctx, 1, 2, 1)
+		}, expRes: models.Student{}, expErr: hidden},
+		{desc: "registrar reverts a change of a visible field", call: func() (interface{}, error) {
+			ctx := as("registrar")
+			mockService.EXPECT().History(ctx, 1).Return([]models.AuditEntry{
+				{Version: 1, Changes: []models.Change{{Field: "family_income", After: json.RawMessage(`50000`)}}},
+				{Version: 2, Changes: []models.Change{{Field: "first_name", Before: json.RawMessage(`"Asa"`),
+					After: json.RawMessage(`"Asha"`)}}},
+			}, nil)
+			mockService.EXPECT().Revert(ctx, 1, 2, 1).Return(stored, nil)
+
+			return mock.Revert(ctx, 1, 2, 1)
+		}, expRes: models.Student{ID: 1, FirstName: "Asha", ContactNumber: "*********3210", FatherOccupation: "Farmer",
+			Version: 2}},
+	}
+
+	for i, tc := range testcases {
+		res, err := tc.call()
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+
+		if !reflect.DeepEqual(tc.expRes, res) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes, res)
+		}
+	}
+}
+
+func TestPostBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := store.NewMockStudent(ctrl)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
+
+	mockAudit.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	arvind := models.Student{FirstName: "arvind", Nationality: "Indian", ContactNumber: "+917348761063"}
+	ravi := models.Student{FirstName: "ravi", Nationality: "Indian", ContactNumber: "+917348761064"}
+	invalid := models.Student{Nationality: "Indian", ContactNumber: "+917348761063"}
+
+	created := func(id int, s models.Student) models.Student {
+		s.ID, s.Version = id, 1
+
+		return s
+	}
+
+	testcases := []struct {
+		desc       string
+		items      []models.BatchItem
+		mode       models.BatchMode
+		storeCalls [][]models.Student
+		storeRes   [][]models.Student
+		storeErrs  []error
+		expRes     []models.BatchResult
+		expErr     error
+	}{
+		{desc: "success:every student created", items: []models.BatchItem{{Student: &arvind}, {Student: &ravi}}, mode: models.Atomic,
+			storeCalls: [][]models.Student{{arvind, ravi}}, storeRes: [][]models.Student{{created(5, arvind), created(6, ravi)}},
+			storeErrs: []error{nil}, expRes: []models.BatchResult{{ID: 5, Version: 1}, {Index: 1, ID: 6, Version: 1}}},
+		{desc: "failure:atomic batch with an invalid student", items: []models.BatchItem{{Student: &arvind}, {Student: &invalid}},
+			mode: models.Atomic, expRes: []models.BatchResult{{Err: errors.Aborted{Index: 1}},
+				{Index: 1, Err: validationErr("first_name", "required", "first name is required")}}},
+		{desc: "failure:atomic batch with a duplicate", items: []models.BatchItem{{Student: &arvind}, {Student: &ravi}},
+			mode: models.Atomic, storeCalls: [][]models.Student{{arvind, ravi}}, storeRes: [][]models.Student{nil},
+			storeErrs: []error{store.Duplicates{IDs: map[int]int{0: 3}}},
+			expRes: []models.BatchResult{{Err: errors.EntityAlreadyExists{Entity: "student", ID: "3"}},
+				{Index: 1, Err: errors.Aborted{}}}},
+		{desc: "success:best effort skips a duplicate", items: []models.BatchItem{{Student: &arvind}, {Student: &ravi}},
+			mode: models.BestEffort, storeCalls: [][]models.Student{{arvind, ravi}, {ravi}},
+			storeRes: [][]models.Student{nil, {created(9, ravi)}}, storeErrs: []error{store.Duplicates{IDs: map[int]int{0: 3}}, nil},
+			expRes: []models.BatchResult{{Err: errors.EntityAlreadyExists{Entity: "student", ID: "3"}},
+				{Index: 1, ID: 9, Version: 1}}},
+		{desc: "success:best effort skips a repeated student", items: []models.BatchItem{{Student: &arvind},
+			{Student: &models.Student{FirstName: " ARVIND", Nationality: "indian", ContactNumber: "+917348761063"}}},
+			mode: models.BestEffort, storeCalls: [][]models.Student{{arvind}}, storeRes: [][]models.Student{{created(5, arvind)}},
+			storeErrs: []error{nil}, expRes: []models.BatchResult{{ID: 5, Version: 1},
+				{Index: 1, Err: errors.EntityAlreadyExists{Entity: "student"}}}},
+		{desc: "failure:store error", items: []models.BatchItem{{Student: &arvind}}, mode: models.BestEffort,
+			storeCalls: [][]models.Student{{arvind}}, storeRes: [][]models.Student{nil}, storeErrs: []error{stdErrors.New("exec error")},
+			expErr: errors.Internal{Err: stdErrors.New("exec error")}},
+		{desc: "failure:unknown mode", items: []models.BatchItem{{Student: &arvind}}, mode: "some",
+			expErr: errors.InvalidParam{Field: "mode", Reason: "must be one of atomic or best_effort"}},
+		{desc: "failure:empty batch", mode: models.Atomic,
+			expErr: errors.InvalidParam{Field: "items", Reason: "must have between 1 and 500 items"}},
+	}
+
+	for i, tc := range testcases {
+		ctx := context.Background()
+
+		for j := range tc.storeCalls {
+			mockStore.EXPECT().PostBatch(ctx, tc.storeCalls[j]).Return(tc.storeRes[j], tc.storeErrs[j])
+		}
+
+		res, err := mock.PostBatch(ctx, tc.items, tc.mode)
+
+		if !reflect.DeepEqual(tc.expRes, res) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes, res)
+		}
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
+
+func TestPutBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := store.NewMockStudent(ctrl)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
+
+	mockAudit.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	arvind := models.Student{ID: 1, FirstName: "arvind", Nationality: "Indian", ContactNumber: "+917348761063", Version: 2}
+	ravi := models.Student{ID: 2, FirstName: "ravi", Nationality: "Indian", ContactNumber: "+917348761064", Version: 4}
+	items := []models.BatchItem{
+		{ID: 1, Version: 2, Student: &models.Student{FirstName: "arvind", LastName: "kumar", Nationality: "Indian",
+			ContactNumber: "+917348761063"}},
+		{ID: 2, Version: 3, Student: &models.Student{FirstName: "ravi", Nationality: "Indian", ContactNumber: "+917348761064"}},
+	}
+	updated := arvind
+	updated.LastName, updated.Version = "kumar", 3
+
+	testcases := []struct {
+		desc   string
+		mode   models.BatchMode
+		expRes []models.BatchResult
+	}{
+		{desc: "failure:atomic batch rolled back by a stale item", mode: models.Atomic, expRes: []models.BatchResult{
+			{Err: errors.Aborted{Index: 1}}, {Index: 1, Err: errors.PreconditionFailed{Entity: "student", ID: "2"}}}},
+		{desc: "success:best effort applies the others", mode: models.BestEffort, expRes: []models.BatchResult{
+			{ID: 1, Version: 3}, {Index: 1, Err: errors.PreconditionFailed{Entity: "student", ID: "2"}}}},
+	}
+
+	for i, tc := range testcases {
+		ctx := context.Background()
+
+		mockStore.EXPECT().GetByID(ctx, 1).Return(arvind, nil)
+		mockStore.EXPECT().Put(ctx, 1, gomock.Any()).Return(updated, nil)
+		mockStore.EXPECT().GetByID(ctx, 2).Return(ravi, nil)
+
+		res, err := mock.PutBatch(ctx, items, tc.mode)
+
+		if !reflect.DeepEqual(tc.expRes, res) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes, res)
+		}
+
+		if err != nil {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, nil, err)
+		}
+	}
+}
+
+func TestDeleteBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := store.NewMockStudent(ctrl)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
+
+	mockAudit.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	ctx := context.Background()
+
+	mockStore.EXPECT().GetByID(ctx, 1).Return(models.Student{ID: 1, Version: 2}, nil)
+	mockStore.EXPECT().Delete(ctx, 1, 2).Return(nil)
+	mockStore.EXPECT().GetByID(ctx, 2).Return(models.Student{}, sql.ErrNoRows)
+
+	res, err := mock.DeleteBatch(ctx, []models.BatchItem{{ID: 1}, {ID: 2}}, models.BestEffort)
+
+	exp := []models.BatchResult{{ID: 1}, {Index: 1, Err: errors.EntityNotFound{Entity: "student", ID: "2"}}}
+
+	if err != nil || !reflect.DeepEqual(exp, res) {
+		t.Errorf("expected %v got %v, %v", exp, res, err)
+	}
+}
+
+func TestImport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := store.NewMockStudent(ctrl)
+	mockAudit := store.NewMockAudit(ctrl)
+	mock := New(mockStore, mockAudit, inline{}, config.Default().Validation)
+
+	mockAudit.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	arvind := models.Student{FirstName: "arvind", Nationality: "Indian", ContactNumber: "+917348761063"}
+	ravi := models.Student{FirstName: "ravi", Nationality: "Indian", ContactNumber: "+917348761064"}
+	items := []models.BatchItem{{Student: &arvind}, {Student: &models.Student{Nationality: "Indian"}}, {Student: &ravi}}
+
+	testcases := []struct {
+		desc   string
+		dryRun bool
+		expRes []models.BatchResult
+	}{
+		{desc: "success:students created", expRes: []models.BatchResult{{ID: 5, Version: 1},
+			{Index: 1, Err: errors.Validation{Errors: []errors.FieldError{
+				{Field: "first_name", Rule: "required", Message: "first name is required"},
+				{Field: "contact_number", Rule: "required", Message: "contact number is required"}}}},
+			{Index: 2, Err: errors.EntityAlreadyExists{Entity: "student", ID: "3"}}}},
+		{desc: "success:dry run reports without ids", dryRun: true, expRes: []models.BatchResult{{},
+			{Index: 1, Err: errors.Validation{Errors: []errors.FieldError{
+				{Field: "first_name", Rule: "required", Message: "first name is required"},
+				{Field: "contact_number", Rule: "required", Message: "contact number is required"}}}},
+			{Index: 2, Err: errors.EntityAlreadyExists{Entity: "student", ID: "3"}}}},
+	}
+
+	for i, tc := range testcases {
+		ctx := context.Background()
+
+		mockStore.EXPECT().PostBatch(ctx, []models.Student{arvind, ravi}).Return(nil, store.Duplicates{IDs: map[int]int{1: 3}})
+		mockStore.EXPECT().PostBatch(ctx, []models.Student{arvind}).Return([]models.Student{{ID: 5, FirstName: "arvind",
+			Nationality: "Indian", ContactNumber: "+917348761063", Version: 1}}, nil)
+
+		res, err := mock.Import(ctx, items, tc.dryRun)
+
+		if !reflect.DeepEqual(tc.expRes, res) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes, res)
+		}
+
+		if err != nil {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, nil, err)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := store.NewMockStudent(ctrl)
+	mock := New(mockStore, store.NewMockAudit(ctrl), inline{}, config.Default().Validation)
+
+	students := []models.Student{{ID: 1, FirstName: "arvind"}, {ID: 2, FirstName: "ravi"}}
+
+	testcases := []struct {
+		desc     string
+		filter   models.Filter
+		storeErr error
+		fnErr    error
+		expRes   []models.Student
+		expErr   error
+	}{
+		{desc: "success:every student unpaged", filter: models.Filter{Nationality: "Indian", Limit: 5, Offset: 10},
+			expRes: students},
+		{desc: "failure:fn error returned as it is", filter: models.Filter{Nationality: "Indian"}, fnErr: stdErrors.New("broken pipe"),
+			expRes: students[:1], expErr: stdErrors.New("broken pipe")},
+		{desc: "failure:store error", filter: models.Filter{Nationality: "Indian"}, storeErr: stdErrors.New("query error"),
+			expRes: students, expErr: errors.Internal{Err: stdErrors.New("query error")}},
+		{desc: "failure:invalid filter", filter: models.Filter{Gender: "X"}, expErr: errors.InvalidParam{Field: "gender"}},
+	}
+
+	for i, tc := range testcases {
+		ctx := context.Background()
+
+		if tc.expRes != nil {
+			mockStore.EXPECT().Each(ctx, &models.Filter{Nationality: "Indian"}, gomock.Any()).DoAndReturn(
+				func(ctx context.Context, filter *models.Filter, fn func(models.Student) error) error {
+					for _, s := range students {
+						if err := fn(s); err != nil {
+							return err
+						}
+					}
+
+					return tc.storeErr
+				})
+		}
+
+		var res []models.Student
+
+		err := mock.Export(ctx, &tc.filter, func(student models.Student) error {
+			res = append(res, student)
+
+			return tc.fnErr
+		})
+
+		if !reflect.DeepEqual(tc.expRes, res) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes, res)
+		}
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"student-management-system/errors"
+	"student-management-system/models"
+	"student-management-system/store"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// passwordCost is the bcrypt work factor, about a quarter of a second per hash on current hardware.
+	passwordCost = 12
+
+	minPassword = 8
+	// maxPassword is the most bytes bcrypt hashes; longer passwords would be silently truncated.
+	maxPassword = 72
+	maxUsername = 64
+
+	entity = "user"
+)
+
+// dummyHash is compared against when a login names an unknown user, so that the answer takes as long as for a
+// wrong password and does not reveal which usernames exist.
+const dummyHash = "$2a$12$rDTrLUj71nWuKzvZam3dHu6iMHiy4ko8ln/fMzK3Cc929SLjVlvyi"
+
+type issuer interface {
+	Issue(subject, role string) (models.Token, error)
+}
+
+type roles interface {
+	HasRole(role string) bool
+}
+
+type service struct {
+	user   store.User
+	tokens issuer
+	roles  roles
+	now    func() time.Time
+}
+
+func New(u store.User, t issuer, r roles) service {
+	return service{user: u, tokens: t, roles: r, now: time.Now}
+}
+
+// Create adds a user in one of the configured roles, whose password is stored as a bcrypt hash.
+func (s service) Create(ctx context.Context, username, password, role string) (models.User, error) {
+	if err := s.validate(username, password, role); err != nil {
+		return models.User{}, err
+	}
+
+	_, err := s.user.GetByUsername(ctx, username)
+	if err == nil {
+		return models.User{}, errors.EntityAlreadyExists{Entity: entity}
+	}
+
+	if err != sql.ErrNoRows {
+		return models.User{}, errors.Internal{Err: err}
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordCost)
+	if err != nil {
+		return models.User{}, errors.Internal{Err: err}
+	}
+
+	user, err := s.user.Post(ctx, &models.User{Username: username, PasswordHash: string(hash), Role: role,
+		CreatedAt: s.now().UTC()})
+	if err != nil {
+		return models.User{}, errors.Internal{Err: err}
+	}
+
+	return user, nil
+}
+
+// Login exchanges a username and password for a bearer token. A wrong password and an unknown user fail alike.
+func (s service) Login(ctx context.Context, credentials *models.Credentials) (models.Token, error) {
+	hash := dummyHash
+
+	user, err := s.user.GetByUsername(ctx, credentials.Username)
+
+	switch {
+	case err == nil:
+		hash = user.PasswordHash
+	case err != sql.ErrNoRows:
+		return models.Token{}, errors.Internal{Err: err}
+	}
+
+	match := bcrypt.CompareHashAndPassword([]byte(hash), []byte(credentials.Password)) == nil
+	if err != nil || !match {
+		return models.Token{}, errors.Unauthenticated{Reason: "invalid username or password"}
+	}
+
+	return s.tokens.Issue(user.Username, user.Role)
+}
+
+// Link makes username a guardian of the student, so that a parent can see the student.
+func (s service) Link(ctx context.Context, username string, studentID int) error {
+	if username == "" || studentID <= 0 {
+		return errors.InvalidParam{Field: "guardian", Reason: "needs a username and a student id"}
+	}
+
+	if err := s.user.AddStudent(ctx, username, studentID); err != nil {
+		return errors.Internal{Err: err}
+	}
+
+	return nil
+}
+
+func (s service) validate(username, password, role string) error {
+	var errs []errors.FieldError
+
+	switch {
+	case username == "":
+		errs = append(errs, errors.FieldError{Field: "username", Rule: "required", Message: "username is required"})
+	case len(username) > maxUsername || !checkUsername(username):
+		errs = append(errs, errors.FieldError{Field: "username", Rule: "username",
+			Message: "username must be at most 64 letters, digits, dots, hyphens, underscores or @"})
+	}
+
+	if len(password) < minPassword || len(password) > maxPassword {
+		errs = append(errs, errors.FieldError{Field: "password", Rule: "length",
+			Message: "password must be between 8 and 72 bytes"})
+	}
+
+	if !s.roles.HasRole(role) {
+		errs = append(errs, errors.FieldError{Field: "role", Rule: "one_of", Message: "role " + role + " is not configured"})
+	}
+
+	if len(errs) > 0 {
+		return errors.Validation{Errors: errs}
+	}
+
+	return nil
+}
+
+func checkUsername(username string) bool {
+	for _, r := range username {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '.', r == '-', r == '_', r == '@':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,159 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	stdErrors "errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"student-management-system/errors"
+	"student-management-system/models"
+	"student-management-system/store"
+
+	"github.com/golang/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fakeIssuer hands out a token naming its subject and role.
+type fakeIssuer struct{}
+
+func (fakeIssuer) Issue(subject, role string) (models.Token, error) {
+	return models.Token{AccessToken: "token-for-" + subject + "-as-" + role, TokenType: "Bearer", ExpiresIn: 3600}, nil
+}
+
+// fakeRoles knows the roles admin and parent.
+type fakeRoles struct{}
+
+func (fakeRoles) HasRole(role string) bool {
+	return role == "admin" || role == "parent"
+}
+
+func TestLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := store.NewMockUser(ctrl)
+	mock := New(mockStore, fakeIssuer{}, fakeRoles{})
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret-pass"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admin := models.User{ID: 1, Username: "admin", PasswordHash: string(hash), Role: "admin"}
+	invalid := errors.Unauthenticated{Reason: "invalid username or password"}
+
+	testcases := []struct {
+		desc     string
+		password string
+		user     models.User
+		storeErr error
+		expRes   models.Token
+		expErr   error
+	}{
+		{desc: "success:token issued", password: "s3cret-pass", user: admin,
+			expRes: models.Token{AccessToken: "token-for-admin-as-admin", TokenType: "Bearer", ExpiresIn: 3600}},
+		{desc: "failure:wrong password", password: "wrong-pass", user: admin, expErr: invalid},
+		{desc: "failure:unknown user", password: "s3cret-pass", storeErr: sql.ErrNoRows, expErr: invalid},
+		{desc: "failure:store error", password: "s3cret-pass", storeErr: stdErrors.New("connection reset"),
+			expErr: errors.Internal{Err: stdErrors.New("connection reset")}},
+	}
+
+	for i, tc := range testcases {
+		ctx := context.Background()
+
+		mockStore.EXPECT().GetByUsername(ctx, "admin").Return(tc.user, tc.storeErr)
+
+		res, err := mock.Login(ctx, &models.Credentials{Username: "admin", Password: tc.password})
+
+		if !reflect.DeepEqual(tc.expRes, res) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes, res)
+		}
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
+
+func TestCreate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := store.NewMockUser(ctrl)
+	mock := New(mockStore, fakeIssuer{}, fakeRoles{})
+	mock.now = func() time.Time { return time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC) }
+
+	ctx := context.Background()
+
+	testcases := []struct {
+		desc     string
+		username string
+		password string
+		role     string
+		exists   bool
+		expErr   error
+	}{
+		{desc: "success:created", username: "admin", password: "s3cret-pass", role: "admin"},
+		{desc: "failure:taken", username: "admin", password: "s3cret-pass", role: "admin", exists: true,
+			expErr: errors.EntityAlreadyExists{Entity: "user"}},
+		{desc: "failure:invalid", username: "ad min", password: "short", role: "janitor", expErr: errors.Validation{Errors: []errors.FieldError{
+			{Field: "username", Rule: "username", Message: "username must be at most 64 letters, digits, dots, hyphens, underscores or @"},
+			{Field: "password", Rule: "length", Message: "password must be between 8 and 72 bytes"},
+			{Field: "role", Rule: "one_of", Message: "role janitor is not configured"},
+		}}},
+	}
+
+	for i, tc := range testcases {
+		if tc.expErr == nil || tc.exists {
+			getErr := sql.ErrNoRows
+			if tc.exists {
+				getErr = nil
+			}
+
+			mockStore.EXPECT().GetByUsername(ctx, tc.username).Return(models.User{}, getErr)
+		}
+
+		if tc.expErr == nil {
+			mockStore.EXPECT().Post(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, u *models.User) (models.User, error) {
+				if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(tc.password)) != nil || u.Role != tc.role {
+					t.Errorf("testcases %d failed the password is not stored as its hash or the role is lost", i+1)
+				}
+
+				u.ID = 1
+
+				return *u, nil
+			})
+		}
+
+		_, err := mock.Create(ctx, tc.username, tc.password, tc.role)
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
+
+func TestLink(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := store.NewMockUser(ctrl)
+	mock := New(mockStore, fakeIssuer{}, fakeRoles{})
+
+	ctx := context.Background()
+
+	mockStore.EXPECT().AddStudent(ctx, "parent", 7).Return(nil)
+
+	if err := mock.Link(ctx, "parent", 7); err != nil {
+		t.Errorf("expected %v got %v", nil, err)
+	}
+
+	expErr := errors.InvalidParam{Field: "guardian", Reason: "needs a username and a student id"}
+
+	if err := mock.Link(ctx, "parent", 0); !reflect.DeepEqual(expErr, err) {
+		t.Errorf("expected %v got %v", expErr, err)
+	}
+}
@@ -0,0 +1,157 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"student-management-system/models"
+	"student-management-system/store/sqltx"
+)
+
+// sealer encrypts the values of the sensitive fields in the recorded changes.
+type sealer interface {
+	Seal(column, plaintext string) (string, error)
+	Open(column, value string) (string, error)
+}
+
+type store struct {
+	db     *sql.DB
+	sealer sealer
+}
+
+// New returns the audit store, which keeps the changes of the fields the student store encrypts encrypted with
+// s as well. The audit log cannot be re-encrypted, so s must never lose the keys it sealed the log under, see
+// envelope.LoadAudit.
+func New(db *sql.DB, s sealer) store {
+	return store{db: db, sealer: s}
+}
+
+// Create appends the entry to the audit log, within the transaction of ctx if there is one.
+func (s store) Create(ctx context.Context, entry *models.AuditEntry) error {
+	sealed := make([]models.Change, len(entry.Changes))
+
+	for i, c := range entry.Changes {
+		var err error
+
+		sealed[i] = c
+
+		if sensitive(c.Field) {
+			if sealed[i].Before, err = s.seal(c.Field, c.Before); err != nil {
+				return err
+			}
+
+			if sealed[i].After, err = s.seal(c.Field, c.After); err != nil {
+				return err
+			}
+		}
+	}
+
+	changes, err := json.Marshal(sealed)
+	if err != nil {
+		return err
+	}
+
+	query := "insert into " + string(models.AuditTableName) + " (student_id,version,action,actor,request_id,at,changes) " +
+		"values (?,?,?,?,?,?,?);"
+
+	res, err := sqltx.From(ctx, s.db).ExecContext(ctx, query, entry.StudentID, entry.Version, entry.Action, entry.Actor,
+		entry.RequestID, entry.At, changes)
+	if err != nil {
+		return err
+	}
+
+	entry.ID, err = res.LastInsertId()
+
+	return err
+}
+
+// GetByStudentID returns the audit log of a student, oldest change first.
+func (s store) GetByStudentID(ctx context.Context, id int) ([]models.AuditEntry, error) {
+	query := "select id,student_id,version,action,actor,request_id,at,changes from " + string(models.AuditTableName) +
+		" where student_id = ? order by id;"
+
+	rows, err := sqltx.From(ctx, s.db).QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	entries := make([]models.AuditEntry, 0)
+
+	for rows.Next() {
+		var (
+			entry   models.AuditEntry
+			changes []byte
+		)
+
+		err := rows.Scan(&entry.ID, &entry.StudentID, &entry.Version, &entry.Action, &entry.Actor, &entry.RequestID,
+			&entry.At, &changes)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+			return nil, err
+		}
+
+		for j, c := range entry.Changes {
+			if !sensitive(c.Field) {
+				continue
+			}
+
+			if entry.Changes[j].Before, err = s.open(c.Field, c.Before); err != nil {
+				return nil, err
+			}
+
+			if entry.Changes[j].After, err = s.open(c.Field, c.After); err != nil {
+				return nil, err
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// sensitive reports whether field is one of the columns the student store encrypts.
+func sensitive(field string) bool {
+	switch field {
+	case "father_name", "mother_name", "contact_number", "home_contact_number", "emergency_contact_number", "family_income":
+		return true
+	default:
+		return false
+	}
+}
+
+// seal replaces a recorded value with the JSON string of its sealed form.
+func (s store) seal(field string, value json.RawMessage) (json.RawMessage, error) {
+	if len(value) == 0 {
+		return value, nil
+	}
+
+	sealed, err := s.sealer.Seal(field, string(value))
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(sealed)
+}
+
+// open restores a value recorded by seal. Values recorded before encryption are returned as they are, which
+// the sealer tells apart by returning them unchanged.
+func (s store) open(field string, value json.RawMessage) (json.RawMessage, error) {
+	var sealed string
+	if err := json.Unmarshal(value, &sealed); err != nil {
+		return value, nil
+	}
+
+	plain, err := s.sealer.Open(field, sealed)
+	if err != nil || plain == sealed {
+		return value, err
+	}
+
+	return json.RawMessage(plain), nil
+}
@@ -0,0 +1,196 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"student-management-system/models"
+	"student-management-system/store/envelope"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// marked is a sealer that marks the values it seals instead of encrypting them, so that queries can be matched.
+type marked struct{}
+
+func (marked) Seal(column, plaintext string) (string, error) {
+	return "sealed:" + plaintext, nil
+}
+
+func (marked) Open(column, value string) (string, error) {
+	return strings.TrimPrefix(value, "sealed:"), nil
+}
+
+func TestCreate(t *testing.T) {
+	at := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	query := "insert into " + string(models.AuditTableName) + " (student_id,version,action,actor,request_id,at,changes) " +
+		"values (?,?,?,?,?,?,?);"
+
+	testcases := []struct {
+		desc       string
+		entry      models.AuditEntry
+		expChanges string
+		expID      int64
+		expErr     error
+	}{
+		{desc: "success:appended", entry: models.AuditEntry{StudentID: 1, Version: 2, Action: models.ActionUpdate,
+			Actor: "admin", RequestID: "req-1", At: at, Changes: []models.Change{
+				{Field: "last_name", Before: json.RawMessage(`"yadav"`), After: json.RawMessage(`"kumar"`)},
+				{Field: "family_income", Before: json.RawMessage(`0`), After: json.RawMessage(`500`)}}},
+			expChanges: `[{"field":"last_name","before":"yadav","after":"kumar"},` +
+				`{"field":"family_income","before":"sealed:0","after":"sealed:500"}]`, expID: 7},
+		{desc: "failure:insert error", entry: models.AuditEntry{StudentID: 1, Version: 1, Action: models.ActionCreate,
+			Actor: "admin", At: at, Changes: []models.Change{}}, expChanges: `[]`, expErr: errors.New("insert error")},
+	}
+
+	for i, tc := range testcases {
+		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			log.Println(err.Error())
+		}
+
+		mock.ExpectExec(query).WithArgs(tc.entry.StudentID, tc.entry.Version, tc.entry.Action, tc.entry.Actor,
+			tc.entry.RequestID, tc.entry.At, []byte(tc.expChanges)).WillReturnResult(sqlmock.NewResult(tc.expID, 1)).WillReturnError(tc.expErr)
+
+		s := New(db, marked{})
+
+		err = s.Create(context.TODO(), &tc.entry)
+
+		if tc.entry.ID != tc.expID {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expID, tc.entry.ID)
+		}
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
+
+func TestGetByStudentID(t *testing.T) {
+	at := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	query := "select id,student_id,version,action,actor,request_id,at,changes from " + string(models.AuditTableName) +
+		" where student_id = ? order by id;"
+	cols := []string{"id", "student_id", "version", "action", "actor", "request_id", "at", "changes"}
+
+	testcases := []struct {
+		desc      string
+		expRows   *sqlmock.Rows
+		expOutput []models.AuditEntry
+		expErr    error
+	}{
+		{desc: "success:history", expRows: sqlmock.NewRows(cols).
+			AddRow(7, 1, 1, "create", "admin", "req-1", at, []byte(`[]`)).
+			AddRow(8, 1, 2, "update", "admin", "req-2", at, []byte(`[{"field":"gender","before":"","after":"M"},`+
+				`{"field":"contact_number","before":"+917348761063","after":"sealed:\"+917348761064\""}]`)),
+			expOutput: []models.AuditEntry{
+				{ID: 7, StudentID: 1, Version: 1, Action: models.ActionCreate, Actor: "admin", RequestID: "req-1", At: at,
+					Changes: []models.Change{}},
+				{ID: 8, StudentID: 1, Version: 2, Action: models.ActionUpdate, Actor: "admin", RequestID: "req-2", At: at,
+					Changes: []models.Change{{Field: "gender", Before: json.RawMessage(`""`), After: json.RawMessage(`"M"`)},
+						{Field: "contact_number", Before: json.RawMessage(`"+917348761063"`),
+							After: json.RawMessage(`"+917348761064"`)}}},
+			}},
+		{desc: "success:no history", expRows: sqlmock.NewRows(cols), expOutput: []models.AuditEntry{}},
+		{desc: "failure:select error", expRows: sqlmock.NewRows(cols), expErr: errors.New("select error")},
+	}
+
+	for i, tc := range testcases {
+		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			log.Println(err.Error())
+		}
+
+		mock.ExpectQuery(query).WithArgs(1).WillReturnRows(tc.expRows).WillReturnError(tc.expErr)
+
+		s := New(db, marked{})
+
+		res, err := s.GetByStudentID(context.TODO(), 1)
+
+		if !reflect.DeepEqual(tc.expOutput, res) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expOutput, res)
+		}
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
+
+// captured matches any argument and keeps it.
+type captured struct {
+	value driver.Value
+}
+
+func (c *captured) Match(v driver.Value) bool {
+	c.value = v
+
+	return true
+}
+
+func TestGetByStudentID_AfterRotation(t *testing.T) {
+	at := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	key := func(b byte) string { return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32)) }
+
+	// the key of the students is rotated from k1 to k2 and k1 removed, the audit key stays
+	before, after := filepath.Join(dir, "before.json"), filepath.Join(dir, "after.json")
+	files := map[string]string{
+		before: `{"active": "k1", "keys": {"k1": "` + key(1) + `"}, "index_key": "` + key(3) + `", "audit_key": "` + key(4) + `"}`,
+		after:  `{"active": "k2", "keys": {"k2": "` + key(2) + `"}, "index_key": "` + key(3) + `", "audit_key": "` + key(4) + `"}`,
+	}
+
+	for name, file := range files {
+		if err := os.WriteFile(name, []byte(file), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		log.Println(err.Error())
+	}
+
+	changes := &captured{}
+
+	insert := "insert into " + string(models.AuditTableName) + " (student_id,version,action,actor,request_id,at,changes) " +
+		"values (?,?,?,?,?,?,?);"
+	mock.ExpectExec(insert).WithArgs(1, 2, models.ActionUpdate, "admin", "", at, changes).WillReturnResult(sqlmock.NewResult(7, 1))
+
+	keyring, err := envelope.LoadAudit(before)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := models.AuditEntry{StudentID: 1, Version: 2, Action: models.ActionUpdate, Actor: "admin", At: at,
+		Changes: []models.Change{{Field: "contact_number", Before: json.RawMessage(`"+917348761063"`),
+			After: json.RawMessage(`"+917348761064"`)}}}
+
+	if err := New(db, keyring).Create(context.TODO(), &entry); err != nil {
+		t.Fatal(err)
+	}
+
+	query := "select id,student_id,version,action,actor,request_id,at,changes from " + string(models.AuditTableName) +
+		" where student_id = ? order by id;"
+	mock.ExpectQuery(query).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "student_id", "version", "action",
+		"actor", "request_id", "at", "changes"}).AddRow(7, 1, 2, "update", "admin", "", at, changes.value))
+
+	if keyring, err = envelope.LoadAudit(after); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := New(db, keyring).GetByStudentID(context.TODO(), 1)
+	if err != nil || len(res) != 1 || !reflect.DeepEqual(res[0].Changes, entry.Changes) {
+		t.Errorf("expected %v got %v, %v", entry.Changes, res, err)
+	}
+}
@@ -0,0 +1,265 @@
+// Package envelope encrypts column values with envelope encryption: every value is sealed with AES-GCM under
+// a fresh data key, and the data key is wrapped with AES-GCM under a key-encryption key of the keyring. It also
+// computes the blind indexes that let exact-match lookups find encrypted values.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// prefix starts every sealed value, telling it apart from the plaintext written before encryption.
+	prefix = "enc:v1:"
+
+	keySize = 32
+	// auditKeyID is the ID the values of the audit log are sealed under, see LoadAudit.
+	auditKeyID = "audit"
+	// indexSize is how many bytes of the HMAC a blind index keeps, enough to make collisions negligible.
+	indexSize = 16
+)
+
+var errMalformed = errors.New("malformed sealed value")
+
+type keyring struct {
+	active string
+	keys   map[string]cipher.AEAD
+	index  []byte
+}
+
+// file is the keyfile: the key-encryption keys by ID, the one new values are sealed under, the blind index key and
+// the audit key, all base64 encoded 32 byte keys.
+type file struct {
+	Active   string            `json:"active"`
+	Keys     map[string]string `json:"keys"`
+	IndexKey string            `json:"index_key"`
+	AuditKey string            `json:"audit_key"`
+}
+
+// Load reads a keyring from a keyfile. Retired keys stay in the file to open the values sealed under them.
+func Load(name string) (keyring, error) {
+	f, index, err := read(name)
+	if err != nil {
+		return keyring{}, err
+	}
+
+	keys := make(map[string][]byte, len(f.Keys))
+
+	for id, k := range f.Keys {
+		if keys[id], err = base64.StdEncoding.DecodeString(k); err != nil {
+			return keyring{}, fmt.Errorf("keyfile %s: key %q: %w", name, id, err)
+		}
+	}
+
+	k, err := New(f.Active, keys, index)
+	if err != nil {
+		return keyring{}, fmt.Errorf("keyfile %s: %w", name, err)
+	}
+
+	return k, nil
+}
+
+// LoadAudit reads the keyring of the audit log from a keyfile, which holds the audit key only. The audit log is
+// append-only and cannot be re-encrypted, so its values are sealed under a key of their own that is never retired,
+// and the keys of the students can be rotated and removed without losing their history.
+func LoadAudit(name string) (keyring, error) {
+	f, index, err := read(name)
+	if err != nil {
+		return keyring{}, err
+	}
+
+	key, err := base64.StdEncoding.DecodeString(f.AuditKey)
+	if err != nil {
+		return keyring{}, fmt.Errorf("keyfile %s: audit key: %w", name, err)
+	}
+
+	k, err := New(auditKeyID, map[string][]byte{auditKeyID: key}, index)
+	if err != nil {
+		return keyring{}, fmt.Errorf("keyfile %s: audit key: %w", name, err)
+	}
+
+	return k, nil
+}
+
+// read parses a keyfile and decodes its blind index key.
+func read(name string) (file, []byte, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return file{}, nil, err
+	}
+
+	var f file
+
+	if err := json.Unmarshal(b, &f); err != nil {
+		return file{}, nil, fmt.Errorf("keyfile %s: %w", name, err)
+	}
+
+	index, err := base64.StdEncoding.DecodeString(f.IndexKey)
+	if err != nil {
+		return file{}, nil, fmt.Errorf("keyfile %s: index key: %w", name, err)
+	}
+
+	return f, index, nil
+}
+
+// New builds a keyring that seals new values under the key-encryption key active of keys.
+func New(active string, keys map[string][]byte, index []byte) (keyring, error) {
+	if _, ok := keys[active]; !ok {
+		return keyring{}, fmt.Errorf("active key %q is not in the keys", active)
+	}
+
+	if len(index) != keySize {
+		return keyring{}, fmt.Errorf("index key must be %d bytes", keySize)
+	}
+
+	k := keyring{active: active, keys: make(map[string]cipher.AEAD, len(keys)), index: index}
+
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return keyring{}, fmt.Errorf("key id %q must be non-empty and without colons", id)
+		}
+
+		if len(key) != keySize {
+			return keyring{}, fmt.Errorf("key %q must be %d bytes", id, keySize)
+		}
+
+		aead, err := newAEAD(key)
+		if err != nil {
+			return keyring{}, err
+		}
+
+		k.keys[id] = aead
+	}
+
+	return k, nil
+}
+
+// Seal encrypts the value of column under a new data key. The column is authenticated with the value, so a
+// sealed value cannot be moved to another column. Empty values are left empty.
+func (k keyring) Seal(column, plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	dek := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return "", err
+	}
+
+	wrapped, err := seal(k.keys[k.active], dek, []byte(k.active))
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := seal(aead, []byte(plaintext), []byte(column))
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + k.active + ":" + base64.RawStdEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Open decrypts a value sealed for column. Values that are not sealed are returned as they are, so that rows
+// written before encryption stay readable until they are re-encrypted.
+func (k keyring) Open(column, value string) (string, error) {
+	if !strings.HasPrefix(value, prefix) {
+		return value, nil
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", errMalformed
+	}
+
+	kek, ok := k.keys[parts[0]]
+	if !ok {
+		return "", fmt.Errorf("value sealed under unknown key %q", parts[0])
+	}
+
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", errMalformed
+	}
+
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errMalformed
+	}
+
+	dek, err := open(kek, wrapped, []byte(parts[0]))
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := open(aead, ciphertext, []byte(column))
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// Current reports whether value is empty or sealed under the active key, and so needs no re-encryption.
+func (k keyring) Current(value string) bool {
+	return value == "" || strings.HasPrefix(value, prefix+k.active+":")
+}
+
+// Index returns the blind index of the value of column: a keyed hash that is equal for equal values, case
+// insensitively like the column collation, without revealing them.
+func (k keyring) Index(column, value string) string {
+	mac := hmac.New(sha256.New, k.index)
+	mac.Write([]byte(column))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strings.ToLower(value)))
+
+	return hex.EncodeToString(mac.Sum(nil)[:indexSize])
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext under a random nonce, which is prepended to the ciphertext.
+func seal(aead cipher.AEAD, plaintext, additional []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+func open(aead cipher.AEAD, ciphertext, additional []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errMalformed
+	}
+
+	return aead.Open(nil, ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():], additional)
+}
@@ -0,0 +1,133 @@
+package envelope
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testKeyring(t *testing.T, active string) keyring {
+	k, err := New(active, map[string][]byte{"old": bytes.Repeat([]byte{1}, keySize),
+		"new": bytes.Repeat([]byte{2}, keySize)}, bytes.Repeat([]byte{3}, keySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return k
+}
+
+func TestSealOpen(t *testing.T) {
+	old, current := testKeyring(t, "old"), testKeyring(t, "new")
+
+	sealed, err := old.Seal("contact_number", "+917348761063")
+	if err != nil || !strings.HasPrefix(sealed, "enc:v1:old:") || strings.Contains(sealed, "7348761063") {
+		t.Fatalf("expected a value sealed under the old key got %v, %v", sealed, err)
+	}
+
+	again, _ := old.Seal("contact_number", "+917348761063")
+	if again == sealed {
+		t.Errorf("expected a new data key and nonce for every value")
+	}
+
+	testcases := []struct {
+		desc     string
+		column   string
+		value    string
+		expValue string
+		expErr   bool
+	}{
+		{desc: "opened with a retired key", column: "contact_number", value: sealed, expValue: "+917348761063"},
+		{desc: "plaintext", column: "contact_number", value: "+917348761063", expValue: "+917348761063"},
+		{desc: "moved to another column", column: "home_contact_number", value: sealed, expErr: true},
+		{desc: "tampered", column: "contact_number", value: sealed[:len(sealed)-2] + "AA", expErr: true},
+		{desc: "unknown key", column: "contact_number", value: strings.Replace(sealed, ":old:", ":gone:", 1), expErr: true},
+		{desc: "malformed", column: "contact_number", value: "enc:v1:old:abc", expErr: true},
+	}
+
+	for i, tc := range testcases {
+		res, err := current.Open(tc.column, tc.value)
+
+		if res != tc.expValue || (err != nil) != tc.expErr {
+			t.Errorf("testcases %d failed expected %v got %v, %v", i+1, tc.expValue, res, err)
+		}
+	}
+
+	if current.Current(sealed) || old.Current("+917348761063") || !old.Current(sealed) || !current.Current("") {
+		t.Errorf("expected only values sealed under the active key to be current")
+	}
+}
+
+func TestIndex(t *testing.T) {
+	k := testKeyring(t, "new")
+
+	if k.Index("father_name", "Ram") != k.Index("father_name", "ram") {
+		t.Errorf("expected the index to ignore case")
+	}
+
+	if k.Index("father_name", "ram") == k.Index("mother_name", "ram") || k.Index("father_name", "ram") ==
+		k.Index("father_name", "rama") {
+		t.Errorf("expected different indexes for different columns and values")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	testcases := []struct {
+		desc   string
+		file   string
+		expErr string
+	}{
+		{desc: "valid", file: `{"active": "k1", "keys": {"k1": "` + strings.Repeat("A", 43) + `="}, "index_key": "` +
+			strings.Repeat("B", 43) + `="}`},
+		{desc: "unknown active key", file: `{"active": "k2", "keys": {"k1": "` + strings.Repeat("A", 43) + `="}, "index_key": "` +
+			strings.Repeat("B", 43) + `="}`, expErr: `active key "k2" is not in the keys`},
+		{desc: "short key", file: `{"active": "k1", "keys": {"k1": "AAAA"}, "index_key": "` + strings.Repeat("B", 43) + `="}`,
+			expErr: `key "k1" must be 32 bytes`},
+		{desc: "no index key", file: `{"active": "k1", "keys": {"k1": "` + strings.Repeat("A", 43) + `="}}`,
+			expErr: "index key must be 32 bytes"},
+	}
+
+	for i, tc := range testcases {
+		name := filepath.Join(dir, "keys.json")
+		if err := os.WriteFile(name, []byte(tc.file), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := Load(name)
+
+		if tc.expErr == "" && err != nil || tc.expErr != "" && (err == nil || !strings.HasSuffix(err.Error(), tc.expErr)) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
+
+func TestLoadAudit(t *testing.T) {
+	dir := t.TempDir()
+
+	testcases := []struct {
+		desc   string
+		file   string
+		expErr string
+	}{
+		{desc: "valid", file: `{"active": "k1", "keys": {"k1": "` + strings.Repeat("A", 43) + `="}, "index_key": "` +
+			strings.Repeat("B", 43) + `=", "audit_key": "` + strings.Repeat("C", 43) + `="}`},
+		{desc: "no audit key", file: `{"active": "k1", "keys": {"k1": "` + strings.Repeat("A", 43) + `="}, "index_key": "` +
+			strings.Repeat("B", 43) + `="}`, expErr: `audit key: key "audit" must be 32 bytes`},
+	}
+
+	for i, tc := range testcases {
+		name := filepath.Join(dir, "keys.json")
+		if err := os.WriteFile(name, []byte(tc.file), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := LoadAudit(name)
+
+		if tc.expErr == "" && err != nil || tc.expErr != "" && (err == nil || !strings.HasSuffix(err.Error(), tc.expErr)) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package store
+
+import "fmt"
+
+// Duplicate is returned by a write that would give a student the identity of another active student, named by
+// ID, or 0 when it could not be found.
+type Duplicate struct {
+	ID int
+}
+
+func (d Duplicate) Error() string {
+	return fmt.Sprintf("duplicate of student %d", d.ID)
+}
+
+// Duplicates is returned by a batch insert of students some of which have the identity of an active student.
+// IDs maps the position in the batch of each of them to the ID of the student it duplicates.
+type Duplicates struct {
+	IDs map[int]int
+}
+
+func (d Duplicates) Error() string {
+	return fmt.Sprintf("%d duplicate students in the batch", len(d.IDs))
+}
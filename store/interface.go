@@ -2,17 +2,39 @@ package store
 
 import (
 	"context"
+	"time"
 
 	"student-management-system/models"
 )
 
+// Audit is the append-only log of changes to students.
+type Audit interface {
+	Create(ctx context.Context, entry *models.AuditEntry) error
+	GetByStudentID(ctx context.Context, id int) ([]models.AuditEntry, error)
+}
+
+// Transactor runs fn in a transaction that the stores join when given the context fn is called with.
+type Transactor interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 type Student interface {
-	Delete(ctx context.Context, id int) error
-	Get(ctx context.Context) ([]models.Student, error)
+	Count(ctx context.Context, filter *models.Filter) (int, error)
+	Delete(ctx context.Context, id, version int) error
+	Each(ctx context.Context, filter *models.Filter, fn func(student models.Student) error) error
+	Get(ctx context.Context, filter *models.Filter) ([]models.Student, error)
 	GetByID(ctx context.Context, id int) (models.Student, error)
-	GetByLastName(ctx context.Context, lastName string) ([]models.Student, error)
-	GetByFirstName(ctx context.Context, firstName string) ([]models.Student, error)
-	GetByFirstAndLastName(ctx context.Context, firstName, lastName string) ([]models.Student, error)
+	Patch(ctx context.Context, id int, student *models.Student, columns []string) (models.Student, error)
 	Post(ctx context.Context, student *models.Student) (models.Student, error)
+	PostBatch(ctx context.Context, students []models.Student) ([]models.Student, error)
+	Purge(ctx context.Context, before time.Time) (int, error)
 	Put(ctx context.Context, id int, student *models.Student) (models.Student, error)
+	Restore(ctx context.Context, id int) error
+}
+
+type User interface {
+	AddStudent(ctx context.Context, username string, studentID int) error
+	GetByUsername(ctx context.Context, username string) (models.User, error)
+	GetStudentIDs(ctx context.Context, username string) ([]int, error)
+	Post(ctx context.Context, user *models.User) (models.User, error)
 }
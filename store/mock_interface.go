@@ -8,10 +8,100 @@ import (
 	context "context"
 	reflect "reflect"
 	models "student-management-system/models"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
 
+// MockAudit is a mock of Audit interface.
+type MockAudit struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditMockRecorder
+}
+
+// MockAuditMockRecorder is the mock recorder for MockAudit.
+type MockAuditMockRecorder struct {
+	mock *MockAudit
+}
+
+// NewMockAudit creates a new mock instance.
+func NewMockAudit(ctrl *gomock.Controller) *MockAudit {
+	mock := &MockAudit{ctrl: ctrl}
+	mock.recorder = &MockAuditMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAudit) EXPECT() *MockAuditMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockAudit) Create(ctx context.Context, entry *models.AuditEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockAuditMockRecorder) Create(ctx, entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockAudit)(nil).Create), ctx, entry)
+}
+
+// GetByStudentID mocks base method.
+func (m *MockAudit) GetByStudentID(ctx context.Context, id int) ([]models.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByStudentID", ctx, id)
+	ret0, _ := ret[0].([]models.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByStudentID indicates an expected call of GetByStudentID.
+func (mr *MockAuditMockRecorder) GetByStudentID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByStudentID", reflect.TypeOf((*MockAudit)(nil).GetByStudentID), ctx, id)
+}
+
+// MockTransactor is a mock of Transactor interface.
+type MockTransactor struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactorMockRecorder
+}
+
+// MockTransactorMockRecorder is the mock recorder for MockTransactor.
+type MockTransactorMockRecorder struct {
+	mock *MockTransactor
+}
+
+// NewMockTransactor creates a new mock instance.
+func NewMockTransactor(ctrl *gomock.Controller) *MockTransactor {
+	mock := &MockTransactor{ctrl: ctrl}
+	mock.recorder = &MockTransactorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransactor) EXPECT() *MockTransactorMockRecorder {
+	return m.recorder
+}
+
+// InTx mocks base method.
+func (m *MockTransactor) InTx(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InTx indicates an expected call of InTx.
+func (mr *MockTransactorMockRecorder) InTx(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InTx", reflect.TypeOf((*MockTransactor)(nil).InTx), ctx, fn)
+}
+
 // MockStudent is a mock of Student interface.
 type MockStudent struct {
 	ctrl     *gomock.Controller
@@ -35,63 +125,62 @@ func (m *MockStudent) EXPECT() *MockStudentMockRecorder {
 	return m.recorder
 }
 
-// Delete mocks base method.
-func (m *MockStudent) Delete(ctx context.Context, id int) error {
+// Count mocks base method.
+func (m *MockStudent) Count(ctx context.Context, filter *models.Filter) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", ctx, id)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "Count", ctx, filter)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// Delete indicates an expected call of Delete.
-func (mr *MockStudentMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+// Count indicates an expected call of Count.
+func (mr *MockStudentMockRecorder) Count(ctx, filter interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockStudent)(nil).Delete), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Count", reflect.TypeOf((*MockStudent)(nil).Count), ctx, filter)
 }
 
-// Get mocks base method.
-func (m *MockStudent) Get(ctx context.Context) ([]models.Student, error) {
+// Delete mocks base method.
+func (m *MockStudent) Delete(ctx context.Context, id, version int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", ctx)
-	ret0, _ := ret[0].([]models.Student)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "Delete", ctx, id, version)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// Get indicates an expected call of Get.
-func (mr *MockStudentMockRecorder) Get(ctx interface{}) *gomock.Call {
+// Delete indicates an expected call of Delete.
+func (mr *MockStudentMockRecorder) Delete(ctx, id, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockStudent)(nil).Get), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockStudent)(nil).Delete), ctx, id, version)
 }
 
-// GetByFirstAndLastName mocks base method.
-func (m *MockStudent) GetByFirstAndLastName(ctx context.Context, firstName, lastName string) ([]models.Student, error) {
+// Each mocks base method.
+func (m *MockStudent) Each(ctx context.Context, filter *models.Filter, fn func(models.Student) error) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByFirstAndLastName", ctx, firstName, lastName)
-	ret0, _ := ret[0].([]models.Student)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "Each", ctx, filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// GetByFirstAndLastName indicates an expected call of GetByFirstAndLastName.
-func (mr *MockStudentMockRecorder) GetByFirstAndLastName(ctx, firstName, lastName interface{}) *gomock.Call {
+// Each indicates an expected call of Each.
+func (mr *MockStudentMockRecorder) Each(ctx, filter, fn interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByFirstAndLastName", reflect.TypeOf((*MockStudent)(nil).GetByFirstAndLastName), ctx, firstName, lastName)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Each", reflect.TypeOf((*MockStudent)(nil).Each), ctx, filter, fn)
 }
 
-// GetByFirstName mocks base method.
-func (m *MockStudent) GetByFirstName(ctx context.Context, firstName string) ([]models.Student, error) {
+// Get mocks base method.
+func (m *MockStudent) Get(ctx context.Context, filter *models.Filter) ([]models.Student, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByFirstName", ctx, firstName)
+	ret := m.ctrl.Call(m, "Get", ctx, filter)
 	ret0, _ := ret[0].([]models.Student)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetByFirstName indicates an expected call of GetByFirstName.
-func (mr *MockStudentMockRecorder) GetByFirstName(ctx, firstName interface{}) *gomock.Call {
+// Get indicates an expected call of Get.
+func (mr *MockStudentMockRecorder) Get(ctx, filter interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByFirstName", reflect.TypeOf((*MockStudent)(nil).GetByFirstName), ctx, firstName)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockStudent)(nil).Get), ctx, filter)
 }
 
 // GetByID mocks base method.
@@ -109,19 +198,19 @@ func (mr *MockStudentMockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockStudent)(nil).GetByID), ctx, id)
 }
 
-// GetByLastName mocks base method.
-func (m *MockStudent) GetByLastName(ctx context.Context, lastName string) ([]models.Student, error) {
+// Patch mocks base method.
+func (m *MockStudent) Patch(ctx context.Context, id int, student *models.Student, columns []string) (models.Student, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByLastName", ctx, lastName)
-	ret0, _ := ret[0].([]models.Student)
+	ret := m.ctrl.Call(m, "Patch", ctx, id, student, columns)
+	ret0, _ := ret[0].(models.Student)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetByLastName indicates an expected call of GetByLastName.
-func (mr *MockStudentMockRecorder) GetByLastName(ctx, lastName interface{}) *gomock.Call {
+// Patch indicates an expected call of Patch.
+func (mr *MockStudentMockRecorder) Patch(ctx, id, student, columns interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByLastName", reflect.TypeOf((*MockStudent)(nil).GetByLastName), ctx, lastName)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockStudent)(nil).Patch), ctx, id, student, columns)
 }
 
 // Post mocks base method.
@@ -139,6 +228,36 @@ func (mr *MockStudentMockRecorder) Post(ctx, student interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Post", reflect.TypeOf((*MockStudent)(nil).Post), ctx, student)
 }
 
+// PostBatch mocks base method.
+func (m *MockStudent) PostBatch(ctx context.Context, students []models.Student) ([]models.Student, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostBatch", ctx, students)
+	ret0, _ := ret[0].([]models.Student)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PostBatch indicates an expected call of PostBatch.
+func (mr *MockStudentMockRecorder) PostBatch(ctx, students interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostBatch", reflect.TypeOf((*MockStudent)(nil).PostBatch), ctx, students)
+}
+
+// Purge mocks base method.
+func (m *MockStudent) Purge(ctx context.Context, before time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", ctx, before)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockStudentMockRecorder) Purge(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockStudent)(nil).Purge), ctx, before)
+}
+
 // Put mocks base method.
 func (m *MockStudent) Put(ctx context.Context, id int, student *models.Student) (models.Student, error) {
 	m.ctrl.T.Helper()
@@ -153,3 +272,99 @@ func (mr *MockStudentMockRecorder) Put(ctx, id, student interface{}) *gomock.Cal
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockStudent)(nil).Put), ctx, id, student)
 }
+
+// Restore mocks base method.
+func (m *MockStudent) Restore(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockStudentMockRecorder) Restore(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockStudent)(nil).Restore), ctx, id)
+}
+
+// MockUser is a mock of User interface.
+type MockUser struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserMockRecorder
+}
+
+// MockUserMockRecorder is the mock recorder for MockUser.
+type MockUserMockRecorder struct {
+	mock *MockUser
+}
+
+// NewMockUser creates a new mock instance.
+func NewMockUser(ctrl *gomock.Controller) *MockUser {
+	mock := &MockUser{ctrl: ctrl}
+	mock.recorder = &MockUserMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUser) EXPECT() *MockUserMockRecorder {
+	return m.recorder
+}
+
+// AddStudent mocks base method.
+func (m *MockUser) AddStudent(ctx context.Context, username string, studentID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddStudent", ctx, username, studentID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddStudent indicates an expected call of AddStudent.
+func (mr *MockUserMockRecorder) AddStudent(ctx, username, studentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddStudent", reflect.TypeOf((*MockUser)(nil).AddStudent), ctx, username, studentID)
+}
+
+// GetByUsername mocks base method.
+func (m *MockUser) GetByUsername(ctx context.Context, username string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUsername", ctx, username)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByUsername indicates an expected call of GetByUsername.
+func (mr *MockUserMockRecorder) GetByUsername(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUsername", reflect.TypeOf((*MockUser)(nil).GetByUsername), ctx, username)
+}
+
+// GetStudentIDs mocks base method.
+func (m *MockUser) GetStudentIDs(ctx context.Context, username string) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStudentIDs", ctx, username)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStudentIDs indicates an expected call of GetStudentIDs.
+func (mr *MockUserMockRecorder) GetStudentIDs(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStudentIDs", reflect.TypeOf((*MockUser)(nil).GetStudentIDs), ctx, username)
+}
+
+// Post mocks base method.
+func (m *MockUser) Post(ctx context.Context, user *models.User) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Post", ctx, user)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Post indicates an expected call of Post.
+func (mr *MockUserMockRecorder) Post(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Post", reflect.TypeOf((*MockUser)(nil).Post), ctx, user)
+}
@@ -0,0 +1,64 @@
+// Package sqltx runs store calls in a database transaction carried by the context, so that writes made
+// through different stores commit or roll back together.
+package sqltx
+
+import (
+	"context"
+	"database/sql"
+)
+
+type key struct{}
+
+// Conn is what the stores query through: the database, or the transaction of the context.
+type Conn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type transactor struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) transactor {
+	return transactor{db: db}
+}
+
+// InTx calls fn with a context carrying a new transaction, which is committed if fn returns nil and rolled
+// back otherwise, including when fn panics, whose panic goes on once the transaction is rolled back. Called
+// within a transaction, fn joins it instead.
+func (t transactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(key{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, key{}, tx)); err != nil {
+		_ = tx.Rollback()
+
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// From returns the transaction of ctx, or db outside of a transaction.
+func From(ctx context.Context, db *sql.DB) Conn {
+	if tx, ok := ctx.Value(key{}).(*sql.Tx); ok {
+		return tx
+	}
+
+	return db
+}
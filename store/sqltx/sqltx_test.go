@@ -0,0 +1,106 @@
+package sqltx
+
+import (
+	"context"
+	"errors"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestInTx(t *testing.T) {
+	fnErr := errors.New("write error")
+
+	testcases := []struct {
+		desc   string
+		fnErr  error
+		expErr error
+	}{
+		{desc: "success:committed", expErr: nil},
+		{desc: "failure:rolled back", fnErr: fnErr, expErr: fnErr},
+	}
+
+	for i, tc := range testcases {
+		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			log.Println(err.Error())
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("update student set version = 2;").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("insert into student_audit values ();").WillReturnResult(sqlmock.NewResult(1, 1))
+
+		if tc.fnErr == nil {
+			mock.ExpectCommit()
+		} else {
+			mock.ExpectRollback()
+		}
+
+		err = New(db).InTx(context.TODO(), func(ctx context.Context) error {
+			if _, err := From(ctx, db).ExecContext(ctx, "update student set version = 2;"); err != nil {
+				return err
+			}
+
+			// a nested call joins the transaction rather than starting another one
+			return New(db).InTx(ctx, func(ctx context.Context) error {
+				if _, err := From(ctx, db).ExecContext(ctx, "insert into student_audit values ();"); err != nil {
+					return err
+				}
+
+				return tc.fnErr
+			})
+		})
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("testcases %d failed: %v", i+1, err)
+		}
+	}
+}
+
+func TestInTx_Panic(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		log.Println(err.Error())
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("update student set version = 2;").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	defer func() {
+		if p := recover(); p != "write panic" {
+			t.Errorf("expected the panic of fn to go on, got %v", p)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	_ = New(db).InTx(context.TODO(), func(ctx context.Context) error {
+		if _, err := From(ctx, db).ExecContext(ctx, "update student set version = 2;"); err != nil {
+			return err
+		}
+
+		panic("write panic")
+	})
+
+	t.Error("expected InTx to panic")
+}
+
+func TestFrom(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		log.Println(err.Error())
+	}
+
+	if conn := From(context.TODO(), db); conn != db {
+		t.Errorf("expected the database outside of a transaction, got %v", conn)
+	}
+}
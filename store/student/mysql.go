@@ -3,188 +3,412 @@ package student
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"student-management-system/models"
+	stores "student-management-system/store"
+	"student-management-system/store/sqltx"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	// purgeBatch is the most students a single purge statement deletes.
+	purgeBatch = 500
+
+	// erDupEntry is the MySQL error of a write that violates a unique index.
+	erDupEntry = 1062
+	// identityIndex keeps the identities of the active students unique.
+	identityIndex = "idx_student_identity"
 )
 
 type store struct {
-	db *sql.DB
+	db     *sql.DB
+	sealer sealer
 }
 
-func New(db *sql.DB) store {
-	return store{db: db}
+// New returns the student store, which keeps the sensitive columns encrypted with s.
+func New(db *sql.DB, s sealer) store {
+	return store{db: db, sealer: s}
 }
 
-func (s store) Get(ctx context.Context) ([]models.Student, error) {
-	var students []models.Student
+func (s store) Get(ctx context.Context, filter *models.Filter) ([]models.Student, error) {
+	students := make([]models.Student, 0)
 
-	query := "select * from " + string(models.TableName) + ";"
+	err := s.Each(ctx, filter, func(student models.Student) error {
+		students = append(students, student)
 
-	rows, err := s.db.QueryContext(ctx, query)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	return students, nil
+}
+
+// Each calls fn with every student matching the filter in order, reading them from the database one at a time
+// rather than all at once, and stops at the first error fn returns.
+func (s store) Each(ctx context.Context, filter *models.Filter, fn func(student models.Student) error) error {
+	where, args := whereClause(filter, s.sealer.Index)
+
+	query := "select " + columns + " from " + string(models.TableName) + where + orderClause(filter.Sort)
+
+	if filter.Limit > 0 {
+		query += " limit ? offset ?"
+
+		args = append(args, filter.Limit, filter.Offset)
+	}
+
+	rows, err := sqltx.From(ctx, s.db).QueryContext(ctx, query+";", args...)
+	if err != nil {
+		return err
+	}
+
 	defer rows.Close()
 
 	for rows.Next() {
-		var student models.Student
-
-		err := rows.Scan(&student.ID, &student.FirstName, &student.LastName, &student.Gender, &student.Dob, &student.MotherTongue,
-			&student.Nationality, &student.FatherName, &student.MotherName, &student.ContactNumber, &student.FatherOccupation,
-			&student.MotherOccupation, &student.FamilyIncome)
+		student, err := s.scan(rows)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		students = append(students, student)
+		if err := fn(student); err != nil {
+			return err
+		}
 	}
 
-	return students, nil
+	return rows.Err()
 }
 
-func (s store) GetByID(ctx context.Context, id int) (models.Student, error) {
-	var student models.Student
+func (s store) Count(ctx context.Context, filter *models.Filter) (int, error) {
+	var total int
+
+	where, args := whereClause(filter, s.sealer.Index)
 
-	query := "select * from " + string(models.TableName) + " where id = ?;"
+	query := "select count(*) from " + string(models.TableName) + where + ";"
 
-	err := s.db.QueryRowContext(ctx, query, id).Scan(&student.ID, &student.FirstName, &student.LastName, &student.Gender, &student.Dob, &student.MotherTongue,
-		&student.Nationality, &student.FatherName, &student.MotherName, &student.ContactNumber, &student.FatherOccupation,
-		&student.MotherOccupation, &student.FamilyIncome)
+	err := sqltx.From(ctx, s.db).QueryRowContext(ctx, query, args...).Scan(&total)
 	if err != nil {
-		return models.Student{}, err
+		return 0, err
 	}
 
-	return student, nil
+	return total, nil
 }
 
-func (s store) GetByFirstAndLastName(ctx context.Context, firstName, lastName string) ([]models.Student, error) {
-	var students []models.Student
+func (s store) GetByID(ctx context.Context, id int) (models.Student, error) {
+	query := "select " + columns + " from " + string(models.TableName) + " where id = ? and deleted_at is null;"
 
-	query := "select * from " + string(models.TableName) + " where first_name = ? and last_name = ?;"
+	return s.scan(sqltx.From(ctx, s.db).QueryRowContext(ctx, query, id))
+}
 
-	rows, err := s.db.QueryContext(ctx, query, firstName, lastName)
+func (s store) Post(ctx context.Context, student *models.Student) (models.Student, error) {
+	values, err := s.values(student)
 	if err != nil {
-		return nil, err
+		return models.Student{}, err
 	}
 
-	defer rows.Close()
+	query := "insert into " + string(models.TableName) + " (" + writeColumns + ") values (?" +
+		strings.Repeat(",?", strings.Count(writeColumns, ",")) + ");"
 
-	for rows.Next() {
-		var student models.Student
+	res, err := sqltx.From(ctx, s.db).ExecContext(ctx, query, args(values, writeColumns)...)
+	if err != nil {
+		return models.Student{}, s.duplicate(ctx, err, values["identity_key"])
+	}
+
+	ID, err := res.LastInsertId()
+	if err != nil {
+		return models.Student{}, err
+	}
+
+	student.ID = int(ID)
+	student.Version = 1
 
-		err := rows.Scan(&student.ID, &student.FirstName, &student.LastName, &student.Gender, &student.Dob, &student.MotherTongue,
-			&student.Nationality, &student.FatherName, &student.MotherName, &student.ContactNumber, &student.FatherOccupation,
-			&student.MotherOccupation, &student.FamilyIncome)
+	return *student, nil
+}
+
+// PostBatch inserts the students with a single statement and sets their IDs, which it reads back by identity key:
+// the IDs of a multi-row insert are not consecutive when auto_increment_increment is above 1, as with Galera or
+// group replication. It must run in a transaction, so that the students it reads back are those it inserted.
+func (s store) PostBatch(ctx context.Context, students []models.Student) ([]models.Student, error) {
+	row := "(?" + strings.Repeat(",?", strings.Count(writeColumns, ",")) + ")"
+	rows := make([]string, 0, len(students))
+	keys := make([]interface{}, 0, len(students))
+
+	var all []interface{}
+
+	for i := range students {
+		values, err := s.values(&students[i])
 		if err != nil {
 			return nil, err
 		}
 
-		students = append(students, student)
+		rows = append(rows, row)
+		keys = append(keys, values["identity_key"])
+		all = append(all, args(values, writeColumns)...)
+	}
+
+	query := "insert into " + string(models.TableName) + " (" + writeColumns + ") values " + strings.Join(rows, ",") + ";"
+
+	res, err := sqltx.From(ctx, s.db).ExecContext(ctx, query, all...)
+	if err != nil {
+		return nil, s.duplicates(ctx, err, keys)
+	}
+
+	if _, err := res.RowsAffected(); err != nil {
+		return nil, err
+	}
+
+	ids, err := s.activeIDs(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range students {
+		id, ok := ids[keys[i].(string)]
+		if !ok {
+			return nil, fmt.Errorf("student %d of the batch was not found after its insert", i)
+		}
+
+		students[i].ID = id
+		students[i].Version = 1
 	}
 
 	return students, nil
 }
 
-func (s store) GetByFirstName(ctx context.Context, firstName string) ([]models.Student, error) {
-	var students []models.Student
+// Put overwrites the student if it is still at student.Version, and returns sql.ErrNoRows otherwise.
+func (s store) Put(ctx context.Context, id int, student *models.Student) (models.Student, error) {
+	values, err := s.values(student)
+	if err != nil {
+		return models.Student{}, err
+	}
 
-	query := "select * from " + string(models.TableName) + " where first_name = ?;"
+	query := "update " + string(models.TableName) + " set " + strings.ReplaceAll(writeColumns, ",", " = ?,") +
+		" = ?,version = version + 1 where id = ? and version = ?;"
 
-	rows, err := s.db.QueryContext(ctx, query, firstName)
+	res, err := sqltx.From(ctx, s.db).ExecContext(ctx, query, append(args(values, writeColumns), id, student.Version)...)
 	if err != nil {
-		return nil, err
+		return models.Student{}, s.duplicate(ctx, err, values["identity_key"])
 	}
 
-	defer rows.Close()
+	if err := updated(res); err != nil {
+		return models.Student{}, err
+	}
 
-	for rows.Next() {
-		var student models.Student
+	student.Version++
 
-		err := rows.Scan(&student.ID, &student.FirstName, &student.LastName, &student.Gender, &student.Dob, &student.MotherTongue,
-			&student.Nationality, &student.FatherName, &student.MotherName, &student.ContactNumber, &student.FatherOccupation,
-			&student.MotherOccupation, &student.FamilyIncome)
-		if err != nil {
-			return nil, err
+	return *student, nil
+}
+
+// Patch updates only the given columns with the values in student, if the student is still at
+// student.Version, and returns sql.ErrNoRows otherwise. The identity key is rewritten only when identity_key is
+// one of the columns, which the caller names when the change alters the identity of the student.
+func (s store) Patch(ctx context.Context, id int, student *models.Student, columns []string) (models.Student, error) {
+	if len(columns) == 0 {
+		return *student, nil
+	}
+
+	values, err := s.values(student)
+	if err != nil {
+		return models.Student{}, err
+	}
+
+	sets := make([]string, 0, len(columns))
+	args := make([]interface{}, 0, len(columns)+1)
+
+	for _, column := range columns {
+		value, ok := values[column]
+		if !ok || strings.HasSuffix(column, "_bidx") {
+			return models.Student{}, fmt.Errorf("column %s cannot be updated", column)
 		}
 
-		students = append(students, student)
+		sets = append(sets, column+" = ?")
+		args = append(args, value)
+
+		if indexed(column) || column == "family_income" {
+			sets = append(sets, column+"_bidx = ?")
+			args = append(args, values[column+"_bidx"])
+		}
 	}
 
-	return students, nil
+	query := "update " + string(models.TableName) + " set " + strings.Join(sets, ",") + ",version = version + 1 " +
+		"where id = ? and version = ?;"
+
+	res, err := sqltx.From(ctx, s.db).ExecContext(ctx, query, append(args, id, student.Version)...)
+	if err != nil {
+		return models.Student{}, s.duplicate(ctx, err, values["identity_key"])
+	}
+
+	if err := updated(res); err != nil {
+		return models.Student{}, err
+	}
+
+	student.Version++
+
+	return *student, nil
 }
 
-func (s store) GetByLastName(ctx context.Context, lastName string) ([]models.Student, error) {
-	var students []models.Student
+// Delete moves the student to the trash if it is still at version, and returns sql.ErrNoRows otherwise.
+func (s store) Delete(ctx context.Context, id, version int) error {
+	query := "update " + string(models.TableName) + " set deleted_at = now(6),version = version + 1 " +
+		"where id = ? and version = ? and deleted_at is null;"
+
+	res, err := sqltx.From(ctx, s.db).ExecContext(ctx, query, id, version)
+	if err != nil {
+		return err
+	}
+
+	return updated(res)
+}
 
-	query := "select * from " + string(models.TableName) + " where last_name = ?;"
+// Restore takes the student out of the trash, and returns sql.ErrNoRows if it is not in the trash.
+func (s store) Restore(ctx context.Context, id int) error {
+	query := "update " + string(models.TableName) + " set deleted_at = null,version = version + 1 " +
+		"where id = ? and deleted_at is not null;"
+
+	res, err := sqltx.From(ctx, s.db).ExecContext(ctx, query, id)
+	if isDuplicate(err) {
+		var key sql.NullString
+
+		if err := sqltx.From(ctx, s.db).QueryRowContext(ctx, "select identity_key from "+string(models.TableName)+
+			" where id = ?;", id).Scan(&key); err != nil {
+			return stores.Duplicate{}
+		}
+
+		return s.duplicate(ctx, err, key.String)
+	}
 
-	rows, err := s.db.QueryContext(ctx, query, lastName)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	defer rows.Close()
+	return updated(res)
+}
 
-	for rows.Next() {
-		var student models.Student
+// Purge permanently deletes the students that were moved to the trash before the given time, in batches so
+// that a large purge does not hold locks for long, and returns how many it deleted.
+func (s store) Purge(ctx context.Context, before time.Time) (int, error) {
+	query := "delete from " + string(models.TableName) + " where deleted_at < ? limit ?;"
 
-		err := rows.Scan(&student.ID, &student.FirstName, &student.LastName, &student.Gender, &student.Dob, &student.MotherTongue,
-			&student.Nationality, &student.FatherName, &student.MotherName, &student.ContactNumber, &student.FatherOccupation,
-			&student.MotherOccupation, &student.FamilyIncome)
+	var total int
+
+	for {
+		res, err := sqltx.From(ctx, s.db).ExecContext(ctx, query, before, purgeBatch)
 		if err != nil {
-			return nil, err
+			return total, err
 		}
 
-		students = append(students, student)
+		n, err := res.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+
+		total += int(n)
+
+		if n < purgeBatch {
+			return total, nil
+		}
 	}
+}
 
-	return students, nil
+// isDuplicate reports whether err is the violation of the unique identity index.
+func isDuplicate(err error) bool {
+	var e *mysql.MySQLError
+
+	return errors.As(err, &e) && e.Number == erDupEntry && strings.Contains(e.Message, identityIndex)
 }
 
-func (s store) Post(ctx context.Context, student *models.Student) (models.Student, error) {
-	query := "insert into " + string(models.TableName) + " (first_name,last_name,gender,dob,mother_tongue,nationality,father_name,mother_name,contact_number," +
-		"father_occupation,mother_occupation,family_income) values (?,?,?,?,?,?,?,?,?,?,?,?);"
+// duplicate turns the violation of the unique identity index by a write of the identity key into a
+// stores.Duplicate naming the active student that has it, and returns other errors as they are.
+func (s store) duplicate(ctx context.Context, err error, key interface{}) error {
+	if !isDuplicate(err) {
+		return err
+	}
 
-	res, err := s.db.ExecContext(ctx, query, student.FirstName, student.LastName, student.Gender, student.Dob, student.MotherTongue,
-		student.Nationality, student.FatherName, student.MotherName, student.ContactNumber, student.FatherOccupation,
-		student.MotherOccupation, student.FamilyIncome)
+	var id int
 
-	if err != nil {
-		return models.Student{}, err
+	query := "select id from " + string(models.TableName) + " where active_identity_key = ?;"
+
+	if err := sqltx.From(ctx, s.db).QueryRowContext(ctx, query, key).Scan(&id); err != nil {
+		return stores.Duplicate{}
 	}
 
-	ID, err := res.LastInsertId()
-	if err != nil {
-		return models.Student{}, err
+	return stores.Duplicate{ID: id}
+}
+
+// duplicates turns the violation of the unique identity index by a batch insert of the given identity keys
+// into a stores.Duplicates naming the active students they duplicate, and returns other errors as they are.
+func (s store) duplicates(ctx context.Context, err error, keys []interface{}) error {
+	if !isDuplicate(err) {
+		return err
 	}
 
-	student.ID = int(ID)
+	existing, qErr := s.activeIDs(ctx, keys)
+	if qErr != nil {
+		return qErr
+	}
 
-	return *student, nil
+	d := stores.Duplicates{IDs: make(map[int]int)}
+
+	for i, key := range keys {
+		if id, ok := existing[key.(string)]; ok {
+			d.IDs[i] = id
+		}
+	}
+
+	// the students duplicated were deleted meanwhile
+	if len(d.IDs) == 0 {
+		return err
+	}
+
+	return d
 }
 
-func (s store) Put(ctx context.Context, id int, student *models.Student) (models.Student, error) {
-	query := "update " + string(models.TableName) + " set first_name = ?,last_name = ?,gender = ?,dob = ?,mother_tongue = ?,nationality = ?," +
-		"father_name = ?,mother_name = ?,contact_number = ?,father_occupation = ?,mother_occupation = ?,family_income = ? where id = ?;"
+// activeIDs returns the IDs of the active students with the given identity keys, by key.
+func (s store) activeIDs(ctx context.Context, keys []interface{}) (map[string]int, error) {
+	query := "select id,active_identity_key from " + string(models.TableName) + " where active_identity_key in (?" +
+		strings.Repeat(",?", len(keys)-1) + ");"
 
-	_, err := s.db.ExecContext(ctx, query, student.FirstName, student.LastName, student.Gender, student.Dob, student.MotherTongue,
-		student.Nationality, student.FatherName, student.MotherName, student.ContactNumber, student.FatherOccupation, student.MotherOccupation,
-		student.FamilyIncome, id)
+	rows, err := sqltx.From(ctx, s.db).QueryContext(ctx, query, keys...)
 	if err != nil {
-		return models.Student{}, err
+		return nil, err
 	}
 
-	return *student, nil
-}
+	defer rows.Close()
+
+	ids := make(map[string]int, len(keys))
 
-func (s store) Delete(ctx context.Context, id int) error {
-	query := "delete from " + string(models.TableName) + " where id = ?;"
+	for rows.Next() {
+		var (
+			id  int
+			key string
+		)
+
+		if err := rows.Scan(&id, &key); err != nil {
+			return nil, err
+		}
+
+		ids[key] = id
+	}
+
+	return ids, rows.Err()
+}
 
-	_, err := s.db.ExecContext(ctx, query, id)
+// updated reports sql.ErrNoRows when a conditional write matched no row, because the student was deleted or
+// changed to another version in the meantime.
+func updated(res sql.Result) error {
+	n, err := res.RowsAffected()
 	if err != nil {
 		return err
 	}
 
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
 	return nil
 }
@@ -2,36 +2,87 @@ package student
 
 import (
 	"context"
+	"database/sql"
 	"database/sql/driver"
 	"errors"
 	"log"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"student-management-system/models"
+	stores "student-management-system/store"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 )
 
+// marked is a sealer that marks the values it seals instead of encrypting them, so that queries can be matched.
+type marked struct{}
+
+func (marked) Seal(column, plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	return "sealed:" + plaintext, nil
+}
+
+func (marked) Open(column, value string) (string, error) {
+	return strings.TrimPrefix(value, "sealed:"), nil
+}
+
+func (marked) Current(value string) bool {
+	return value == "" || strings.HasPrefix(value, "sealed:")
+}
+
+func (marked) Index(column, value string) string {
+	return "index:" + value
+}
+
 func TestGet(t *testing.T) {
 	testcases := []struct {
 		desc      string
+		filter    models.Filter
+		expQuery  string
+		expArgs   []driver.Value
 		expOutput []models.Student
 		expRows   *sqlmock.Rows
 		expErr    error
 	}{
-		{desc: "success:get all", expOutput: []models.Student{{ID: 1, FirstName: "arvind", Nationality: "Indian",
-			ContactNumber: 7348761063}}, expRows: sqlmock.NewRows([]string{"id", "first_name", "last_name",
-			"gender", "dob", "mother_tongue", "nationality", "father_name", "mother_name",
-			"contact_number", "father_occupation", "mother_occupation", "family_income"}).AddRow(1, "arvind",
-			"", "", "", "", "Indian", "", "", 7348761063, "", "", 0), expErr: nil},
-		{desc: "failure:error scanning", expRows: sqlmock.NewRows([]string{"id", "first_name", "last_name",
-			"gender", "dob", "mother_tongue", "nationality", "father_name", "mother_name",
-			"contact_number", "father_occupation", "mother_occupation", "family_income"}).AddRow("abc", "arvind",
-			"", "", "", "", "Indian", "", "", "7348761063", "", "", 0), expErr: errors.New("scanning error")},
-		{desc: "failure:error select all", expRows: sqlmock.NewRows([]string{"id", "first_name", "last_name",
-			"gender", "dob", "mother_tongue", "nationality", "father_name", "mother_name",
-			"contact_number", "father_occupation", "mother_occupation", "family_income"}), expErr: errors.New("error")},
+		{desc: "success:get all", expQuery: "select " + columns + " from " + string(models.TableName) + " where deleted_at is null order by id;",
+			expOutput: []models.Student{{ID: 1, FirstName: "arvind", Nationality: "Indian", FatherName: "ram",
+				ContactNumber: "+917348761063", FamilyIncome: 50000, Version: 1}, {ID: 2, FirstName: "ravi", Nationality: "Indian",
+				ContactNumber: "+917348761064", FamilyIncome: 100, Version: 1}}, expRows: sqlmock.NewRows([]string{"id", "first_name",
+				"last_name", "gender", "dob", "mother_tongue", "nationality", "father_name", "mother_name",
+				"contact_number", "home_contact_number", "emergency_contact_number",
+				"father_occupation", "mother_occupation", "family_income", "version", "deleted_at"}).AddRow(1, "arvind",
+				"", "", "", "", "Indian", "sealed:ram", "", "sealed:+917348761063", "", "", "", "", "sealed:50000", 1, nil).
+				AddRow(2, "ravi", "", "", "", "", "Indian", "", "", "+917348761064", "", "", "", "", "100", 1, nil), expErr: nil},
+		{desc: "success:filtered, sorted and paged", filter: models.Filter{FirstName: "arvind", Gender: "M",
+			FatherName: "Ram", DobFrom: models.NewDate(2000, time.January, 1), DobTo: models.NewDate(2005, time.December, 31),
+			Sort:  []models.Sort{{Field: "last_name"}, {Field: "dob", Desc: true}, {Field: "unknown"}, {Field: "family_income"}},
+			Limit: 10, Offset: 20},
+			expQuery: "select " + columns + " from " + string(models.TableName) + " where deleted_at is null and first_name = ? and gender = ? and " +
+				"father_name_bidx = ? and dob >= ? and dob <= ? order by last_name,dob desc,id limit ? offset ?;",
+			expArgs: []driver.Value{"arvind", "M", "index:Ram", time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2005, time.December, 31, 0, 0, 0, 0, time.UTC), 10, 20},
+			expOutput: []models.Student{}, expRows: sqlmock.NewRows([]string{"id", "first_name", "last_name",
+				"gender", "dob", "mother_tongue", "nationality", "father_name", "mother_name",
+				"contact_number", "home_contact_number", "emergency_contact_number",
+				"father_occupation", "mother_occupation", "family_income", "version", "deleted_at"})},
+		{desc: "failure:error scanning", expQuery: "select " + columns + " from " + string(models.TableName) + " where deleted_at is null order by id;",
+			expRows: sqlmock.NewRows([]string{"id", "first_name", "last_name",
+				"gender", "dob", "mother_tongue", "nationality", "father_name", "mother_name",
+				"contact_number", "home_contact_number", "emergency_contact_number",
+				"father_occupation", "mother_occupation", "family_income", "version", "deleted_at"}).AddRow("abc", "arvind",
+				"", "", "", "", "Indian", "", "", "+917348761063", "", "", "", "", 0, 1, nil), expErr: errors.New("scanning error")},
+		{desc: "failure:error select all", expQuery: "select " + columns + " from " + string(models.TableName) + " where deleted_at is null order by id;",
+			expRows: sqlmock.NewRows([]string{"id", "first_name", "last_name",
+				"gender", "dob", "mother_tongue", "nationality", "father_name", "mother_name",
+				"contact_number", "home_contact_number", "emergency_contact_number",
+				"father_occupation", "mother_occupation", "family_income", "version", "deleted_at"}), expErr: errors.New("error")},
 	}
 
 	for i, tc := range testcases {
@@ -40,11 +91,11 @@ func TestGet(t *testing.T) {
 			log.Println(err.Error())
 		}
 
-		mock.ExpectQuery("select * from " + string(models.TableName) + ";").WillReturnRows(tc.expRows).WillReturnError(tc.expErr)
+		mock.ExpectQuery(tc.expQuery).WithArgs(tc.expArgs...).WillReturnRows(tc.expRows).WillReturnError(tc.expErr)
 
-		s := New(db)
+		s := New(db, marked{})
 
-		res, err := s.Get(context.TODO())
+		res, err := s.Get(context.TODO(), &tc.filter)
 
 		if !reflect.DeepEqual(tc.expOutput, res) {
 			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expOutput, res)
@@ -56,6 +107,108 @@ func TestGet(t *testing.T) {
 	}
 }
 
+func TestEach(t *testing.T) {
+	query := "select " + columns + " from " + string(models.TableName) + " where deleted_at is null and nationality = ? order by id;"
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "first_name", "last_name", "gender", "dob", "mother_tongue", "nationality",
+			"father_name", "mother_name", "contact_number", "home_contact_number", "emergency_contact_number",
+			"father_occupation", "mother_occupation", "family_income", "version", "deleted_at"}).
+			AddRow(1, "arvind", "", "", "", "", "Indian", "", "", "sealed:+917348761063", "", "", "", "", "0", 1, nil).
+			AddRow(2, "ravi", "", "", "", "", "Indian", "", "", "+917348761064", "", "", "", "", "0", 1, nil)
+	}
+
+	testcases := []struct {
+		desc   string
+		fnErr  error
+		expRes []int
+		expErr error
+	}{
+		{desc: "success:every student in order", expRes: []int{1, 2}},
+		{desc: "failure:fn stops the iteration", fnErr: errors.New("broken pipe"), expRes: []int{1}, expErr: errors.New("broken pipe")},
+	}
+
+	for i, tc := range testcases {
+		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			log.Println(err.Error())
+		}
+
+		mock.ExpectQuery(query).WithArgs("Indian").WillReturnRows(rows()).RowsWillBeClosed()
+
+		s := New(db, marked{})
+
+		var ids []int
+
+		err = s.Each(context.TODO(), &models.Filter{Nationality: "Indian"}, func(student models.Student) error {
+			ids = append(ids, student.ID)
+
+			return tc.fnErr
+		})
+
+		if !reflect.DeepEqual(tc.expRes, ids) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes, ids)
+		}
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, nil, err)
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	testcases := []struct {
+		desc     string
+		filter   models.Filter
+		expQuery string
+		expArgs  []driver.Value
+		expRows  *sqlmock.Rows
+		expRes   int
+		expErr   error
+	}{
+		{desc: "success:count all", expQuery: "select count(*) from " + string(models.TableName) + " where deleted_at is null;",
+			expRows: sqlmock.NewRows([]string{"count(*)"}).AddRow(3), expRes: 3},
+		{desc: "success:count filtered", filter: models.Filter{Nationality: "Indian", ContactNumber: "+917348761063", Limit: 10},
+			expQuery: "select count(*) from " + string(models.TableName) + " where deleted_at is null and nationality = ? and contact_number_bidx = ?;",
+			expArgs:  []driver.Value{"Indian", "index:+917348761063"}, expRows: sqlmock.NewRows([]string{"count(*)"}).AddRow(1), expRes: 1},
+		{desc: "success:count of some students", filter: models.Filter{IDs: []int{3, 7}},
+			expQuery: "select count(*) from " + string(models.TableName) + " where deleted_at is null and id in (?,?);",
+			expArgs:  []driver.Value{3, 7}, expRows: sqlmock.NewRows([]string{"count(*)"}).AddRow(2), expRes: 2},
+		{desc: "success:count of no students", filter: models.Filter{IDs: []int{}},
+			expQuery: "select count(*) from " + string(models.TableName) + " where deleted_at is null and false;",
+			expRows:  sqlmock.NewRows([]string{"count(*)"}).AddRow(0), expRes: 0},
+		{desc: "success:count in an income range", filter: models.Filter{MinFamilyIncome: 100000, MaxFamilyIncome: 299999},
+			expQuery: "select count(*) from " + string(models.TableName) + " where deleted_at is null and family_income_bidx in (?,?);",
+			expArgs:  []driver.Value{"index:1", "index:2"}, expRows: sqlmock.NewRows([]string{"count(*)"}).AddRow(4), expRes: 4},
+		{desc: "failure:query error", expQuery: "select count(*) from " + string(models.TableName) + " where deleted_at is null;",
+			expRows: sqlmock.NewRows([]string{"count(*)"}), expErr: errors.New("query error")},
+	}
+
+	for i, tc := range testcases {
+		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			log.Println(err.Error())
+		}
+
+		mock.ExpectQuery(tc.expQuery).WithArgs(tc.expArgs...).WillReturnRows(tc.expRows).WillReturnError(tc.expErr)
+
+		s := New(db, marked{})
+
+		res, err := s.Count(context.TODO(), &tc.filter)
+
+		if res != tc.expRes {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes, res)
+		}
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
+
 func TestPost(t *testing.T) {
 	testcases := []struct {
 		desc    string
@@ -67,22 +220,23 @@ func TestPost(t *testing.T) {
 		{desc: "success:posted successfully", reqData: models.Student{
 			FirstName:     "arvind",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
+			ContactNumber: "+917348761063",
 		}, expRes: models.Student{
 			ID:            1,
 			FirstName:     "arvind",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
+			ContactNumber: "+917348761063",
+			Version:       1,
 		}, sqlRes: sqlmock.NewResult(1, 0), expErr: nil},
 		{desc: "failure:query error", reqData: models.Student{
 			FirstName:     "arvind",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
+			ContactNumber: "+917348761063",
 		}, sqlRes: sqlmock.NewResult(0, 0), expErr: errors.New("query error")},
 		{desc: "failure:lastInsertedID error", reqData: models.Student{
 			FirstName:     "arvind",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
+			ContactNumber: "+917348761063",
 		}, sqlRes: sqlmock.NewErrorResult(errors.New("lastInsertedId error")), expErr: errors.New("lastInsertedId error")},
 	}
 
@@ -93,13 +247,14 @@ func TestPost(t *testing.T) {
 		}
 
 		query := "insert into " + string(models.TableName) + " (first_name,last_name,gender,dob,mother_tongue,nationality,father_name,mother_name,contact_number," +
-			"father_occupation,mother_occupation,family_income) values (?,?,?,?,?,?,?,?,?,?,?,?);"
+			"home_contact_number,emergency_contact_number,father_occupation,mother_occupation,family_income,father_name_bidx,mother_name_bidx," +
+			"contact_number_bidx,family_income_bidx,identity_key) values (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?);"
 		mock.ExpectExec(query).WithArgs(tc.reqData.FirstName, tc.reqData.LastName, tc.reqData.Gender, tc.reqData.Dob,
-			tc.reqData.MotherTongue, tc.reqData.Nationality, tc.reqData.FatherName, tc.reqData.MotherName, tc.reqData.ContactNumber,
-			tc.reqData.FatherOccupation, tc.reqData.MotherOccupation, tc.reqData.FamilyIncome).WillReturnResult(tc.sqlRes).
-			WillReturnError(tc.expErr)
+			tc.reqData.MotherTongue, tc.reqData.Nationality, "", "", "sealed:"+tc.reqData.ContactNumber,
+			"", "", tc.reqData.FatherOccupation, tc.reqData.MotherOccupation, "sealed:0", nil, nil,
+			"index:"+tc.reqData.ContactNumber, "index:0", "index:"+tc.reqData.Identity()).WillReturnResult(tc.sqlRes).WillReturnError(tc.expErr)
 
-		s := New(db)
+		s := New(db, marked{})
 
 		res, err := s.Post(context.TODO(), &tc.reqData)
 
@@ -113,28 +268,20 @@ func TestPost(t *testing.T) {
 	}
 }
 
-func TestGetByFirstAndLastName(t *testing.T) {
+func TestPost_Duplicate(t *testing.T) {
+	dup := &mysql.MySQLError{Number: erDupEntry, Message: "Duplicate entry 'x' for key 'student.idx_student_identity'"}
+
 	testcases := []struct {
-		desc      string
-		firstName string
-		lastName  string
-		expOutput []models.Student
-		expRows   *sqlmock.Rows
-		expErr    error
+		desc   string
+		err    error
+		id     *sqlmock.Rows
+		expErr error
 	}{
-		{desc: "success:get all student with valid first and lastName ", firstName: "arvind", lastName: "yadav", expOutput: []models.Student{{ID: 1,
-			FirstName: "arvind", LastName: "yadav", Nationality: "Indian", ContactNumber: 7348761063}},
-			expRows: sqlmock.NewRows([]string{"id", "first_name", "last_name",
-				"gender", "dob", "mother_tongue", "nationality", "father_name", "mother_name",
-				"contact_number", "father_occupation", "mother_occupation", "family_income"}).AddRow(1, "arvind",
-				"yadav", "", "", "", "Indian", "", "", 7348761063, "", "", 0), expErr: nil},
-		{desc: "failure:error scanning", expRows: sqlmock.NewRows([]string{"id", "first_name", "last_name",
-			"gender", "dob", "mother_tongue", "nationality", "father_name", "mother_name",
-			"contact_number", "father_occupation", "mother_occupation", "family_income"}).AddRow("abc", "arvind",
-			"", "", "", "", "Indian", "", "", "7348761063", "", "", 0), expErr: errors.New("scanning error")},
-		{desc: "failure:error select all", expRows: sqlmock.NewRows([]string{"id", "first_name", "last_name",
-			"gender", "dob", "mother_tongue", "nationality", "father_name", "mother_name",
-			"contact_number", "father_occupation", "mother_occupation", "family_income"}), expErr: errors.New("error")},
+		{desc: "failure:duplicate of an active student", err: dup, id: sqlmock.NewRows([]string{"id"}).AddRow(7),
+			expErr: stores.Duplicate{ID: 7}},
+		{desc: "failure:duplicate no longer found", err: dup, id: sqlmock.NewRows([]string{"id"}), expErr: stores.Duplicate{}},
+		{desc: "failure:violation of another index", err: &mysql.MySQLError{Number: erDupEntry, Message: "Duplicate entry '1' for key 'PRIMARY'"},
+			expErr: &mysql.MySQLError{Number: erDupEntry, Message: "Duplicate entry '1' for key 'PRIMARY'"}},
 	}
 
 	for i, tc := range testcases {
@@ -143,90 +290,50 @@ func TestGetByFirstAndLastName(t *testing.T) {
 			log.Println(err.Error())
 		}
 
-		mock.ExpectQuery("select * from "+string(models.TableName)+" where first_name = ? and "+
-			"last_name = ?;").WithArgs(tc.firstName, tc.lastName).WillReturnRows(tc.expRows).WillReturnError(tc.expErr)
-
-		s := New(db)
+		student := models.Student{FirstName: "arvind", Nationality: "Indian"}
 
-		res, err := s.GetByFirstAndLastName(context.TODO(), tc.firstName, tc.lastName)
+		mock.ExpectExec("insert into " + string(models.TableName) + " (" + writeColumns + ") values " +
+			"(?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?);").WillReturnError(tc.err)
 
-		if !reflect.DeepEqual(tc.expOutput, res) {
-			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expOutput, res)
+		if tc.id != nil {
+			mock.ExpectQuery("select id from " + string(models.TableName) + " where active_identity_key = ?;").
+				WithArgs("index:" + student.Identity()).WillReturnRows(tc.id)
 		}
 
+		_, err = New(db, marked{}).Post(context.TODO(), &student)
+
 		if !reflect.DeepEqual(tc.expErr, err) {
 			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
 		}
-	}
-}
-
-func TestGetByFirstName(t *testing.T) {
-	testcases := []struct {
-		desc      string
-		firstName string
-		expOutput []models.Student
-		expRows   *sqlmock.Rows
-		expErr    error
-	}{
-		{desc: "success:get all student with valid firstName ", firstName: "arvind", expOutput: []models.Student{{ID: 1,
-			FirstName: "arvind", Nationality: "Indian", ContactNumber: 7348761063}},
-			expRows: sqlmock.NewRows([]string{"id", "first_name", "last_name",
-				"gender", "dob", "mother_tongue", "nationality", "father_name", "mother_name",
-				"contact_number", "father_occupation", "mother_occupation", "family_income"}).AddRow(1, "arvind",
-				"", "", "", "", "Indian", "", "", 7348761063, "", "", 0), expErr: nil},
-		{desc: "failure:error scanning", expRows: sqlmock.NewRows([]string{"id", "first_name", "last_name",
-			"gender", "dob", "mother_tongue", "nationality", "father_name", "mother_name",
-			"contact_number", "father_occupation", "mother_occupation", "family_income"}).AddRow("abc", "arvind",
-			"", "", "", "", "Indian", "", "", "7348761063", "", "", 0), expErr: errors.New("scanning error")},
-		{desc: "failure:error select all", expRows: sqlmock.NewRows([]string{"id", "first_name", "last_name",
-			"gender", "dob", "mother_tongue", "nationality", "father_name", "mother_name",
-			"contact_number", "father_occupation", "mother_occupation", "family_income"}), expErr: errors.New("error")},
-	}
 
-	for i, tc := range testcases {
-		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
-		if err != nil {
-			log.Println(err.Error())
-		}
-
-		mock.ExpectQuery("select * from " + string(models.TableName) + " where " +
-			"first_name = ?;").WithArgs(tc.firstName).WillReturnRows(tc.expRows).WillReturnError(tc.expErr)
-
-		s := New(db)
-
-		res, err := s.GetByFirstName(context.TODO(), tc.firstName)
-
-		if !reflect.DeepEqual(tc.expOutput, res) {
-			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expOutput, res)
-		}
-
-		if !reflect.DeepEqual(tc.expErr, err) {
-			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("testcases %d failed: %v", i+1, err)
 		}
 	}
 }
 
-func TestGetByLastName(t *testing.T) {
+func TestPostBatch(t *testing.T) {
+	dup := &mysql.MySQLError{Number: erDupEntry, Message: "Duplicate entry 'x' for key 'student.idx_student_identity'"}
+	students := []models.Student{{FirstName: "arvind", Nationality: "Indian"}, {FirstName: "ravi", Nationality: "Indian"}}
+	keys := []driver.Value{"index:" + students[0].Identity(), "index:" + students[1].Identity()}
+
 	testcases := []struct {
-		desc      string
-		lastName  string
-		expOutput []models.Student
-		expRows   *sqlmock.Rows
-		expErr    error
+		desc     string
+		execErr  error
+		existing *sqlmock.Rows
+		expRes   []models.Student
+		expErr   error
 	}{
-		{desc: "success:get all student with valid firstName ", lastName: "yadav", expOutput: []models.Student{{ID: 1,
-			FirstName: "arvind", LastName: "yadav", Nationality: "Indian", ContactNumber: 7348761063}},
-			expRows: sqlmock.NewRows([]string{"id", "first_name", "last_name",
-				"gender", "dob", "mother_tongue", "nationality", "father_name", "mother_name",
-				"contact_number", "father_occupation", "mother_occupation", "family_income"}).AddRow(1, "arvind",
-				"yadav", "", "", "", "Indian", "", "", 7348761063, "", "", 0), expErr: nil},
-		{desc: "failure:error scanning", expRows: sqlmock.NewRows([]string{"id", "first_name", "last_name",
-			"gender", "dob", "mother_tongue", "nationality", "father_name", "mother_name",
-			"contact_number", "father_occupation", "mother_occupation", "family_income"}).AddRow("abc", "arvind",
-			"", "", "", "", "Indian", "", "", "7348761063", "", "", 0), expErr: errors.New("scanning error")},
-		{desc: "failure:error select all", expRows: sqlmock.NewRows([]string{"id", "first_name", "last_name",
-			"gender", "dob", "mother_tongue", "nationality", "father_name", "mother_name",
-			"contact_number", "father_occupation", "mother_occupation", "family_income"}), expErr: errors.New("error")},
+		{desc: "success:ids read back, not assumed consecutive",
+			existing: sqlmock.NewRows([]string{"id", "active_identity_key"}).AddRow(9, keys[1]).AddRow(7, keys[0]),
+			expRes: []models.Student{{ID: 7, FirstName: "arvind", Nationality: "Indian", Version: 1},
+				{ID: 9, FirstName: "ravi", Nationality: "Indian", Version: 1}}},
+		{desc: "failure:ids not read back", existing: sqlmock.NewRows([]string{"id", "active_identity_key"}).AddRow(7, keys[0]),
+			expErr: errors.New("student 1 of the batch was not found after its insert")},
+		{desc: "failure:duplicates by position", execErr: dup,
+			existing: sqlmock.NewRows([]string{"id", "active_identity_key"}).AddRow(3, keys[1]),
+			expErr:   stores.Duplicates{IDs: map[int]int{1: 3}}},
+		{desc: "failure:exec error", execErr: errors.New("exec error"), expErr: errors.New("exec error")},
 	}
 
 	for i, tc := range testcases {
@@ -235,20 +342,30 @@ func TestGetByLastName(t *testing.T) {
 			log.Println(err.Error())
 		}
 
-		mock.ExpectQuery("select * from " + string(models.TableName) + " where " +
-			"last_name = ?;").WithArgs(tc.lastName).WillReturnRows(tc.expRows).WillReturnError(tc.expErr)
+		row := "(?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)"
+		mock.ExpectExec("insert into " + string(models.TableName) + " (" + writeColumns + ") values " + row + "," + row + ";").
+			WillReturnResult(sqlmock.NewResult(7, 2)).WillReturnError(tc.execErr)
 
-		s := New(db)
+		if tc.existing != nil {
+			mock.ExpectQuery("select id,active_identity_key from " + string(models.TableName) +
+				" where active_identity_key in (?,?);").WithArgs(keys...).WillReturnRows(tc.existing)
+		}
 
-		res, err := s.GetByLastName(context.TODO(), tc.lastName)
+		batch := append([]models.Student(nil), students...)
 
-		if !reflect.DeepEqual(tc.expOutput, res) {
-			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expOutput, res)
+		res, err := New(db, marked{}).PostBatch(context.TODO(), batch)
+
+		if !reflect.DeepEqual(tc.expRes, res) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes, res)
 		}
 
 		if !reflect.DeepEqual(tc.expErr, err) {
 			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
 		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("testcases %d failed: %v", i+1, err)
+		}
 	}
 }
 
@@ -265,16 +382,19 @@ func TestGetByID(t *testing.T) {
 			FirstName:     "arvind",
 			LastName:      "yadav",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
+			ContactNumber: "+917348761063",
+			Version:       1,
 		}, expRows: sqlmock.NewRows([]string{"id", "first_name", "last_name",
 			"gender", "dob", "mother_tongue", "nationality", "father_name", "mother_name",
-			"contact_number", "father_occupation", "mother_occupation", "family_income"}).AddRow(1, "arvind",
-			"yadav", "", "", "", "Indian", "", "", 7348761063, "", "", 0), expErr: nil},
+			"contact_number", "home_contact_number", "emergency_contact_number",
+			"father_occupation", "mother_occupation", "family_income", "version", "deleted_at"}).AddRow(1, "arvind",
+			"yadav", "", "", "", "Indian", "", "", "+917348761063", "", "", "", "", 0, 1, nil), expErr: nil},
 		{desc: "failure:scanning row error", id: 1,
 			expRows: sqlmock.NewRows([]string{"id", "first_name", "last_name",
 				"gender", "dob", "mother_tongue", "nationality", "father_name", "mother_name",
-				"contact_number", "father_occupation", "mother_occupation", "family_income"}).AddRow("abc", "arvind",
-				"yadav", "", "", "", "Indian", "", "", 7348761063, "", "", 0), expErr: errors.New("scanning error")},
+				"contact_number", "home_contact_number", "emergency_contact_number",
+				"father_occupation", "mother_occupation", "family_income", "version", "deleted_at"}).AddRow("abc", "arvind",
+				"yadav", "", "", "", "Indian", "", "", "+917348761063", "", "", "", "", 0, 1, nil), expErr: errors.New("scanning error")},
 	}
 
 	for i, tc := range testcases {
@@ -285,9 +405,9 @@ func TestGetByID(t *testing.T) {
 
 		ctx := context.TODO()
 
-		s := New(db)
+		s := New(db, marked{})
 
-		mock.ExpectQuery("select * from " + string(models.TableName) + " where id = ?;").WithArgs(tc.id).WillReturnRows(tc.expRows).WillReturnError(tc.expErr)
+		mock.ExpectQuery("select " + columns + " from " + string(models.TableName) + " where id = ? and deleted_at is null;").WithArgs(tc.id).WillReturnRows(tc.expRows).WillReturnError(tc.expErr)
 
 		result, err := s.GetByID(ctx, tc.id)
 
@@ -309,6 +429,7 @@ func TestDelete(t *testing.T) {
 		expErr           error
 	}{
 		{desc: "success:deleted successfully", id: 1, noOfRowsAffected: 1},
+		{desc: "failure:stale version", id: 1, expErr: sql.ErrNoRows},
 		{desc: "failure:id is not present in db result set", id: 1111, expErr: errors.New("id not found")},
 	}
 
@@ -319,12 +440,13 @@ func TestDelete(t *testing.T) {
 		}
 
 		ctx := context.TODO()
-		s := New(db)
+		s := New(db, marked{})
 
-		mock.ExpectExec("delete from " + string(models.TableName) + " where " +
-			"id = ?;").WithArgs(tc.id).WillReturnResult(sqlmock.NewResult(0, tc.noOfRowsAffected)).WillReturnError(tc.expErr)
+		mock.ExpectExec("update "+string(models.TableName)+" set deleted_at = now(6),version = version + 1 where "+
+			"id = ? and version = ? and deleted_at is null;").WithArgs(tc.id, 2).WillReturnResult(sqlmock.NewResult(0, tc.noOfRowsAffected)).
+			WillReturnError(tc.expErr)
 
-		err = s.Delete(ctx, tc.id)
+		err = s.Delete(ctx, tc.id, 2)
 
 		if !reflect.DeepEqual(err, tc.expErr) {
 			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
@@ -344,16 +466,24 @@ func TestPut(t *testing.T) {
 		{desc: "success:updated successfully", id: 1, reqBody: models.Student{
 			FirstName:     "arvind",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
+			ContactNumber: "+917348761063",
+			Version:       3,
 		}, noOfRowsAffect: 1, expRes: models.Student{
 			FirstName:     "arvind",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
+			ContactNumber: "+917348761063",
+			Version:       4,
 		}},
+		{desc: "failure:stale version", id: 1, reqBody: models.Student{
+			FirstName:     "arvind",
+			Nationality:   "Indian",
+			ContactNumber: "+917348761063",
+			Version:       2,
+		}, expErr: sql.ErrNoRows},
 		{desc: "failure:invalid id", id: 1111, reqBody: models.Student{
 			FirstName:     "arvind",
 			Nationality:   "Indian",
-			ContactNumber: 7348761063,
+			ContactNumber: "+917348761063",
 		}, expErr: errors.New("sql:no rows in db result set")},
 	}
 
@@ -364,12 +494,15 @@ func TestPut(t *testing.T) {
 		}
 
 		ctx := context.TODO()
-		s := New(db)
+		s := New(db, marked{})
 
 		mock.ExpectExec("update "+string(models.TableName)+" set first_name = ?,last_name = ?,gender = ?,dob = ?,mother_tongue = ?,nationality = ?,"+
-			"father_name = ?,mother_name = ?,contact_number = ?,father_occupation = ?,mother_occupation = ?,family_income = ? where id = ?;").WithArgs(tc.reqBody.FirstName,
-			tc.reqBody.LastName, tc.reqBody.Gender, tc.reqBody.Dob, tc.reqBody.MotherTongue, tc.reqBody.Nationality, tc.reqBody.FatherName, tc.reqBody.MotherName,
-			tc.reqBody.ContactNumber, tc.reqBody.FatherOccupation, tc.reqBody.MotherOccupation, tc.reqBody.FamilyIncome, tc.id).WillReturnResult(
+			"father_name = ?,mother_name = ?,contact_number = ?,home_contact_number = ?,emergency_contact_number = ?,"+
+			"father_occupation = ?,mother_occupation = ?,family_income = ?,father_name_bidx = ?,mother_name_bidx = ?,"+
+			"contact_number_bidx = ?,family_income_bidx = ?,identity_key = ?,version = version + 1 where id = ? and version = ?;").WithArgs(tc.reqBody.FirstName,
+			tc.reqBody.LastName, tc.reqBody.Gender, tc.reqBody.Dob, tc.reqBody.MotherTongue, tc.reqBody.Nationality, "", "",
+			"sealed:"+tc.reqBody.ContactNumber, "", "", tc.reqBody.FatherOccupation, tc.reqBody.MotherOccupation, "sealed:0", nil, nil,
+			"index:"+tc.reqBody.ContactNumber, "index:0", "index:"+tc.reqBody.Identity(), tc.id, tc.reqBody.Version).WillReturnResult(
 			sqlmock.NewResult(0, tc.noOfRowsAffect)).WillReturnError(tc.expErr)
 
 		result, err := s.Put(ctx, tc.id, &tc.reqBody)
@@ -383,3 +516,235 @@ func TestPut(t *testing.T) {
 		}
 	}
 }
+
+func TestPatch(t *testing.T) {
+	student := models.Student{ID: 1, FirstName: "arvind", LastName: "kumar", Dob: models.NewDate(2000, time.September, 10),
+		ContactNumber: "+917348761064", Version: 2}
+
+	identity := "index:" + student.Identity()
+
+	testcases := []struct {
+		desc     string
+		columns  []string
+		expQuery string
+		expArgs  []driver.Value
+		rows     int64
+		expErr   error
+	}{
+		{desc: "success:only the given columns", columns: []string{"last_name", "dob", "contact_number", "identity_key"},
+			expQuery: "update " + string(models.TableName) + " set last_name = ?,dob = ?,contact_number = ?,contact_number_bidx = ?," +
+				"identity_key = ?,version = version + 1 where id = ? and version = ?;",
+			expArgs: []driver.Value{"kumar", time.Date(2000, time.September, 10, 0, 0, 0, 0, time.UTC), "sealed:+917348761064",
+				"index:+917348761064", identity, 1, 2},
+			rows: 1},
+		{desc: "success:identity key left alone", columns: []string{"last_name"},
+			expQuery: "update " + string(models.TableName) + " set last_name = ?,version = version + 1 where id = ? and version = ?;",
+			expArgs:  []driver.Value{"kumar", 1, 2}, rows: 1},
+		{desc: "failure:stale version", columns: []string{"last_name"},
+			expQuery: "update " + string(models.TableName) + " set last_name = ?,version = version + 1 where id = ? and version = ?;",
+			expArgs:  []driver.Value{"kumar", 1, 2}, expErr: sql.ErrNoRows},
+		{desc: "failure:exec error", columns: []string{"last_name"},
+			expQuery: "update " + string(models.TableName) + " set last_name = ?,version = version + 1 where id = ? and version = ?;",
+			expArgs:  []driver.Value{"kumar", 1, 2}, expErr: errors.New("exec error")},
+	}
+
+	for i, tc := range testcases {
+		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			log.Println(err.Error())
+		}
+
+		mock.ExpectExec(tc.expQuery).WithArgs(tc.expArgs...).WillReturnResult(sqlmock.NewResult(0, tc.rows)).WillReturnError(tc.expErr)
+
+		s := New(db, marked{})
+		student := student
+
+		_, err = s.Patch(context.TODO(), 1, &student, tc.columns)
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("testcases %d failed: %v", i+1, err)
+		}
+	}
+}
+
+func TestPatch_NoColumns(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		log.Println(err.Error())
+	}
+
+	s := New(db, marked{})
+	student := models.Student{ID: 1, FirstName: "arvind"}
+
+	for _, column := range []string{"id", "contact_number_bidx", "family_income_bidx"} {
+		if _, err := s.Patch(context.TODO(), 1, &student, []string{column}); err == nil {
+			t.Errorf("expected an error for the column %s that cannot be updated", column)
+		}
+	}
+
+	res, err := s.Patch(context.TODO(), 1, &student, nil)
+	if err != nil || !reflect.DeepEqual(student, res) {
+		t.Errorf("expected %v got %v, %v", student, res, err)
+	}
+}
+
+func TestGet_Trash(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		log.Println(err.Error())
+	}
+
+	deletedAt := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("select " + columns + " from " + string(models.TableName) + " where deleted_at is not null " +
+		"order by deleted_at desc,id;").WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name",
+		"gender", "dob", "mother_tongue", "nationality", "father_name", "mother_name",
+		"contact_number", "home_contact_number", "emergency_contact_number",
+		"father_occupation", "mother_occupation", "family_income", "version", "deleted_at"}).AddRow(1, "arvind",
+		"", "", nil, "", "Indian", "", "", "+917348761063", "", "", "", "", 0, 2, deletedAt))
+
+	s := New(db, marked{})
+
+	res, err := s.Get(context.TODO(), &models.Filter{Deleted: true, Sort: []models.Sort{{Field: "deleted_at", Desc: true}}})
+
+	exp := []models.Student{{ID: 1, FirstName: "arvind", Nationality: "Indian", ContactNumber: "+917348761063", Version: 2,
+		DeletedAt: &deletedAt}}
+
+	if err != nil || !reflect.DeepEqual(exp, res) {
+		t.Errorf("expected %v got %v, %v", exp, res, err)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	testcases := []struct {
+		desc   string
+		rows   int64
+		expErr error
+	}{
+		{desc: "success:restored", rows: 1},
+		{desc: "failure:not in the trash", expErr: sql.ErrNoRows},
+		{desc: "failure:exec error", expErr: errors.New("exec error")},
+	}
+
+	for i, tc := range testcases {
+		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			log.Println(err.Error())
+		}
+
+		mock.ExpectExec("update " + string(models.TableName) + " set deleted_at = null,version = version + 1 " +
+			"where id = ? and deleted_at is not null;").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, tc.rows)).
+			WillReturnError(tc.expErr)
+
+		s := New(db, marked{})
+
+		err = s.Restore(context.TODO(), 1)
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
+
+func TestRestore_Duplicate(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		log.Println(err.Error())
+	}
+
+	mock.ExpectExec("update " + string(models.TableName) + " set deleted_at = null,version = version + 1 " +
+		"where id = ? and deleted_at is not null;").WithArgs(1).
+		WillReturnError(&mysql.MySQLError{Number: erDupEntry, Message: "Duplicate entry 'x' for key 'student.idx_student_identity'"})
+	mock.ExpectQuery("select identity_key from " + string(models.TableName) + " where id = ?;").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"identity_key"}).AddRow("key"))
+	mock.ExpectQuery("select id from " + string(models.TableName) + " where active_identity_key = ?;").WithArgs("key").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	err = New(db, marked{}).Restore(context.TODO(), 1)
+	if !reflect.DeepEqual(stores.Duplicate{ID: 7}, err) {
+		t.Errorf("expected %v got %v", stores.Duplicate{ID: 7}, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("%v", err)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		log.Println(err.Error())
+	}
+
+	before := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+	query := "delete from " + string(models.TableName) + " where deleted_at < ? limit ?;"
+
+	mock.ExpectExec(query).WithArgs(before, purgeBatch).WillReturnResult(sqlmock.NewResult(0, purgeBatch))
+	mock.ExpectExec(query).WithArgs(before, purgeBatch).WillReturnResult(sqlmock.NewResult(0, 7))
+
+	s := New(db, marked{})
+
+	n, err := s.Purge(context.TODO(), before)
+	if err != nil || n != purgeBatch+7 {
+		t.Errorf("expected %v got %v, %v", purgeBatch+7, n, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("%v", err)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		log.Println(err.Error())
+	}
+
+	rewritten := "father_name,mother_name,contact_number,home_contact_number,emergency_contact_number,family_income," +
+		"father_name_bidx,mother_name_bidx,contact_number_bidx,family_income_bidx,identity_key"
+	query := "select " + columns + "," + rewritten + " from " + string(models.TableName) + " where id > ? order by id limit ?;"
+	update := "update " + string(models.TableName) + " set father_name = ?,mother_name = ?,contact_number = ?," +
+		"home_contact_number = ?,emergency_contact_number = ?,family_income = ?,father_name_bidx = ?,mother_name_bidx = ?," +
+		"contact_number_bidx = ?,family_income_bidx = ?,identity_key = ? where id = ? and version = ?;"
+	first := models.Student{FirstName: "arvind", Nationality: "Indian", FatherName: "Ram", ContactNumber: "+917348761063",
+		FamilyIncome: 100}
+	second := models.Student{FirstName: "ravi", Nationality: "Indian", ContactNumber: "+917348761064"}
+	third := models.Student{FirstName: "meera", Nationality: "Indian", ContactNumber: "+917348761065", FamilyIncome: 250000}
+	dup := &mysql.MySQLError{Number: erDupEntry, Message: "Duplicate entry 'x' for key 'student.idx_student_identity'"}
+
+	mock.ExpectQuery(query).WithArgs(0, rotateBatch).WillReturnRows(sqlmock.NewRows(append([]string{"id", "first_name",
+		"last_name", "gender", "dob", "mother_tongue", "nationality", "father_name", "mother_name",
+		"contact_number", "home_contact_number", "emergency_contact_number",
+		"father_occupation", "mother_occupation", "family_income", "version", "deleted_at"}, strings.Split(rewritten, ",")...)).
+		// written before encryption
+		AddRow(3, "arvind", "", "", nil, "", "Indian", "Ram", "", "+917348761063", "", "", "", "", "100", 2, nil,
+			"Ram", "", "+917348761063", "", "", "100", nil, nil, nil, nil, nil).
+		// sealed under the active key, but without an income band
+		AddRow(5, "ravi", "", "", nil, "", "Indian", "", "", "sealed:+917348761064", "", "", "", "", "sealed:0", 4, nil,
+			"", "", "sealed:+917348761064", "", "", "sealed:0", nil, nil, "index:+917348761064", nil,
+			"index:"+second.Identity()).
+		// current, and left alone
+		AddRow(7, "meera", "", "", nil, "", "Indian", "", "", "sealed:+917348761065", "", "", "", "", "sealed:250000", 1, nil,
+			"", "", "sealed:+917348761065", "", "", "sealed:250000", nil, nil, "index:+917348761065", "index:2",
+			"index:"+third.Identity()))
+	mock.ExpectExec(update).WithArgs("sealed:Ram", "", "sealed:+917348761063", "", "", "sealed:100", "index:Ram", nil,
+		"index:+917348761063", "index:0", "index:"+first.Identity(), 3, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	// the second student duplicates the first, and keeps no identity key
+	mock.ExpectExec(update).WithArgs("", "", "sealed:+917348761064", "", "", "sealed:0", nil, nil,
+		"index:+917348761064", "index:0", "index:"+second.Identity(), 5, 4).WillReturnError(dup)
+	mock.ExpectExec(update).WithArgs("", "", "sealed:+917348761064", "", "", "sealed:0", nil, nil,
+		"index:+917348761064", "index:0", nil, 5, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	n, err := New(db, marked{}).Rotate(context.TODO())
+	if err != nil || n != 2 {
+		t.Errorf("expected %v got %v, %v", 2, n, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("%v", err)
+	}
+}
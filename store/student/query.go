@@ -0,0 +1,149 @@
+package student
+
+import (
+	"strings"
+
+	"student-management-system/models"
+)
+
+const (
+	columns = "id,first_name,last_name,gender,dob,mother_tongue,nationality,father_name,mother_name,contact_number," +
+		"home_contact_number,emergency_contact_number,father_occupation,mother_occupation,family_income,version," +
+		"deleted_at"
+	// writeColumns are the columns an insert or a replacement sets.
+	writeColumns = "first_name,last_name,gender,dob,mother_tongue,nationality,father_name,mother_name,contact_number," +
+		"home_contact_number,emergency_contact_number,father_occupation,mother_occupation,family_income," + indexColumns +
+		",identity_key"
+)
+
+// whereClause translates the filter into a parameterised where clause. Only values are passed as arguments,
+// column names are fixed here, so nothing from the request is ever interpolated into the query. Students in
+// the trash are only selected when the filter asks for them, and then exclusively. The encrypted columns are
+// matched on their blind index, computed by index, and the family income range on the blind indexes of the income
+// bands it covers, which the service only lets it cover whole of.
+func whereClause(filter *models.Filter, index func(column, value string) string) (clause string, args []interface{}) {
+	conditions := []string{"deleted_at is null"}
+
+	if filter.Deleted {
+		conditions[0] = "deleted_at is not null"
+	}
+
+	equals := []struct {
+		column string
+		value  string
+	}{
+		{"first_name", filter.FirstName},
+		{"last_name", filter.LastName},
+		{"gender", filter.Gender},
+		{"mother_tongue", filter.MotherTongue},
+		{"nationality", filter.Nationality},
+		{"father_name", filter.FatherName},
+		{"mother_name", filter.MotherName},
+		{"contact_number", filter.ContactNumber},
+		{"father_occupation", filter.FatherOccupation},
+		{"mother_occupation", filter.MotherOccupation},
+	}
+
+	for _, e := range equals {
+		switch {
+		case e.value == "":
+		case indexed(e.column):
+			conditions = append(conditions, e.column+"_bidx = ?")
+			args = append(args, index(e.column, e.value))
+		default:
+			conditions = append(conditions, e.column+" = ?")
+			args = append(args, e.value)
+		}
+	}
+
+	if filter.MaxFamilyIncome != 0 {
+		placeholders := make([]string, 0, (filter.MaxFamilyIncome-filter.MinFamilyIncome)/models.IncomeBand+1)
+
+		for income := filter.MinFamilyIncome; income <= filter.MaxFamilyIncome; income += models.IncomeBand {
+			placeholders = append(placeholders, "?")
+			args = append(args, index("family_income", bucket(income)))
+		}
+
+		conditions = append(conditions, "family_income_bidx in ("+strings.Join(placeholders, ",")+")")
+	}
+
+	if !filter.DobFrom.IsZero() {
+		conditions = append(conditions, "dob >= ?")
+		args = append(args, filter.DobFrom)
+	}
+
+	if !filter.DobTo.IsZero() {
+		conditions = append(conditions, "dob <= ?")
+		args = append(args, filter.DobTo)
+	}
+
+	if filter.IDs != nil {
+		placeholders := make([]string, 0, len(filter.IDs))
+
+		for _, id := range filter.IDs {
+			placeholders = append(placeholders, "?")
+			args = append(args, id)
+		}
+
+		if len(placeholders) == 0 {
+			conditions = append(conditions, "false")
+		} else {
+			conditions = append(conditions, "id in ("+strings.Join(placeholders, ",")+")")
+		}
+	}
+
+	return " where " + strings.Join(conditions, " and "), args
+}
+
+// writable maps every column an update may set to its value in student.
+func writable(student *models.Student) map[string]interface{} {
+	return map[string]interface{}{
+		"first_name":               student.FirstName,
+		"last_name":                student.LastName,
+		"gender":                   student.Gender,
+		"dob":                      student.Dob,
+		"mother_tongue":            student.MotherTongue,
+		"nationality":              student.Nationality,
+		"father_name":              student.FatherName,
+		"mother_name":              student.MotherName,
+		"contact_number":           student.ContactNumber,
+		"home_contact_number":      student.HomeContactNumber,
+		"emergency_contact_number": student.EmergencyContactNumber,
+		"father_occupation":        student.FatherOccupation,
+		"mother_occupation":        student.MotherOccupation,
+		"family_income":            student.FamilyIncome,
+	}
+}
+
+// orderClause builds the order by clause, always ending on id so that pages are stable. The encrypted columns
+// cannot be sorted on.
+func orderClause(sorts []models.Sort) string {
+	order := make([]string, 0, len(sorts)+1)
+
+	for _, s := range sorts {
+		column, ok := sortColumn(s.Field)
+		if !ok {
+			continue
+		}
+
+		if s.Desc {
+			column += " desc"
+		}
+
+		order = append(order, column)
+	}
+
+	order = append(order, "id")
+
+	return " order by " + strings.Join(order, ",")
+}
+
+func sortColumn(field string) (string, bool) {
+	switch field {
+	case "id", "first_name", "last_name", "gender", "dob", "mother_tongue", "nationality", "father_occupation",
+		"mother_occupation", "deleted_at":
+		return field, true
+	default:
+		return "", false
+	}
+}
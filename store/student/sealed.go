@@ -0,0 +1,264 @@
+package student
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"student-management-system/models"
+	"student-management-system/store/sqltx"
+)
+
+const (
+	// sensitiveColumns are stored encrypted.
+	sensitiveColumns = "father_name,mother_name,contact_number,home_contact_number,emergency_contact_number,family_income"
+	// indexColumns hold the blind indexes of the sensitive columns with exact-match filters, and of the band of the
+	// family income for its range filters.
+	indexColumns = "father_name_bidx,mother_name_bidx,contact_number_bidx,family_income_bidx"
+
+	// rotateBatch is the most students Rotate reads at a time.
+	rotateBatch = 500
+)
+
+// sealer encrypts the sensitive columns and computes their blind indexes.
+type sealer interface {
+	Seal(column, plaintext string) (string, error)
+	Open(column, value string) (string, error)
+	Current(value string) bool
+	Index(column, value string) string
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// stored is a student as Rotate reads it, with the values its rewritten columns hold.
+type stored struct {
+	student models.Student
+	columns []sql.NullString
+}
+
+// withColumns scans the columns of a student followed by further columns into dest.
+type withColumns struct {
+	row  scanner
+	dest []interface{}
+}
+
+func (w withColumns) Scan(dest ...interface{}) error {
+	return w.row.Scan(append(dest, w.dest...)...)
+}
+
+// bucket is the band of a family income, which the range filters on the encrypted income are matched on through
+// its blind index, so that the bands are not readable from the database either.
+func bucket(income int) string {
+	return strconv.Itoa(income / models.IncomeBand)
+}
+
+// indexed reports whether column has a blind index.
+func indexed(column string) bool {
+	return column == "father_name" || column == "mother_name" || column == "contact_number"
+}
+
+// sensitive returns the text fields of student stored encrypted, by column.
+func sensitive(student *models.Student) map[string]*string {
+	return map[string]*string{
+		"father_name":              &student.FatherName,
+		"mother_name":              &student.MotherName,
+		"contact_number":           &student.ContactNumber,
+		"home_contact_number":      &student.HomeContactNumber,
+		"emergency_contact_number": &student.EmergencyContactNumber,
+	}
+}
+
+// values maps every column an insert or update may set to its stored value for student: the sensitive columns
+// sealed, the blind indexes of the non-empty indexed ones and of the band of the family income, and the keyed hash
+// of its identity.
+func (s store) values(student *models.Student) (map[string]interface{}, error) {
+	values := writable(student)
+	values["identity_key"] = s.sealer.Index("identity_key", student.Identity())
+	values["family_income_bidx"] = s.sealer.Index("family_income", bucket(student.FamilyIncome))
+
+	plain := map[string]string{"family_income": strconv.Itoa(student.FamilyIncome)}
+	for column, field := range sensitive(student) {
+		plain[column] = *field
+	}
+
+	for column, value := range plain {
+		sealed, err := s.sealer.Seal(column, value)
+		if err != nil {
+			return nil, err
+		}
+
+		values[column] = sealed
+
+		if indexed(column) {
+			values[column+"_bidx"] = nil
+
+			if value != "" {
+				values[column+"_bidx"] = s.sealer.Index(column, value)
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// args returns the stored values of the given comma separated columns, in order.
+func args(values map[string]interface{}, columns string) []interface{} {
+	cols := strings.Split(columns, ",")
+	args := make([]interface{}, 0, len(cols))
+
+	for _, c := range cols {
+		args = append(args, values[c])
+	}
+
+	return args
+}
+
+// scan reads a student selected with columns, opening its sensitive columns.
+func (s store) scan(row scanner) (models.Student, error) {
+	var (
+		student models.Student
+		income  string
+	)
+
+	err := row.Scan(&student.ID, &student.FirstName, &student.LastName, &student.Gender, &student.Dob, &student.MotherTongue,
+		&student.Nationality, &student.FatherName, &student.MotherName, &student.ContactNumber, &student.HomeContactNumber,
+		&student.EmergencyContactNumber, &student.FatherOccupation, &student.MotherOccupation, &income,
+		&student.Version, &student.DeletedAt)
+	if err != nil {
+		return models.Student{}, err
+	}
+
+	for column, field := range sensitive(&student) {
+		if *field, err = s.sealer.Open(column, *field); err != nil {
+			return models.Student{}, err
+		}
+	}
+
+	if income, err = s.sealer.Open("family_income", income); err != nil {
+		return models.Student{}, err
+	}
+
+	if income != "" {
+		if student.FamilyIncome, err = strconv.Atoi(income); err != nil {
+			return models.Student{}, err
+		}
+	}
+
+	return student, nil
+}
+
+// Rotate re-encrypts the sensitive columns of every student, in the trash too, under the active key and
+// recomputes their blind indexes and identity key, and returns how many students it rewrote. A
+// student whose columns are all sealed under the active key and whose other columns are as they would be written
+// now is left alone, so that running it again only rewrites what changed. The version is left alone, as the
+// student does not change; a student updated meanwhile is skipped, since it was written under the active key. A
+// student that duplicates an active student stored before it keeps no identity key.
+func (s store) Rotate(ctx context.Context) (int, error) {
+	rewritten := sensitiveColumns + "," + indexColumns + ",identity_key"
+	query := "select " + columns + "," + rewritten + " from " + string(models.TableName) +
+		" where id > ? order by id limit ?;"
+	update := "update " + string(models.TableName) + " set " + strings.ReplaceAll(rewritten, ",", " = ?,") +
+		" = ? where id = ? and version = ?;"
+
+	var total, last int
+
+	for {
+		students, err := s.batch(ctx, query, last, strings.Count(rewritten, ",")+1)
+		if err != nil {
+			return total, err
+		}
+
+		for i := range students {
+			student := &students[i].student
+			last = student.ID
+
+			values, err := s.values(student)
+			if err != nil {
+				return total, err
+			}
+
+			if s.current(strings.Split(rewritten, ","), students[i].columns, values) {
+				continue
+			}
+
+			res, err := sqltx.From(ctx, s.db).ExecContext(ctx, update,
+				append(args(values, rewritten), student.ID, student.Version)...)
+			if isDuplicate(err) {
+				values["identity_key"] = nil
+
+				res, err = sqltx.From(ctx, s.db).ExecContext(ctx, update,
+					append(args(values, rewritten), student.ID, student.Version)...)
+			}
+
+			if err != nil {
+				return total, err
+			}
+
+			if err := updated(res); err == nil {
+				total++
+			} else if err != sql.ErrNoRows {
+				return total, err
+			}
+		}
+
+		if len(students) < rotateBatch {
+			return total, nil
+		}
+	}
+}
+
+// current reports whether a student needs no rewrite: the stored values of its sensitive columns are sealed under
+// the active key, and those of its other columns equal the values they would be written with now, computed
+// with the index key and income band in use.
+func (s store) current(columns []string, stored []sql.NullString, values map[string]interface{}) bool {
+	sensitive := strings.Count(sensitiveColumns, ",") + 1
+
+	for i, column := range columns {
+		if i < sensitive {
+			if !s.sealer.Current(stored[i].String) {
+				return false
+			}
+
+			continue
+		}
+
+		value := values[column]
+		if value == nil && stored[i].Valid || value != nil && (!stored[i].Valid || stored[i].String != fmt.Sprint(value)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s store) batch(ctx context.Context, query string, after, extra int) ([]stored, error) {
+	rows, err := sqltx.From(ctx, s.db).QueryContext(ctx, query, after, rotateBatch)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var students []stored
+
+	for rows.Next() {
+		row := stored{columns: make([]sql.NullString, extra)}
+		dest := make([]interface{}, extra)
+
+		for i := range row.columns {
+			dest[i] = &row.columns[i]
+		}
+
+		if row.student, err = s.scan(withColumns{row: rows, dest: dest}); err != nil {
+			return nil, err
+		}
+
+		students = append(students, row)
+	}
+
+	return students, rows.Err()
+}
@@ -0,0 +1,84 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+
+	"student-management-system/models"
+	"student-management-system/store/sqltx"
+)
+
+type store struct {
+	db *sql.DB
+}
+
+func New(db *sql.DB) store {
+	return store{db: db}
+}
+
+func (s store) GetByUsername(ctx context.Context, username string) (models.User, error) {
+	var user models.User
+
+	query := "select id,username,password_hash,role,created_at from " + string(models.UserTableName) + " where username = ?;"
+
+	err := sqltx.From(ctx, s.db).QueryRowContext(ctx, query, username).Scan(&user.ID, &user.Username, &user.PasswordHash,
+		&user.Role, &user.CreatedAt)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	return user, nil
+}
+
+func (s store) Post(ctx context.Context, user *models.User) (models.User, error) {
+	query := "insert into " + string(models.UserTableName) + " (username,password_hash,role,created_at) values (?,?,?,?);"
+
+	res, err := sqltx.From(ctx, s.db).ExecContext(ctx, query, user.Username, user.PasswordHash, user.Role, user.CreatedAt)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	ID, err := res.LastInsertId()
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user.ID = int(ID)
+
+	return *user, nil
+}
+
+// AddStudent makes username a guardian of the student. Adding an existing link does nothing.
+func (s store) AddStudent(ctx context.Context, username string, studentID int) error {
+	query := "insert ignore into " + string(models.GuardianTableName) + " (username,student_id) values (?,?);"
+
+	_, err := sqltx.From(ctx, s.db).ExecContext(ctx, query, username, studentID)
+
+	return err
+}
+
+// GetStudentIDs returns the IDs of the students username is a guardian of, in ascending order.
+func (s store) GetStudentIDs(ctx context.Context, username string) ([]int, error) {
+	query := "select student_id from " + string(models.GuardianTableName) + " where username = ? order by student_id;"
+
+	rows, err := sqltx.From(ctx, s.db).QueryContext(ctx, query, username)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ids := make([]int, 0)
+
+	for rows.Next() {
+		var id int
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
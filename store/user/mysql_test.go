@@ -0,0 +1,139 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"student-management-system/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetByUsername(t *testing.T) {
+	at := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	query := "select id,username,password_hash,role,created_at from " + string(models.UserTableName) + " where username = ?;"
+	cols := []string{"id", "username", "password_hash", "role", "created_at"}
+
+	testcases := []struct {
+		desc      string
+		expRows   *sqlmock.Rows
+		expOutput models.User
+		expErr    error
+	}{
+		{desc: "success:found", expRows: sqlmock.NewRows(cols).AddRow(1, "admin", "$2a$12$hash", "admin", at),
+			expOutput: models.User{ID: 1, Username: "admin", PasswordHash: "$2a$12$hash", Role: "admin", CreatedAt: at}},
+		{desc: "failure:no such user", expRows: sqlmock.NewRows(cols), expErr: sql.ErrNoRows},
+	}
+
+	for i, tc := range testcases {
+		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			log.Println(err.Error())
+		}
+
+		mock.ExpectQuery(query).WithArgs("admin").WillReturnRows(tc.expRows)
+
+		s := New(db)
+
+		res, err := s.GetByUsername(context.TODO(), "admin")
+
+		if !reflect.DeepEqual(tc.expOutput, res) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expOutput, res)
+		}
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
+
+func TestPost(t *testing.T) {
+	at := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	query := "insert into " + string(models.UserTableName) + " (username,password_hash,role,created_at) values (?,?,?,?);"
+
+	testcases := []struct {
+		desc      string
+		expOutput models.User
+		expErr    error
+	}{
+		{desc: "success:created", expOutput: models.User{ID: 3, Username: "admin", PasswordHash: "$2a$12$hash", Role: "admin",
+			CreatedAt: at}},
+		{desc: "failure:insert error", expErr: errors.New("insert error")},
+	}
+
+	for i, tc := range testcases {
+		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			log.Println(err.Error())
+		}
+
+		mock.ExpectExec(query).WithArgs("admin", "$2a$12$hash", "admin", at).WillReturnResult(sqlmock.NewResult(3, 1)).
+			WillReturnError(tc.expErr)
+
+		s := New(db)
+
+		res, err := s.Post(context.TODO(), &models.User{Username: "admin", PasswordHash: "$2a$12$hash", Role: "admin",
+			CreatedAt: at})
+
+		if !reflect.DeepEqual(tc.expOutput, res) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expOutput, res)
+		}
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
+
+func TestAddStudent(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		log.Println(err.Error())
+	}
+
+	mock.ExpectExec("insert ignore into "+string(models.GuardianTableName)+" (username,student_id) values (?,?);").
+		WithArgs("parent", 7).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := New(db).AddStudent(context.TODO(), "parent", 7); err != nil {
+		t.Errorf("expected %v got %v", nil, err)
+	}
+}
+
+func TestGetStudentIDs(t *testing.T) {
+	query := "select student_id from " + string(models.GuardianTableName) + " where username = ? order by student_id;"
+
+	testcases := []struct {
+		desc      string
+		expRows   *sqlmock.Rows
+		expOutput []int
+		expErr    error
+	}{
+		{desc: "success:children", expRows: sqlmock.NewRows([]string{"student_id"}).AddRow(3).AddRow(7), expOutput: []int{3, 7}},
+		{desc: "success:none", expRows: sqlmock.NewRows([]string{"student_id"}), expOutput: []int{}},
+		{desc: "failure:select error", expRows: sqlmock.NewRows([]string{"student_id"}), expErr: errors.New("select error")},
+	}
+
+	for i, tc := range testcases {
+		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			log.Println(err.Error())
+		}
+
+		mock.ExpectQuery(query).WithArgs("parent").WillReturnRows(tc.expRows).WillReturnError(tc.expErr)
+
+		res, err := New(db).GetStudentIDs(context.TODO(), "parent")
+
+		if !reflect.DeepEqual(tc.expOutput, res) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expOutput, res)
+		}
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package tabular
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"student-management-system/errors"
+	"student-management-system/models"
+)
+
+// Format is a file format students are exported in.
+type Format string
+
+const (
+	CSV       Format = "csv"
+	JSONLines Format = "jsonl"
+	XLSX      Format = "xlsx"
+)
+
+// ContentType is the media type of files in the format.
+func (f Format) ContentType() string {
+	switch f {
+	case JSONLines:
+		return "application/x-ndjson"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "text/csv"
+	}
+}
+
+// Encoder writes students to a file one at a time. Close completes the file, and must be called even when no
+// student was written.
+type Encoder interface {
+	Encode(student *models.Student) error
+	Close() error
+}
+
+// exportFields are the columns of the exported files, which Import reads back once the id column is left out.
+var exportFields = []string{"id", "first_name", "last_name", "gender", "dob", "mother_tongue", "nationality", "father_name",
+	"mother_name", "contact_number", "home_contact_number", "emergency_contact_number", "father_occupation",
+	"mother_occupation", "family_income"}
+
+// NewEncoder returns an encoder writing students to w in the format f.
+func NewEncoder(w io.Writer, f Format) (Encoder, error) {
+	switch f {
+	case CSV:
+		return &csvEncoder{w: csv.NewWriter(w)}, nil
+	case JSONLines:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+
+		return jsonEncoder{enc: enc}, nil
+	case XLSX:
+		return &xlsxEncoder{w: w}, nil
+	default:
+		return nil, errors.InvalidParam{Field: "format", Reason: "must be csv, jsonl or xlsx"}
+	}
+}
+
+type csvEncoder struct {
+	w      *csv.Writer
+	header bool
+}
+
+func (e *csvEncoder) Encode(student *models.Student) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	rec := record(student)
+	for i := range rec {
+		rec[i] = escape(rec[i])
+	}
+
+	return e.w.Write(rec)
+}
+
+func (e *csvEncoder) Close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	e.w.Flush()
+
+	return e.w.Error()
+}
+
+func (e *csvEncoder) writeHeader() error {
+	if e.header {
+		return nil
+	}
+
+	e.header = true
+
+	return e.w.Write(exportFields)
+}
+
+// formulaStarts are the first characters of the cells spreadsheets evaluate as formulas, and the quote that
+// marks a cell as text.
+const formulaStarts = "=+-@\t\r'"
+
+// escape puts a quote in front of a CSV cell that starts like a formula, such as a contact number with its
+// country code, so that spreadsheets read it as text instead of running it. Cells starting with a quote get one
+// too, so that unescape takes off exactly the quotes escape added.
+func escape(cell string) string {
+	if cell != "" && strings.IndexByte(formulaStarts, cell[0]) >= 0 {
+		return "'" + cell
+	}
+
+	return cell
+}
+
+// unescape takes off the quote escape put in front of a cell.
+func unescape(cell string) string {
+	if len(cell) > 1 && cell[0] == '\'' && strings.IndexByte(formulaStarts, cell[1]) >= 0 {
+		return cell[1:]
+	}
+
+	return cell
+}
+
+type jsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e jsonEncoder) Encode(student *models.Student) error {
+	return e.enc.Encode(student)
+}
+
+func (e jsonEncoder) Close() error {
+	return nil
+}
+
+// record returns the values of the exportFields of student, leaving empty the dob and family income not set.
+func record(student *models.Student) []string {
+	rec := make([]string, len(exportFields))
+	text := texts(student)
+
+	for i, field := range exportFields {
+		switch field {
+		case "id":
+			rec[i] = strconv.Itoa(student.ID)
+		case "dob":
+			if !student.Dob.IsZero() {
+				rec[i] = student.Dob.String()
+			}
+		case "family_income":
+			if student.FamilyIncome != 0 {
+				rec[i] = strconv.Itoa(student.FamilyIncome)
+			}
+		default:
+			rec[i] = *text[field]
+		}
+	}
+
+	return rec
+}
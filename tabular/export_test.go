@@ -0,0 +1,161 @@
+package tabular
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"student-management-system/errors"
+	"student-management-system/models"
+)
+
+func TestEncoder(t *testing.T) {
+	students := []models.Student{
+		{ID: 1, FirstName: "Asha", Dob: models.NewDate(2010, time.April, 1), Nationality: "Indian", FamilyIncome: 50000},
+		{ID: 2, FirstName: "Ravi, Jr", FatherName: `R & "K"`},
+	}
+
+	testcases := []struct {
+		desc     string
+		format   Format
+		students []models.Student
+		expRes   string
+		expErr   error
+	}{
+		{desc: "success:csv", format: CSV, students: students, expRes: strings.Join(exportFields, ",") + "\n" +
+			"1,Asha,,,2010-04-01,,Indian,,,,,,,,50000\n" +
+			"2,\"Ravi, Jr\",,,,,,\"R & \"\"K\"\"\",,,,,,,\n"},
+		{desc: "success:csv formulas are text", format: CSV, students: []models.Student{{ID: 3, FirstName: "=1+2",
+			LastName: "@SUM(A1)", MotherName: "'Rani", ContactNumber: "+919876543210", FatherOccupation: "-"}},
+			expRes: strings.Join(exportFields, ",") + "\n" + "3,'=1+2,'@SUM(A1),,,,,,''Rani,'+919876543210,,,'-,,\n"},
+		{desc: "success:csv without students has a header", format: CSV, expRes: strings.Join(exportFields, ",") + "\n"},
+		{desc: "success:json lines", format: JSONLines, students: students,
+			expRes: `{"id":1,"first_name":"Asha","dob":"2010-04-01","nationality":"Indian","family_income":50000}` + "\n" +
+				`{"id":2,"first_name":"Ravi, Jr","dob":null,"father_name":"R & \"K\""}` + "\n"},
+		{desc: "success:xlsx sheet", format: XLSX, students: students[1:], expRes: `<row r="1"><c r="A1" t="inlineStr">` +
+			`<is><t xml:space="preserve">id</t></is></c>`},
+		{desc: "failure:unknown format", format: "pdf", expErr: errors.InvalidParam{Field: "format", Reason: "must be csv, jsonl or xlsx"}},
+	}
+
+	for i, tc := range testcases {
+		var b bytes.Buffer
+
+		enc, err := NewEncoder(&b, tc.format)
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+
+		if err != nil {
+			continue
+		}
+
+		for j := range tc.students {
+			if err := enc.Encode(&tc.students[j]); err != nil {
+				t.Errorf("testcases %d failed expected %v got %v", i+1, nil, err)
+			}
+		}
+
+		if err := enc.Close(); err != nil {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, nil, err)
+		}
+
+		res := b.String()
+		if tc.format == XLSX {
+			res = sheet(t, b.Bytes())
+		}
+
+		if !strings.Contains(res, tc.expRes) || tc.format != XLSX && res != tc.expRes {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes, res)
+		}
+	}
+}
+
+func TestXLSX(t *testing.T) {
+	var b bytes.Buffer
+
+	enc, _ := NewEncoder(&b, XLSX)
+
+	if err := enc.Encode(&models.Student{ID: 2, FirstName: "R & K", FamilyIncome: 100}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := `<row r="2"><c r="A2"><v>2</v></c><c r="B2" t="inlineStr"><is><t xml:space="preserve">R &amp; K</t></is></c>` +
+		`<c r="O2"><v>100</v></c></row></sheetData></worksheet>`
+
+	if res := sheet(t, b.Bytes()); !strings.HasSuffix(res, exp) {
+		t.Errorf("expected %v got %v", exp, res)
+	}
+}
+
+func TestExport_ImportedBack(t *testing.T) {
+	student := models.Student{FirstName: "Asha", LastName: "'Rao", Dob: models.NewDate(2010, time.April, 1),
+		Nationality: "Indian", FatherName: "=Ravi", ContactNumber: "+919876543210", FamilyIncome: 50000}
+
+	var b bytes.Buffer
+
+	enc, _ := NewEncoder(&b, CSV)
+	exported := student
+	exported.ID = 9
+
+	if err := enc.Encode(&exported); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var imported []models.BatchItem
+
+	_, err := Import(context.Background(), &b, Mapping{"id": skip}, models.MaxImport,
+		func(ctx context.Context, items []models.BatchItem) ([]models.BatchResult, error) {
+			imported = items
+
+			return make([]models.BatchResult, len(items)), nil
+		})
+
+	exp := []models.BatchItem{{Student: &student}}
+
+	if err != nil || !reflect.DeepEqual(exp, imported) {
+		t.Errorf("expected %v got %v, %v", exp, imported, err)
+	}
+}
+
+// sheet returns the worksheet of a workbook.
+func sheet(t *testing.T, workbook []byte) string {
+	r, err := zip.NewReader(bytes.NewReader(workbook), int64(len(workbook)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range r.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		content, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return string(content)
+	}
+
+	t.Fatal("no sheet in the workbook")
+
+	return ""
+}
@@ -0,0 +1,264 @@
+// Package tabular reads students from and writes them to files of rows, such as the CSV admission lists kept in
+// spreadsheets.
+package tabular
+
+import (
+	"context"
+	"encoding/csv"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"student-management-system/errors"
+	"student-management-system/models"
+)
+
+// skip maps a header to no field, for columns of a file that are not imported.
+const skip = "-"
+
+// Mapping maps the headers of a file to the json names of the student fields they hold. Headers it does not
+// mention must be field names themselves.
+type Mapping map[string]string
+
+// Result is the outcome of a row of a file: the ID of the student created from it, or the error it failed with.
+type Result struct {
+	Line int
+	ID   int
+	Err  error
+}
+
+type importFunc func(ctx context.Context, items []models.BatchItem) ([]models.BatchResult, error)
+
+// row is a record of a file with the line it starts on, and the student it holds or the errors reading it.
+type row struct {
+	line    int
+	student models.Student
+	err     error
+}
+
+// ParseMapping parses header=field pairs, e.g. "Surname=last_name". A field of "-" leaves the column out.
+func ParseMapping(pairs []string) (Mapping, error) {
+	m := make(Mapping, len(pairs))
+
+	for _, p := range pairs {
+		i := strings.LastIndex(p, "=")
+		if i < 0 {
+			return nil, errors.InvalidParam{Field: "map", Reason: fmt.Sprintf("%q must be header=field", p)}
+		}
+
+		header, field := strings.TrimSpace(p[:i]), strings.TrimSpace(p[i+1:])
+		if field != skip && !isField(field) {
+			return nil, errors.InvalidParam{Field: "map", Reason: field + " is not a student field"}
+		}
+
+		m[header] = field
+	}
+
+	return m, nil
+}
+
+// Import reads the students of a CSV file whose first line names the field of every column, and creates them
+// with fn, reporting the outcome of every row. A row that cannot be read fails alone, while a malformed file or
+// header fails the import, and so does a file of more than max rows as soon as the row after the last allowed
+// one is read. The quote an export puts in front of a value that starts like a formula is taken off.
+func Import(ctx context.Context, r io.Reader, m Mapping, max int, fn importFunc) ([]Result, error) {
+	rows, err := read(r, m, max)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.InvalidParam{Field: "body", Reason: "has no rows"}
+	}
+
+	items := make([]models.BatchItem, 0, len(rows))
+
+	for i := range rows {
+		if rows[i].err == nil {
+			items = append(items, models.BatchItem{Student: &rows[i].student})
+		}
+	}
+
+	var created []models.BatchResult
+
+	if len(items) > 0 {
+		if created, err = fn(ctx, items); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]Result, len(rows))
+
+	for i, j := 0, 0; i < len(rows); i++ {
+		results[i] = Result{Line: rows[i].line, Err: rows[i].err}
+
+		if rows[i].err == nil {
+			results[i].ID, results[i].Err = created[j].ID, created[j].Err
+			j++
+		}
+	}
+
+	return results, nil
+}
+
+// readErr reports a file that cannot be read as an invalid body, unless the reader refused to read past the
+// size it allows.
+func readErr(err error) error {
+	var tooLarge errors.PayloadTooLarge
+	if stdErrors.As(err, &tooLarge) {
+		return tooLarge
+	}
+
+	return errors.InvalidParam{Field: "body", Reason: err.Error()}
+}
+
+func read(r io.Reader, m Mapping, max int) ([]row, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.InvalidParam{Field: "body", Reason: "has no header"}
+	}
+
+	if err != nil {
+		return nil, readErr(err)
+	}
+
+	columns, err := columnsOf(header, m)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []row
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+
+		if err != nil {
+			return nil, readErr(err)
+		}
+
+		if len(rows) == max {
+			return nil, errors.InvalidParam{Field: "body", Reason: "has more than " + strconv.Itoa(max) + " rows"}
+		}
+
+		line, _ := cr.FieldPos(0)
+		rows = append(rows, parse(line, record, columns))
+	}
+}
+
+// columnsOf returns the field of every column of the header, "-" for the columns left out.
+func columnsOf(header []string, m Mapping) ([]string, error) {
+	columns := make([]string, len(header))
+	seen := make(map[string]bool, len(header))
+
+	for i, h := range header {
+		// spreadsheets often start the file with a byte order mark
+		h = strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))
+
+		field, ok := m[h]
+		if !ok {
+			field = h
+		}
+
+		switch {
+		case field == skip:
+		case !isField(field):
+			return nil, errors.InvalidParam{Field: h, Reason: "is not a student field, map it to one with header=field"}
+		case seen[field]:
+			return nil, errors.InvalidParam{Field: h, Reason: "is a second column for " + field}
+		}
+
+		seen[field] = true
+		columns[i] = field
+	}
+
+	return columns, nil
+}
+
+func parse(line int, record, columns []string) row {
+	res := row{line: line}
+
+	if len(record) != len(columns) {
+		res.err = errors.InvalidParam{Field: "row", Reason: fmt.Sprintf("has %d columns, the header has %d", len(record), len(columns))}
+
+		return res
+	}
+
+	var errs []errors.FieldError
+
+	for i, field := range columns {
+		if field == skip {
+			continue
+		}
+
+		if err := set(&res.student, field, unescape(strings.TrimSpace(record[i]))); err != nil {
+			errs = append(errs, *err)
+		}
+	}
+
+	if len(errs) > 0 {
+		res.err = errors.Validation{Errors: errs}
+	}
+
+	return res
+}
+
+// set stores the value of field in student. Empty values leave the field empty.
+func set(student *models.Student, field, value string) *errors.FieldError {
+	if value == "" {
+		return nil
+	}
+
+	switch field {
+	case "dob":
+		d, err := models.ParseDate(value)
+		if err != nil {
+			return &errors.FieldError{Field: field, Rule: "date", Message: "dob must be a date in YYYY-MM-DD format"}
+		}
+
+		student.Dob = d
+	case "family_income":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return &errors.FieldError{Field: field, Rule: "integer", Message: "family income must be a whole number"}
+		}
+
+		student.FamilyIncome = n
+	default:
+		*texts(student)[field] = value
+	}
+
+	return nil
+}
+
+func isField(field string) bool {
+	_, ok := texts(&models.Student{})[field]
+
+	return ok || field == "dob" || field == "family_income"
+}
+
+// texts returns the text fields of student by json name.
+func texts(student *models.Student) map[string]*string {
+	return map[string]*string{
+		"first_name":               &student.FirstName,
+		"last_name":                &student.LastName,
+		"gender":                   &student.Gender,
+		"mother_tongue":            &student.MotherTongue,
+		"nationality":              &student.Nationality,
+		"father_name":              &student.FatherName,
+		"mother_name":              &student.MotherName,
+		"contact_number":           &student.ContactNumber,
+		"home_contact_number":      &student.HomeContactNumber,
+		"emergency_contact_number": &student.EmergencyContactNumber,
+		"father_occupation":        &student.FatherOccupation,
+		"mother_occupation":        &student.MotherOccupation,
+	}
+}
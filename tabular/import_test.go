@@ -0,0 +1,127 @@
+package tabular
+
+import (
+	"bytes"
+	"context"
+	stdErrors "errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"student-management-system/errors"
+	"student-management-system/models"
+)
+
+func TestImport(t *testing.T) {
+	testcases := []struct {
+		desc     string
+		file     string
+		mapping  Mapping
+		expItems []models.BatchItem
+		created  []models.BatchResult
+		expRes   []Result
+		expErr   error
+	}{
+		{desc: "success:mapped headers and failing rows", file: "\ufeffFirst Name,nationality,dob,Notes,family_income\n" +
+			"Asha,Indian,2010-04-01,new,50000\n" +
+			"Ravi,Indian,01/04/2010,,lots\n" +
+			"Meera,\"Indian\"\n" +
+			"Meera,Indian,,,\n",
+			mapping: Mapping{"First Name": "first_name", "Notes": "-"},
+			expItems: []models.BatchItem{
+				{Student: &models.Student{FirstName: "Asha", Nationality: "Indian", Dob: models.NewDate(2010, time.April, 1),
+					FamilyIncome: 50000}},
+				{Student: &models.Student{FirstName: "Meera", Nationality: "Indian"}},
+			},
+			created: []models.BatchResult{{ID: 7, Version: 1}, {Index: 1, Err: errors.EntityAlreadyExists{Entity: "student", ID: "3"}}},
+			expRes: []Result{{Line: 2, ID: 7},
+				{Line: 3, Err: errors.Validation{Errors: []errors.FieldError{
+					{Field: "dob", Rule: "date", Message: "dob must be a date in YYYY-MM-DD format"},
+					{Field: "family_income", Rule: "integer", Message: "family income must be a whole number"}}}},
+				{Line: 4, Err: errors.InvalidParam{Field: "row", Reason: "has 2 columns, the header has 5"}},
+				{Line: 5, Err: errors.EntityAlreadyExists{Entity: "student", ID: "3"}}}},
+		{desc: "failure:unknown header", file: "first_name,Surname\nAsha,Rao\n",
+			expErr: errors.InvalidParam{Field: "Surname", Reason: "is not a student field, map it to one with header=field"}},
+		{desc: "failure:two columns for a field", file: "first_name,Name\nAsha,Asha\n", mapping: Mapping{"Name": "first_name"},
+			expErr: errors.InvalidParam{Field: "Name", Reason: "is a second column for first_name"}},
+		{desc: "failure:more rows than allowed", file: "first_name\nAsha\nRavi\nMeera\nAmit\nNeha\n",
+			expErr: errors.InvalidParam{Field: "body", Reason: "has more than 4 rows"}},
+		{desc: "failure:no rows", file: "first_name\n", expErr: errors.InvalidParam{Field: "body", Reason: "has no rows"}},
+		{desc: "failure:import error", file: "first_name\nAsha\n",
+			expItems: []models.BatchItem{{Student: &models.Student{FirstName: "Asha"}}},
+			expErr:   errors.Internal{Err: stdErrors.New("connection reset")}},
+	}
+
+	for i, tc := range testcases {
+		res, err := Import(context.Background(), strings.NewReader(tc.file), tc.mapping, 4,
+			func(ctx context.Context, items []models.BatchItem) ([]models.BatchResult, error) {
+				if !reflect.DeepEqual(tc.expItems, items) {
+					t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expItems, items)
+				}
+
+				if tc.created == nil {
+					return nil, errors.Internal{Err: stdErrors.New("connection reset")}
+				}
+
+				return tc.created, nil
+			})
+
+		if !reflect.DeepEqual(tc.expRes, res) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes, res)
+		}
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
+
+func TestParseMapping(t *testing.T) {
+	testcases := []struct {
+		desc   string
+		pairs  []string
+		expRes Mapping
+		expErr error
+	}{
+		{desc: "success:headers with spaces and skipped columns", pairs: []string{"First Name = first_name", "Notes=-"},
+			expRes: Mapping{"First Name": "first_name", "Notes": "-"}},
+		{desc: "failure:not a pair", pairs: []string{"first_name"},
+			expErr: errors.InvalidParam{Field: "map", Reason: `"first_name" must be header=field`}},
+		{desc: "failure:unknown field", pairs: []string{"Surname=surname"},
+			expErr: errors.InvalidParam{Field: "map", Reason: "surname is not a student field"}},
+	}
+
+	for i, tc := range testcases {
+		res, err := ParseMapping(tc.pairs)
+
+		if !reflect.DeepEqual(tc.expRes, res) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expRes, res)
+		}
+
+		if !reflect.DeepEqual(tc.expErr, err) {
+			t.Errorf("testcases %d failed expected %v got %v", i+1, tc.expErr, err)
+		}
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	var b bytes.Buffer
+
+	err := WriteReport(&b, []Result{
+		{Line: 2, ID: 7},
+		{Line: 3, Err: errors.Validation{Errors: []errors.FieldError{
+			{Field: "first_name", Rule: "required", Message: "first name is required"},
+			{Field: "dob", Rule: "date", Message: "dob must be a date in YYYY-MM-DD format"}}}},
+		{Line: 5, Err: errors.EntityAlreadyExists{Entity: "student", ID: "3"}},
+	})
+
+	exp := "line,field,rule,message\n" +
+		"3,first_name,required,first name is required\n" +
+		"3,dob,date,dob must be a date in YYYY-MM-DD format\n" +
+		"5,,unique,student already exists with id 3\n"
+
+	if err != nil || b.String() != exp {
+		t.Errorf("expected %v got %v, %v", exp, b.String(), err)
+	}
+}
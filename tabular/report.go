@@ -0,0 +1,49 @@
+package tabular
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"student-management-system/errors"
+)
+
+// WriteReport writes the failures of results as CSV, one line for every failing field of a row, or for the row
+// as a whole when the failure is not about a field: its line in the file, the field, the rule that failed and a
+// message.
+func WriteReport(w io.Writer, results []Result) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write([]string{"line", "field", "rule", "message"}); err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		line := strconv.Itoa(r.Line)
+
+		for _, f := range failures(r.Err) {
+			if err := cw.Write([]string{line, escape(f.Field), escape(f.Rule), escape(f.Message)}); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func failures(err error) []errors.FieldError {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case errors.Validation:
+		return e.Errors
+	case errors.EntityAlreadyExists:
+		return []errors.FieldError{{Rule: "unique", Message: e.Error()}}
+	case errors.InvalidParam:
+		return []errors.FieldError{{Field: e.Field, Rule: "invalid", Message: e.Error()}}
+	default:
+		return []errors.FieldError{{Message: e.Error()}}
+	}
+}
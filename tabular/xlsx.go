@@ -0,0 +1,152 @@
+package tabular
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"strconv"
+
+	"student-management-system/models"
+)
+
+// The parts of a workbook with a single sheet, other than the sheet itself. Cells hold inline strings, so the
+// workbook needs no shared strings or styles.
+var xlsxParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ` +
+		`ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" ` +
+		`Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="students" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" ` +
+		`Target="worksheets/sheet1.xml"/></Relationships>`},
+}
+
+// xlsxEncoder writes a workbook whose sheet is streamed row by row as the last part of the zip archive, so that
+// only the row being written is held in memory.
+type xlsxEncoder struct {
+	w     io.Writer
+	zip   *zip.Writer
+	sheet io.Writer
+	rows  int
+}
+
+func (e *xlsxEncoder) Encode(student *models.Student) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+
+	return e.row(record(student), true)
+}
+
+func (e *xlsxEncoder) Close() error {
+	if err := e.start(); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(e.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+
+	return e.zip.Close()
+}
+
+// start writes the parts before the sheet and the header row, the first time it is called.
+func (e *xlsxEncoder) start() error {
+	if e.zip != nil {
+		return nil
+	}
+
+	e.zip = zip.NewWriter(e.w)
+
+	for _, p := range xlsxParts {
+		f, err := e.zip.Create(p.name)
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.WriteString(f, p.content); err != nil {
+			return err
+		}
+	}
+
+	sheet, err := e.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+
+	e.sheet = sheet
+
+	_, err = io.WriteString(e.sheet, xml.Header+`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err != nil {
+		return err
+	}
+
+	return e.row(exportFields, false)
+}
+
+// row writes a row of cells. With numbers, the id and family income columns are written as numbers.
+func (e *xlsxEncoder) row(values []string, numbers bool) error {
+	e.rows++
+	n := strconv.Itoa(e.rows)
+
+	if _, err := io.WriteString(e.sheet, `<row r="`+n+`">`); err != nil {
+		return err
+	}
+
+	for i, v := range values {
+		ref := column(i) + n
+
+		if err := e.cell(ref, v, numbers && (exportFields[i] == "id" || exportFields[i] == "family_income")); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(e.sheet, "</row>")
+
+	return err
+}
+
+// cell writes the cell ref, e.g. "B2", leaving it out when value is empty.
+func (e *xlsxEncoder) cell(ref, value string, number bool) error {
+	var err error
+
+	switch {
+	case value == "":
+	case number:
+		_, err = io.WriteString(e.sheet, `<c r="`+ref+`"><v>`+value+"</v></c>")
+	default:
+		if _, err = io.WriteString(e.sheet, `<c r="`+ref+`" t="inlineStr"><is><t xml:space="preserve">`); err != nil {
+			return err
+		}
+
+		if err = xml.EscapeText(e.sheet, []byte(value)); err != nil {
+			return err
+		}
+
+		_, err = io.WriteString(e.sheet, "</t></is></c>")
+	}
+
+	return err
+}
+
+// column returns the letters of the column at index i, e.g. "A" for 0 and "AA" for 26.
+func column(i int) string {
+	name := ""
+
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+
+	return name
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"student-management-system/auth"
+	"student-management-system/config"
+	"student-management-system/policy"
+	user2 "student-management-system/service/user"
+	"student-management-system/store/user"
+)
+
+const usersUsage = "usage: user add <username> <role> < password-file | user link <username> <student-id>"
+
+// users runs the "user" subcommand:
+//
+//	user add <username> <role>
+//	user link <username> <student-id>
+//
+// add reads the password from the first line of stdin, so that it stays out of the shell history and process
+// list. link makes the user a guardian of the student, for roles that may only read their own students.
+func users(ctx context.Context, db *sql.DB, cfg *config.Auth, args []string) error {
+	if len(args) != 3 {
+		return errors.New(usersUsage)
+	}
+
+	perms, err := policy.New(cfg.Roles)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := auth.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	svc := user2.New(user.New(db), tokens, perms)
+
+	switch args[0] {
+	case "add":
+		password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+
+		u, err := svc.Create(ctx, args[1], strings.TrimRight(password, "\r\n"), args[2])
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Printf("created user %d %s with role %s\n", u.ID, u.Username, u.Role)
+
+		return err
+	case "link":
+		id, err := strconv.Atoi(args[2])
+		if err != nil {
+			return errors.New(usersUsage)
+		}
+
+		if err := svc.Link(ctx, args[1], id); err != nil {
+			return err
+		}
+
+		_, err = fmt.Printf("linked user %s to student %d\n", args[1], id)
+
+		return err
+	default:
+		return errors.New(usersUsage)
+	}
+}